@@ -8,8 +8,9 @@ import (
 )
 
 type ChecksumCalculator interface {
-	SuggestTalismanRC(fileNamePatterns []string) string
+	SuggestTalismanRC(fileNamePatterns []string, checksumVersion int) string
 	CalculateCollectiveChecksumForPattern(fileNamePattern string) string
+	CalculateChecksumForPattern(fileNamePattern string, checksumVersion int) string
 }
 
 type checksumCalculator struct {
@@ -22,14 +23,14 @@ func NewChecksumCalculator(hasher utility.SHA256Hasher, gitAdditions []gitrepo.A
 	return &checksumCalculator{hasher: hasher, allTrackedFiles: gitAdditions}
 }
 
-// SuggestTalismanRC returns the suggestion for .talismanrc format
-func (cc *checksumCalculator) SuggestTalismanRC(fileNamePatterns []string) string {
+// SuggestTalismanRC returns the suggestion for .talismanrc format, with checksums calculated using the given scheme
+func (cc *checksumCalculator) SuggestTalismanRC(fileNamePatterns []string, checksumVersion int) string {
 	var fileIgnoreConfigs []talismanrc.FileIgnoreConfig
 	result := strings.Builder{}
 	for _, pattern := range fileNamePatterns {
-		collectiveChecksum := cc.CalculateCollectiveChecksumForPattern(pattern)
-		if collectiveChecksum != "" {
-			fileIgnoreConfigs = append(fileIgnoreConfigs, talismanrc.IgnoreFileWithChecksum(pattern, collectiveChecksum))
+		checksum := cc.CalculateChecksumForPattern(pattern, checksumVersion)
+		if checksum != "" {
+			fileIgnoreConfigs = append(fileIgnoreConfigs, talismanrc.IgnoreFileWithVersionedChecksum(pattern, checksum, checksumVersion))
 		}
 	}
 	if len(fileIgnoreConfigs) != 0 {
@@ -41,17 +42,26 @@ func (cc *checksumCalculator) SuggestTalismanRC(fileNamePatterns []string) strin
 
 // CalculateCollectiveChecksumForPattern calculates and returns the checksum for files matching the input pattern
 func (cc *checksumCalculator) CalculateCollectiveChecksumForPattern(fileNamePattern string) string {
+	return cc.CalculateChecksumForPattern(fileNamePattern, talismanrc.ChecksumVersionPathAndContent)
+}
+
+// CalculateChecksumForPattern calculates and returns the checksum for files matching the input pattern using the given scheme
+func (cc *checksumCalculator) CalculateChecksumForPattern(fileNamePattern string, checksumVersion int) string {
 	var patternPaths []string
-	currentCollectiveChecksum := ""
+	currentChecksum := ""
 	for _, file := range cc.allTrackedFiles {
 		if file.Matches(fileNamePattern) {
 			patternPaths = append(patternPaths, string(file.Path))
 		}
 	}
-	// Calculate current collective checksum
 	patternPaths = utility.UniqueItems(patternPaths)
-	if len(patternPaths) != 0 {
-		currentCollectiveChecksum = cc.hasher.CollectiveSHA256Hash(patternPaths)
+	if len(patternPaths) == 0 {
+		return currentChecksum
+	}
+	if checksumVersion == talismanrc.ChecksumVersionContent {
+		currentChecksum = cc.hasher.ContentSHA256Hash(patternPaths)
+	} else {
+		currentChecksum = cc.hasher.CollectiveSHA256Hash(patternPaths)
 	}
-	return currentCollectiveChecksum
+	return currentChecksum
 }
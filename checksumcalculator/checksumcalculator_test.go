@@ -2,6 +2,7 @@ package checksumcalculator
 
 import (
 	"talisman/gitrepo"
+	"talisman/talismanrc"
 	"talisman/utility"
 	"testing"
 
@@ -76,6 +77,25 @@ func TestNewChecksumCalculator(t *testing.T) {
 		all_txt_actualCC := cc3.CalculateCollectiveChecksumForPattern(fileNamePattern3)
 		assert.Equal(t, all_txt_expectedCC, all_txt_actualCC)
 	})
+
+	t.Run("should return the same content checksum for files with the same contents in different folders", func(t *testing.T) {
+		gitAdditions := []gitrepo.Addition{
+			{
+				Path: "hello.txt",
+				Name: "hello.txt",
+			},
+			{
+				Path: "subfolder/hello.txt",
+				Name: "hello.txt",
+			},
+		}
+		expectedCC := "cd372fb85148700fa88095e3492d3f9f5beb43e555e5ff26d95f5a6adc36f8e6"
+		cc := NewChecksumCalculator(defaultSHA256Hasher, gitAdditions)
+
+		assert.Equal(t, expectedCC, cc.CalculateChecksumForPattern("hello.txt", talismanrc.ChecksumVersionContent))
+		assert.Equal(t, expectedCC, cc.CalculateChecksumForPattern("subfolder/hello.txt", talismanrc.ChecksumVersionContent))
+		assert.Equal(t, expectedCC, cc.CalculateChecksumForPattern("*.txt", talismanrc.ChecksumVersionContent))
+	})
 }
 
 func TestDefaultChecksumCalculator_SuggestTalismanRC(t *testing.T) {
@@ -93,7 +113,7 @@ func TestDefaultChecksumCalculator_SuggestTalismanRC(t *testing.T) {
 		fileNamePatterns := []string{"*NonExistenceFileNamePattern1", "*NonExistenceFileNamePattern2", "*NonExistenceFileNamePattern3"}
 		cc := NewChecksumCalculator(defaultSHA256Hasher, gitAdditions)
 
-		actualCC := cc.SuggestTalismanRC(fileNamePatterns)
+		actualCC := cc.SuggestTalismanRC(fileNamePatterns, talismanrc.ChecksumVersionPathAndContent)
 
 		assert.Equal(t, expectedCC, actualCC)
 	})
@@ -112,7 +132,22 @@ func TestDefaultChecksumCalculator_SuggestTalismanRC(t *testing.T) {
 		fileNamePatterns := []string{"*1", "Git*2", "*NonExistenceFileNamePattern3"}
 		cc := NewChecksumCalculator(defaultSHA256Hasher, gitAdditions)
 
-		actualCC := cc.SuggestTalismanRC(fileNamePatterns)
+		actualCC := cc.SuggestTalismanRC(fileNamePatterns, talismanrc.ChecksumVersionPathAndContent)
+
+		assert.Equal(t, expectedCC, actualCC)
+	})
+
+	t.Run("should record the checksum version in suggestions for content checksums", func(t *testing.T) {
+		gitAdditions := []gitrepo.Addition{
+			{
+				Path: "GitRepoPath1",
+				Name: "GitRepoName1",
+			},
+		}
+		expectedCC := "\n\x1b[33m.talismanrc format for given file names / patterns\x1b[0m\nfileignoreconfig:\n- filename: '*1'\n  checksum: cd372fb85148700fa88095e3492d3f9f5beb43e555e5ff26d95f5a6adc36f8e6\n  checksum_version: 2\nversion: \"1.0\"\n"
+		cc := NewChecksumCalculator(defaultSHA256Hasher, gitAdditions)
+
+		actualCC := cc.SuggestTalismanRC([]string{"*1"}, talismanrc.ChecksumVersionContent)
 
 		assert.Equal(t, expectedCC, actualCC)
 	})
@@ -4,13 +4,17 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"talisman/prompt"
+	"talisman/talismanrc"
 	"testing"
 
 	"talisman/git_testing"
 
 	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -49,6 +53,12 @@ private.pem
   ignore_detectors: []
   `
 
+const talismanRCWithMisspelledKey = `
+fileignoreconfigs:
+- filename: private.pem
+  checksum: 1db800b79e6e9695adc451f77be974dc47bcd84d42873560d7767bfca30db8b1
+`
+
 const talismanRCForHelloTxtFile = `
 fileignoreconfig:
 - filename: hello.txt
@@ -56,6 +66,19 @@ fileignoreconfig:
   ignore_detectors: []
 `
 
+const talismanRCWithContentChecksumForPrivatePem = `
+fileignoreconfig:
+- filename: private.pem
+  checksum: 3d91b58504a6cc3a159005ee7b16c7ae503ca6ac2a6a3c893837083c236b864a
+  checksum_version: 2
+`
+
+const talismanRCWithPathAndContentChecksumForPrivatePem = `
+fileignoreconfig:
+- filename: private.pem
+  checksum: 1db800b79e6e9695adc451f77be974dc47bcd84d42873560d7767bfca30db8b1
+`
+
 func init() {
 	git_testing.Logger = logrus.WithField("Environment", "Debug")
 	git_testing.Logger.Debug("Acceptance test started")
@@ -107,6 +130,69 @@ func TestAddingSecretKeyShouldExitZeroIfPEMFileIsIgnored(t *testing.T) {
 	})
 }
 
+func TestIgnoresWithContentChecksumsFollowRenames(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", talismanRCWithContentChecksumForPrivatePem)
+		git.SetupBaselineFiles("simple-file")
+		moveFile(git, "private.pem", "server.pem")
+		git.Commit("server.pem", "Renamed private key")
+
+		assert.Equal(t, 0, runTalismanInPrePushMode(git), "Expected run() to return 0 as the ignore for the renamed pem file is keyed on its contents")
+	})
+}
+
+func TestIgnoresWithPathAndContentChecksumsDoNotFollowRenames(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", talismanRCWithPathAndContentChecksumForPrivatePem)
+		git.SetupBaselineFiles("simple-file")
+		moveFile(git, "private.pem", "server.pem")
+		git.Commit("server.pem", "Renamed private key")
+
+		assert.Equal(t, 1, runTalismanInPrePushMode(git), "Expected run() to return 1 as the ignore for the pem file is keyed on its path")
+	})
+}
+
+func TestSettingsCanBeOverriddenFromTheEnvironment(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("*", "add private key")
+		t.Setenv("TALISMAN_DISABLED_DETECTORS", "filename")
+
+		assert.Equal(t, 0, runTalismanInPrePushMode(git), "Expected run() to return 0 as the filename detector was disabled")
+	})
+}
+
+func TestSettingsCanBeOverriddenFromTheCommandLine(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.CreateFileWithContents(".talismanrc", "disabled_detectors: [filename]\n")
+		git.AddAndcommit("*", "add private key")
+		defer func() { options.Set = nil }()
+
+		assert.Equal(t, 0, runTalismanInPrePushMode(git), "Expected run() to return 0 as the filename detector was disabled")
+		options.Set = []string{"disabled_detectors="}
+		assert.Equal(t, 1, runTalismanInPrePushMode(git), "Expected run() to return 1 as --set enabled all detectors again")
+		options.Set = []string{"threshold=severe"}
+		assert.Equal(t, 1, runTalismanInPrePushMode(git), "Expected run() to return 1 as the override is invalid")
+	})
+}
+
+func TestPrintConfig(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", "threshold: medium\n")
+		t.Setenv("TALISMAN_THRESHOLD", "high")
+		options.PrintConfig = true
+		defer func() { options.PrintConfig = false }()
+
+		assert.Equal(t, 0, runTalisman(git), "Expected run() to return 0 after printing the configuration")
+	})
+}
+
 func TestScanningSimpleFileShouldExitZero(t *testing.T) {
 	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
 		options.Scan = false
@@ -226,6 +312,125 @@ func TestStagingSecretKeyShouldExitOneWhenPreCommitFlagIsSet(t *testing.T) {
 	})
 }
 
+func TestPushingSecretInCommitMessageShouldExitOne(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("hello.txt", "hello")
+		git.AddAndcommit("hello.txt", "Use "+awsAccessKeyIDExample)
+
+		assert.Equal(t, 1, runTalismanInPrePushMode(git), "Expected run() to return 1 and fail as a commit message contains a secret")
+	})
+}
+
+func TestPushingSecretInCommitMessageShouldExitZeroIfMetadataDetectorIsDisabled(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", "disabled_detectors: [metadata]\n")
+		git.CreateFileWithContents("hello.txt", "hello")
+		git.AddAndcommit("hello.txt", "Use "+awsAccessKeyIDExample)
+
+		assert.Equal(t, 0, runTalismanInPrePushMode(git), "Expected run() to return 0 as commit messages are not checked")
+	})
+}
+
+func TestCommitMsgHookShouldExitOneForSecretInMessage(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		messageFile := git.CreateFileWithContents("COMMIT_EDITMSG", "Use "+awsAccessKeyIDExample+"\n")
+
+		assert.Equal(t, 1, runTalismanInCommitMsgMode(git, messageFile), "Expected run() to return 1 and fail as the message contains a secret")
+	})
+}
+
+func TestCommitMsgHookShouldExitZeroForSecretOnlyInComments(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		messageFile := git.CreateFileWithContents("COMMIT_EDITMSG", "Add greeting\n# "+awsAccessKeyIDExample+"\n")
+
+		assert.Equal(t, 0, runTalismanInCommitMsgMode(git, messageFile), "Expected run() to return 0 as git leaves out comment lines")
+	})
+}
+
+func TestCommitMsgHookShouldExitOneForSecretInCommentsThatGitKeeps(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		messageFile := git.CreateFileWithContents("COMMIT_EDITMSG", "Add greeting\n# "+awsAccessKeyIDExample+"\n")
+
+		t.Run("when the message is not edited, as with git commit -m", func(t *testing.T) {
+			t.Setenv("GIT_EDITOR", ":")
+			assert.Equal(t, 1, runTalismanInCommitMsgMode(git, messageFile), "Expected run() to return 1 as git keeps comment lines of messages given with -m")
+		})
+
+		t.Run("when commit.cleanup keeps comment lines", func(t *testing.T) {
+			command := exec.Command("git", "config", "commit.cleanup", "whitespace")
+			command.Dir = git.Root()
+			if !assert.NoError(t, command.Run()) {
+				return
+			}
+			assert.Equal(t, 1, runTalismanInCommitMsgMode(git, messageFile), "Expected run() to return 1 as git keeps comment lines with commit.cleanup=whitespace")
+		})
+	})
+}
+
+func TestCommitMsgHookShouldNotListTheFilesOfTheRepository(t *testing.T) {
+	wd, _ := os.Getwd()
+	defer os.Chdir(wd)
+	os.Chdir(t.TempDir())
+	level := logrus.GetLevel()
+	defer logrus.SetLevel(level)
+	logrus.SetLevel(logrus.WarnLevel)
+	hook := &logrustest.Hook{}
+	defer logrus.StandardLogger().ReplaceHooks(logrus.StandardLogger().ReplaceHooks(logrus.LevelHooks{}))
+	logrus.AddHook(hook)
+
+	for _, mode := range []string{CommitMsg, PrepareCommitMsg} {
+		NewRunner(nil, mode).ignoreEvaluator(&talismanrc.TalismanRC{})
+	}
+
+	assert.Empty(t, hook.AllEntries(), "Expected the checksums of files not to be calculated outside of a repository")
+}
+
+func TestCommitMsgHookShouldExitZeroIfMessageIsIgnored(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: commit:message\n  ignore_detectors: [filecontent]\n")
+		messageFile := git.CreateFileWithContents("COMMIT_EDITMSG", "Use "+awsAccessKeyIDExample+"\n")
+
+		assert.Equal(t, 0, runTalismanInCommitMsgMode(git, messageFile), "Expected run() to return 0 as the message is ignored in .talismanrc")
+	})
+}
+
+func TestCommitMsgHookShouldExitOneIfMessageFileCannotBeRead(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+
+		assert.Equal(t, 1, runTalismanInCommitMsgMode(git, "MISTYPED_EDITMSG"), "Expected run() to return 1 as the message cannot be checked")
+	})
+}
+
+func TestPrepareCommitMsgHookShouldExitOneForSecretInMessage(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		messageFile := git.CreateFileWithContents("COMMIT_EDITMSG", "Use "+awsAccessKeyIDExample+"\n")
+		options.GitHook = PrepareCommitMsg
+		options.MessageFile = messageFile
+		defer func() { options.GitHook, options.MessageFile = PrePush, "" }()
+
+		assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 and fail as the message given to git commit contains a secret")
+	})
+}
+
+func TestPushingSecretInCommitMessageShouldExitZeroIfAllCommitMessagesAreIgnored(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", "fileignoreconfig:\n- filename: commit:*\n  ignore_detectors: [filecontent]\n")
+		git.CreateFileWithContents("hello.txt", "hello")
+		git.AddAndcommit("hello.txt", "Use "+awsAccessKeyIDExample)
+
+		assert.Equal(t, 0, runTalismanInPrePushMode(git), "Expected run() to return 0 as commit messages are ignored in .talismanrc")
+	})
+}
+
 func TestPatternFindsSecretKey(t *testing.T) {
 	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
 		options.Debug = false
@@ -394,12 +599,62 @@ func TestTalismanFailsIfTalismanrcIsInvalidYamlInPatternMode(t *testing.T) {
 	})
 }
 
+func TestTalismanFailsIfTalismanrcHasUnknownKeyInPrePushMode(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents(".talismanrc", talismanRCWithMisspelledKey)
+		git.AddAndcommit("*", "Misspelled Talismanrc commit")
+
+		assert.Equal(t, 1, runTalismanInPrePushMode(git), "Expected run() to return 1 as talismanrc fails validation")
+	})
+}
+
+func TestValidateSubcommand(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		options.Validate = true
+		defer func() { options.Validate = false }()
+		git.SetupBaselineFiles("simple-file")
+
+		t.Run("Succeeds for a valid .talismanrc", func(t *testing.T) {
+			git.CreateFileWithContents(".talismanrc", talismanRCDataWithFileNameAndCorrectChecksum)
+			assert.Equal(t, 0, runTalisman(git), "Expected run() to return 0 as talismanrc is valid")
+		})
+
+		t.Run("Fails for an invalid .talismanrc", func(t *testing.T) {
+			git.OverwriteFileContent(".talismanrc", talismanRCWithMisspelledKey)
+			assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 as talismanrc has an unknown key")
+		})
+
+		t.Run("Validates the .talismanrc at the top level when run from a subdirectory", func(t *testing.T) {
+			git.CreateFileWithContents("sub/.talismanrc", talismanRCDataWithFileNameAndCorrectChecksum)
+			wd, _ := os.Getwd()
+			os.Chdir(filepath.Join(git.Root(), "sub"))
+			defer func() { os.Chdir(wd) }()
+			assert.Equal(t, 1, run(prompt.NewPromptContext(false, prompt.NewPrompt())),
+				"Expected run() to return 1 as the talismanrc at the top level has an unknown key")
+		})
+
+		t.Run("Fails when there is no .talismanrc", func(t *testing.T) {
+			git.RemoveFile(".talismanrc")
+			assert.Equal(t, 1, runTalisman(git), "Expected run() to return 1 as there is no talismanrc to validate")
+		})
+	})
+}
+
 func runTalismanInPrePushMode(git *git_testing.GitTesting) int {
 	options.Debug = true
 	options.GitHook = PrePush
 	return runTalisman(git)
 }
 
+func runTalismanInCommitMsgMode(git *git_testing.GitTesting, messageFile string) int {
+	options.Debug = false
+	options.GitHook = CommitMsg
+	options.MessageFile = messageFile
+	defer func() { options.GitHook, options.MessageFile = PrePush, "" }()
+	return runTalisman(git)
+}
+
 func runTalisman(git *git_testing.GitTesting) int {
 	wd, _ := os.Getwd()
 	os.Chdir(git.Root())
@@ -412,6 +667,14 @@ func runTalisman(git *git_testing.GitTesting) int {
 	return run(promptContext)
 }
 
+func moveFile(git *git_testing.GitTesting, from, to string) {
+	command := exec.Command("git", "mv", from, to)
+	command.Dir = git.Root()
+	if output, err := command.CombinedOutput(); err != nil {
+		panic(fmt.Sprintf("unable to move %s to %s: %s", from, to, output))
+	}
+}
+
 func mockStdIn(oldSha string, newSha string) io.Reader {
 	return strings.NewReader(fmt.Sprintf("master %s master %s\n", newSha, oldSha))
 }
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"strings"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+
+	logr "github.com/sirupsen/logrus"
+)
+
+// blameAttribution attributes findings in files changed between oldCommit and newCommit to the commit that added the
+// line they were found in, according to git blame. Findings that cannot be matched to a line, such as those about the
+// name of a file, are attributed to the first line added in the range.
+func blameAttribution(repo gitrepo.GitRepo, oldCommit string, newCommit string) func(gitrepo.FilePath, helpers.Details) *gitrepo.Attribution {
+	blamed := map[gitrepo.FilePath][]gitrepo.BlamedLine{}
+	return func(filePath gitrepo.FilePath, details helpers.Details) *gitrepo.Attribution {
+		if gitrepo.IsMessagePath(string(filePath)) {
+			return nil
+		}
+		lines, ok := blamed[filePath]
+		if !ok {
+			var err error
+			lines, err = repo.Blame(oldCommit, newCommit, string(filePath))
+			if err != nil {
+				logr.Warnf("%v", err)
+			}
+			blamed[filePath] = lines
+		}
+		return attributionOfLine(lines, detectedText(details.Message))
+	}
+}
+
+func attributionOfLine(lines []gitrepo.BlamedLine, text string) *gitrepo.Attribution {
+	var firstAdded *gitrepo.Attribution
+	for i := range lines {
+		if lines[i].Boundary {
+			continue
+		}
+		if text != "" && strings.Contains(lines[i].Content, text) {
+			return &lines[i].Attribution
+		}
+		if firstAdded == nil {
+			firstAdded = &lines[i].Attribution
+		}
+	}
+	return firstAdded
+}
+
+// detectedText returns the text a detector reported as found, which its messages end with, as in
+// "Expected file to not contain base64 encoded texts such as: <text>". Text cut short for reporting is cut at the ellipsis.
+func detectedText(message string) string {
+	separator := strings.LastIndex(message, ": ")
+	if separator < 0 {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimSuffix(message[separator+2:], "..."))
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"os"
+	"talisman/git_testing"
+	"talisman/gitrepo"
+	"talisman/prompt"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectedTextIsTheEndOfTheMessage(t *testing.T) {
+	assert.Equal(t, "c2VjcmV0", detectedText("Expected file to not contain base64 encoded texts such as: c2VjcmV0"))
+	assert.Equal(t, "aVeryLongValue", detectedText("Potential secret pattern : aVeryLongValue..."))
+	assert.Equal(t, "", detectedText("The file name \"private.pem\" failed checks"))
+}
+
+func TestAttributionOfLinePrefersTheLineContainingTheDetectedText(t *testing.T) {
+	lines := []gitrepo.BlamedLine{
+		{Attribution: gitrepo.Attribution{Commit: "old"}, Content: "password=secret", Boundary: true},
+		{Attribution: gitrepo.Attribution{Commit: "first"}, Content: "name=value"},
+		{Attribution: gitrepo.Attribution{Commit: "second"}, Content: "password=secret"},
+	}
+
+	assert.Equal(t, "second", attributionOfLine(lines, "password=secret").Commit)
+	assert.Equal(t, "first", attributionOfLine(lines, "").Commit, "Expected findings without a line to be attributed to the first line added")
+	assert.Nil(t, attributionOfLine(lines[:1], "password=secret"))
+}
+
+func TestPrePushHookAttributesFindingsToTheirAuthorWithBlame(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("contains_keys.properties", awsAccessKeyIDExample)
+		git.AddAndcommit("contains_keys.properties", "add keys")
+		wd, _ := os.Getwd()
+		os.Chdir(git.Root())
+		defer os.Chdir(wd)
+		options.Blame = true
+		defer func() { options.Blame = false }()
+
+		prePushHook, err := NewPrePushHook(mockStdIn(git.EarliestCommit(), git.LatestCommit()))
+		assert.NoError(t, err)
+		prePushHook.Run(&talismanrc.TalismanRC{}, prompt.NewPromptContext(false, prompt.NewPrompt()))
+
+		failures := prePushHook.results.GetFailures("contains_keys.properties")
+		assert.NotEmpty(t, failures)
+		assert.Equal(t, git.LatestCommit(), failures[0].Attribution.Commit)
+		assert.Equal(t, "talisman-test-user@example.com", failures[0].Attribution.Email)
+	})
+}
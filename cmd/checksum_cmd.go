@@ -29,11 +29,19 @@ func (s *ChecksumCmd) Run() int {
 		return EXIT_FAILURE
 	}
 
-	gitTrackedFilesAsAdditions := repo.TrackedFilesAsAdditions()
-	gitTrackedFilesAsAdditions = append(gitTrackedFilesAsAdditions, repo.StagedAdditions()...)
+	gitTrackedFilesAsAdditions, err := repo.TrackedFilesAsAdditions()
+	if err != nil {
+		return gitErrorExitCode(err)
+	}
+	stagedAdditions, err := repo.StagedAdditions()
+	if err != nil {
+		return gitErrorExitCode(err)
+	}
+	gitTrackedFilesAsAdditions = append(gitTrackedFilesAsAdditions, stagedAdditions...)
 
 	cc := checksumcalculator.NewChecksumCalculator(s.hasher, gitTrackedFilesAsAdditions)
-	rcSuggestion := cc.SuggestTalismanRC(s.fileNamePatterns)
+	tRC, _ := loadTalismanRC()
+	rcSuggestion := cc.SuggestTalismanRC(s.fileNamePatterns, tRC.GetChecksumVersion())
 
 	if rcSuggestion != "" {
 		fmt.Print(rcSuggestion)
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"talisman/gitrepo"
+)
+
+// scissorsLine is the line below which git leaves out everything from the message, as with git commit --verbose
+const scissorsLine = "------------------------ >8 ------------------------"
+
+type CommitMsgHook struct {
+	runner
+}
+
+// NewCommitMsgHook returns a hook that checks the message of the commit being made, read from the file git passes
+// to the commit-msg and prepare-commit-msg hooks. As a prepare-commit-msg hook, it checks a message given with -m or
+// taken from a template, a merge or a squash before the editor is opened; as a commit-msg hook, it checks the message
+// as it was written. It returns an error if the message cannot be read, as the commit must then not be made.
+func NewCommitMsgHook(messageFile string, mode string) (*CommitMsgHook, error) {
+	text, err := os.ReadFile(messageFile)
+	if err != nil {
+		return nil, err
+	}
+	stripComments, cutAtScissors := commitMessageCleanup()
+	message := gitrepo.Message{Kind: gitrepo.CommitMessage, Text: cleanCommitMessage(string(text), commentChar(), stripComments, cutAtScissors)}
+	hook := &CommitMsgHook{*NewRunner(nil, mode)}
+	hook.messages = []gitrepo.Message{message}
+	return hook, nil
+}
+
+// isMessageHook reports whether a githook checks the message of the commit being made
+func isMessageHook(githook string) bool {
+	return githook == CommitMsg || githook == PrepareCommitMsg
+}
+
+// cleanCommitMessage leaves out what git leaves out of a message before committing it: the comment lines, when it
+// strips them, and everything from the scissors line, when it cuts the message there
+func cleanCommitMessage(text string, commentChar string, stripComments bool, cutAtScissors bool) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if strings.HasPrefix(line, commentChar) {
+			if cutAtScissors && strings.Contains(line, scissorsLine) {
+				break
+			}
+			if stripComments {
+				continue
+			}
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// commitMessageCleanup tells whether git will strip the comment lines of the message and cut it at the scissors line.
+// Git only does so when the message goes through an editor, which it tells hooks is not the case by setting
+// GIT_EDITOR to ":", and commit.cleanup leaves it to do so. Anything else is checked, as --cleanup cannot be seen.
+func commitMessageCleanup() (stripComments bool, cutAtScissors bool) {
+	if os.Getenv("GIT_EDITOR") == ":" {
+		return false, false
+	}
+	out, _ := exec.Command("git", "config", "commit.cleanup").Output()
+	switch strings.TrimSpace(string(out)) {
+	case "", "default", "strip":
+		return true, true
+	case "scissors":
+		return false, true
+	}
+	return false, false
+}
+
+func commentChar() string {
+	out, err := exec.Command("git", "config", "core.commentChar").Output()
+	commentChar := strings.TrimSpace(string(out))
+	if err != nil || commentChar == "" || commentChar == "auto" {
+		return "#"
+	}
+	return commentChar
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"talisman/filesystem"
+)
+
+// DirectoryCmd checks the files in a directory tree without git, such as build artefacts or an extracted tarball
+type DirectoryCmd struct {
+	*runner
+}
+
+// NewDirectoryCmd reads the files in the directory tree, leaving out those excluded by its .gitignore files or by
+// the given excludes
+func NewDirectoryCmd(directory string, excludes *filesystem.Excludes) (*DirectoryCmd, error) {
+	additions, err := filesystem.Walk(directory, excludes)
+	if err != nil {
+		return nil, err
+	}
+	return &DirectoryCmd{NewRunner(additions, "pattern")}, nil
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"talisman/prompt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanningADirectoryOutsideOfARepositoryLeavesOutExcludedFiles(t *testing.T) {
+	directory := t.TempDir()
+	writeFile(t, directory, ".gitignore", "node_modules/\n")
+	writeFile(t, directory, "node_modules/lib/keys.properties", awsAccessKeyIDExample)
+	writeFile(t, directory, "dist/app.js", "safeContents")
+
+	assert.Equal(t, EXIT_SUCCESS, runFilesystemScan(directory, func() { options.Directory = "." }),
+		"Expected run() to return 0 as the key is in a directory excluded by .gitignore")
+
+	writeFile(t, directory, "dist/keys.properties", awsAccessKeyIDExample)
+	assert.Equal(t, EXIT_FAILURE, runFilesystemScan(directory, func() { options.Directory = "dist" }),
+		"Expected run() to return 1 as the key is not excluded")
+	assert.Equal(t, EXIT_SUCCESS, runFilesystemScan(directory, func() { options.Directory, options.Exclude = "dist", []string{"*.properties"} }),
+		"Expected run() to return 0 as the key is excluded with --exclude")
+}
+
+func runFilesystemScan(directory string, setOptions func()) int {
+	saved, savedInput := options, talismanInput
+	defer func() { options, talismanInput = saved, savedInput }()
+	options.Scan, options.ScanWithHtml, options.Pattern, options.Checksum, options.GitHook = false, false, "", "", PrePush
+	setOptions()
+	wd, _ := os.Getwd()
+	os.Chdir(directory)
+	defer os.Chdir(wd)
+	return run(prompt.NewPromptContext(false, prompt.NewPrompt()))
+}
+
+func writeFile(t *testing.T, directory string, name string, contents string) {
+	filePath := filepath.Join(directory, filepath.FromSlash(name))
+	assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+	assert.NoError(t, os.WriteFile(filePath, []byte(contents), 0600))
+}
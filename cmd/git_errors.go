@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"talisman/gitrepo"
+)
+
+// gitErrorExitCode prints what went wrong when git failed, along with what can be done about it, and returns the exit
+// code for that kind of failure
+func gitErrorExitCode(err error) int {
+	exitCode, advice := EXIT_GIT_ERROR, "Please check that git works in this directory and try again."
+	switch {
+	case errors.Is(err, gitrepo.ErrNotARepository):
+		exitCode, advice = EXIT_NOT_A_REPOSITORY, "Please run talisman inside a git repository."
+	case errors.Is(err, gitrepo.ErrNoCommits):
+		exitCode, advice = EXIT_NO_COMMITS, "Please commit something first, as there is no history to check yet."
+	case errors.Is(err, gitrepo.ErrShallowHistory):
+		exitCode, advice = EXIT_SHALLOW_HISTORY, "Please fetch the missing history with 'git fetch --unshallow', "+
+			"or scan only the current files with --ignoreHistory."
+	case errors.Is(err, gitrepo.ErrMissingObject):
+		exitCode, advice = EXIT_MISSING_OBJECT, "Please check that the commits exist, and run 'git fsck' "+
+			"if the repository may be corrupt."
+	}
+	fmt.Fprintf(os.Stderr, "talisman: %v\n%s\n", err, advice)
+	return exitCode
+}
+
+// printWarning tells about something talisman could not check, whatever the log level is
+func printWarning(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, "talisman: warning: "+format+"\n", args...)
+}
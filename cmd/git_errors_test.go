@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"talisman/git_testing"
+	"talisman/gitrepo"
+	"talisman/prompt"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGitErrorExitCodeGivesEachKindOfFailureItsOwnExitCode(t *testing.T) {
+	failed := fmt.Errorf("git failed")
+	exitCodes := map[error]int{
+		&gitrepo.GitError{Kind: gitrepo.ErrNotARepository, Err: failed}: EXIT_NOT_A_REPOSITORY,
+		&gitrepo.GitError{Kind: gitrepo.ErrNoCommits, Err: failed}:      EXIT_NO_COMMITS,
+		&gitrepo.GitError{Kind: gitrepo.ErrShallowHistory, Err: failed}: EXIT_SHALLOW_HISTORY,
+		&gitrepo.GitError{Kind: gitrepo.ErrMissingObject, Err: failed}:  EXIT_MISSING_OBJECT,
+		&gitrepo.GitError{Err: failed}:                                  EXIT_GIT_ERROR,
+	}
+	for err, exitCode := range exitCodes {
+		assert.Equal(t, exitCode, gitErrorExitCode(err), "Unexpected exit code for %v", err)
+	}
+}
+
+func TestScanningTheHeadOfARepositoryWithoutCommitsExitsWithNoCommits(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		saved := options
+		defer func() { options = saved }()
+		options.Scan, options.IgnoreHistory, options.GitHook = true, true, PreCommit
+
+		assert.Equal(t, EXIT_NO_COMMITS, runTalisman(git), "Expected run() to return EXIT_NO_COMMITS as there is no HEAD to scan")
+	})
+}
+
+func TestPushingOnTopOfACommitThatDoesNotExistExitsWithMissingObject(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+
+		exitCode := runPrePushHookIn(git.Root(), "0123456789abcdef0123456789abcdef01234567", git.LatestCommit())
+
+		assert.Equal(t, EXIT_MISSING_OBJECT, exitCode, "Expected run() to return EXIT_MISSING_OBJECT as the remote commit does not exist")
+	})
+}
+
+func TestPushingFromAShallowCloneChecksTheChangesMadeAfterItsHistoryStops(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("simple-file", "safeContents")
+		git.AddAndcommit("simple-file", "initial commit")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		git.AppendFileContent("simple-file", "more contents")
+		git.AddAndcommit("simple-file", "change simple-file")
+		git.CreateFileWithContents("id_rsa", "secret")
+		git.AddAndcommit("id_rsa", "add another private key")
+
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			exitCode := runPrePushHookIn(clone.Root(), git.EarliestCommit(), clone.LatestCommit())
+			assert.Equal(t, EXIT_FAILURE, exitCode, "Expected run() to return 1 as a key was added after the shallow history starts")
+		}, "--depth=2")
+
+		git.RemoveFile("id_rsa")
+		git.AddAndcommit("id_rsa", "remove the other private key")
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			exitCode := runPrePushHookIn(clone.Root(), git.EarliestCommit(), clone.LatestCommit())
+			assert.Equal(t, EXIT_SUCCESS, exitCode, "Expected run() to return 0 as the key was added before the shallow history starts")
+		}, "--depth=2")
+	})
+}
+
+func TestPushingFromAPartialCloneDoesNotCheckFilesItHasNotFetched(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("simple-file", "safeContents")
+		git.AddAndcommit("simple-file", "initial commit")
+		git.CreateFileWithContents("private.pem", "secret")
+		git.AddAndcommit("private.pem", "add private key")
+		withKey := git.LatestCommit()
+		git.RemoveFile("private.pem")
+		git.AddAndcommit("private.pem", "remove private key")
+
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			exitCode := runPrePushHookIn(clone.Root(), git.EarliestCommit(), withKey)
+			assert.Equal(t, EXIT_SUCCESS, exitCode, "Expected run() to return 0 as the key was not fetched by the blobless clone")
+
+			missing, err := gitrepo.RepoLocatedAt(clone.Root()).MissingObjects("--all")
+			assert.NoError(t, err)
+			assert.NotEmpty(t, missing, "Expected checking the push not to fetch the missing key")
+		}, "--filter=blob:none")
+	})
+}
+
+func runPrePushHookIn(directory, remoteCommit, localCommit string) int {
+	saved := options
+	defer func() { options = saved }()
+	options.Scan, options.ScanWithHtml, options.Pattern, options.Checksum, options.GitHook = false, false, "", "", PrePush
+	wd, _ := os.Getwd()
+	os.Chdir(directory)
+	defer os.Chdir(wd)
+	talismanInput = mockStdIn(remoteCommit, localCommit)
+	return run(prompt.NewPromptContext(false, prompt.NewPrompt()))
+}
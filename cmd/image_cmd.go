@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"talisman/containerimage"
+	"talisman/detector"
+	"talisman/detector/helpers"
+	"talisman/filesystem"
+	"talisman/report"
+	"talisman/talismanrc"
+	"talisman/utility"
+
+	logr "github.com/sirupsen/logrus"
+)
+
+// ImageCmd scans the layers of a container image saved to a tarball, such as by docker save, for secrets
+type ImageCmd struct {
+	image           *containerimage.Image
+	excludes        *filesystem.Excludes
+	results         *helpers.DetectionResults
+	reportDirectory string
+}
+
+// NewImageCmd reads the manifests of the image tarball, leaving out the files of its layers matching the excludes
+func NewImageCmd(tarballPath string, excludes *filesystem.Excludes, reportDirectory string) (*ImageCmd, error) {
+	image, err := containerimage.Open(tarballPath)
+	if err != nil {
+		return nil, err
+	}
+	return &ImageCmd{
+		image:           image,
+		excludes:        excludes,
+		results:         helpers.NewDetectionResults(),
+		reportDirectory: reportDirectory,
+	}, nil
+}
+
+// Run scans every file of every layer, including files that later layers delete, and reports each finding with the
+// layer holding it and the instruction that created the layer
+func (c *ImageCmd) Run(tRC *talismanrc.TalismanRC) int {
+	defer c.image.Close()
+	fmt.Printf("\n\n")
+	utility.CreateArt("Running Scan..")
+
+	total, err := c.image.CountFiles(c.excludes)
+	if err != nil {
+		logr.Errorf("error reading image: %v", err)
+		return EXIT_FAILURE
+	}
+	setCustomSeverities(tRC)
+	additions, streamErr := c.image.StreamFiles(c.excludes)
+	detector.DefaultChain(tRC, helpers.ScanHistoryEvaluator()).TestStream(additions, total, tRC, c.results, nil)
+	if err := streamErr(); err != nil {
+		logr.Errorf("error reading image: %v", err)
+		return EXIT_FAILURE
+	}
+	for _, layer := range c.image.Layers() {
+		c.results.Layers = append(c.results.Layers, helpers.ImageLayer{
+			Digest:      layer.Digest,
+			Instruction: layer.Instruction,
+			Files:       layer.Files,
+		})
+	}
+
+	if c.results.HasWarnings() {
+		fmt.Println(c.results.ReportWarnings())
+	}
+	if c.results.HasFailures() {
+		c.results.ReportImageFailures()
+	}
+	reportsPath, err := report.GenerateReport(c.results, c.reportDirectory)
+	if err != nil {
+		logr.Errorf("error while generating report: %v", err)
+		return EXIT_FAILURE
+	}
+	fmt.Printf("\nPlease check '%s' folder for the talisman scan report\n\n", reportsPath)
+	return c.exitStatus()
+}
+
+func (c *ImageCmd) exitStatus() int {
+	if c.results.HasFailures() {
+		return EXIT_FAILURE
+	}
+	return EXIT_SUCCESS
+}
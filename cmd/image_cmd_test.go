@@ -0,0 +1,69 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"talisman/detector/helpers"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tarOf(files map[string]string, names ...string) string {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for _, name := range names {
+		writer.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(files[name])), Typeflag: tar.TypeReg})
+		writer.Write([]byte(files[name]))
+	}
+	writer.Close()
+	return buffer.String()
+}
+
+func writeImageTarball(t *testing.T, directory string, secret string) string {
+	secretLayer := tarOf(map[string]string{"app/config.properties": secret}, "app/config.properties")
+	removalLayer := tarOf(map[string]string{"app/.wh.config.properties": ""}, "app/.wh.config.properties")
+	image := map[string]string{
+		"manifest.json": `[{"Config": "config.json", "Layers": ["secret/layer.tar", "removal/layer.tar"]}]`,
+		"config.json": `{"rootfs": {"diff_ids": ["sha256:0123456789abcdef", "sha256:fedcba9876543210"]},
+			"history": [{"created_by": "COPY config.properties /app/ # buildkit"}, {"created_by": "RUN /bin/sh -c rm /app/config.properties # buildkit"}]}`,
+		"secret/layer.tar":  secretLayer,
+		"removal/layer.tar": removalLayer,
+	}
+	tarballPath := filepath.Join(directory, "image.tar")
+	assert.NoError(t, os.WriteFile(tarballPath, []byte(tarOf(image, "manifest.json", "config.json", "secret/layer.tar", "removal/layer.tar")), 0644))
+	return tarballPath
+}
+
+func TestScanningAnImageReportsSecretsThatLaterLayersDeleteWithTheirLayer(t *testing.T) {
+	directory := t.TempDir()
+	tarballPath := writeImageTarball(t, directory, "safeContents")
+	assert.Equal(t, EXIT_SUCCESS, runFilesystemScan(directory, func() {
+		options.Image, options.ReportDirectory = tarballPath, directory
+	}), "Expected run() to return 0 as the image holds no secrets")
+
+	tarballPath = writeImageTarball(t, directory, awsAccessKeyIDExample)
+	assert.Equal(t, EXIT_FAILURE, runFilesystemScan(directory, func() {
+		options.Image, options.ReportDirectory = tarballPath, directory
+	}), "Expected run() to return 1 as a layer holds a key, even though a later layer deletes it")
+
+	data, err := os.ReadFile(filepath.Join(directory, "talisman_reports", "data", "report.json"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	var results struct {
+		Layers  []helpers.ImageLayer
+		Results []struct{ Filename string }
+	}
+	assert.NoError(t, json.Unmarshal(data, &results))
+	assert.Equal(t, []helpers.ImageLayer{
+		{Digest: "sha256:0123456789abcdef", Instruction: "COPY config.properties /app/", Files: 1},
+		{Digest: "sha256:fedcba9876543210", Instruction: "RUN rm /app/config.properties", Files: 0},
+	}, results.Layers)
+	if assert.Len(t, results.Results, 1) {
+		assert.Equal(t, "0123456789ab:app/config.properties", results.Results[0].Filename)
+	}
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"talisman/filesystem"
 	"talisman/gitrepo"
 	"talisman/utility"
 
@@ -13,11 +14,15 @@ type PatternCmd struct {
 	*runner
 }
 
-func NewPatternCmd(pattern string) *PatternCmd {
+func NewPatternCmd(pattern string, excludes *filesystem.Excludes) *PatternCmd {
 	var additions []gitrepo.Addition
 
 	files, _ := doublestar.Glob(pattern)
 	for _, file := range files {
+		if excludes.Excluded(file, false) {
+			log.Debugf("skipping excluded file %s", file)
+			continue
+		}
 		log.Debugf("reading file %s", file)
 		data, err := utility.SafeReadFile(file)
 
@@ -10,9 +10,13 @@ type PreCommitHook struct {
 	runner
 }
 
-func NewPreCommitHook() *PreCommitHook {
+func NewPreCommitHook() (*PreCommitHook, error) {
 	wd, _ := os.Getwd()
 	repo := gitrepo.RepoLocatedAt(wd)
 
-	return &PreCommitHook{*NewRunner(repo.GetDiffForStagedFiles(), PreCommit)}
+	additions, err := repo.GetDiffForStagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	return &PreCommitHook{*NewRunner(additions, PreCommit)}, nil
 }
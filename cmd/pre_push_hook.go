@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"os"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"talisman/detector/helpers"
 	"talisman/gitrepo"
 )
 
@@ -19,24 +21,54 @@ const (
 
 type PrePushHook struct {
 	localRef, localCommit, remoteRef, remoteCommit string
+	//shallowBoundary is where the checks start when the remote commit is missing from a shallow clone
+	shallowBoundary string
 	*runner
 }
 
-func NewPrePushHook(stdin io.Reader) *PrePushHook {
+func NewPrePushHook(stdin io.Reader) (*PrePushHook, error) {
 	localRef, localCommit, remoteRef, remoteCommit := readRefAndSha(stdin)
 	prePushHook := &PrePushHook{
 		localRef,
 		localCommit,
 		remoteRef,
 		remoteCommit,
+		"",
 		NewRunner(nil, PrePush)}
-	prePushHook.additions = prePushHook.getRepoAdditions()
-	return prePushHook
+	var err error
+	if prePushHook.additions, err = prePushHook.getRepoAdditions(); err != nil {
+		return nil, err
+	}
+	if prePushHook.messages, err = prePushHook.getRepoMessages(); err != nil {
+		return nil, err
+	}
+	if options.Blame && !prePushHook.runningOnDeletedRef() {
+		prePushHook.attribute = prePushHook.blameAttribution()
+	}
+	return prePushHook, nil
+}
+
+//Findings are attributed to the outgoing commits that added the lines they were found in
+func (p *PrePushHook) blameAttribution() func(gitrepo.FilePath, helpers.Details) *gitrepo.Attribution {
+	wd, _ := os.Getwd()
+	return blameAttribution(gitrepo.RepoLocatedAt(wd), p.oldCommit(), p.localCommit)
+}
+
+//The outgoing commits are those after the remote commit, or all commits of a new ref, limited to those after the
+//shallow boundary when the remote commit was not fetched
+func (p *PrePushHook) oldCommit() string {
+	if p.shallowBoundary != "" {
+		return p.shallowBoundary
+	}
+	if p.runningOnNewRef() {
+		return ""
+	}
+	return p.remoteCommit
 }
 
 //If the outgoing ref does not exist on the remote, all commits on the local ref will be checked
 //If the outgoing ref already exists, all additions in the range between "localSha" and "remoteSha" will be validated
-func (p *PrePushHook) getRepoAdditions() []gitrepo.Addition {
+func (p *PrePushHook) getRepoAdditions() ([]gitrepo.Addition, error) {
 	if p.runningOnDeletedRef() {
 		log.WithFields(log.Fields{
 			"localRef":     p.localRef,
@@ -45,7 +77,7 @@ func (p *PrePushHook) getRepoAdditions() []gitrepo.Addition {
 			"remoteCommit": p.remoteCommit,
 		}).Info("Running on a deleted ref. Nothing to verify as outgoing changes are all deletions.")
 
-		return []gitrepo.Addition{}
+		return []gitrepo.Addition{}, nil
 	}
 
 	if p.runningOnNewRef() {
@@ -69,6 +101,28 @@ func (p *PrePushHook) getRepoAdditions() []gitrepo.Addition {
 	return p.getRepoAdditionsFrom(p.remoteCommit, p.localCommit)
 }
 
+//Messages of the outgoing commits are checked, along with the annotation of an outgoing annotated tag
+func (p *PrePushHook) getRepoMessages() ([]gitrepo.Message, error) {
+	if p.runningOnDeletedRef() {
+		return nil, nil
+	}
+	wd, _ := os.Getwd()
+	repo := gitrepo.RepoLocatedAt(wd)
+	var messages []gitrepo.Message
+	if strings.HasPrefix(p.localRef, "refs/tags/") {
+		annotations, err := repo.TagAnnotations(p.localRef)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, annotations...)
+	}
+	commitMessages, err := repo.CommitMessagesWithinRange(p.oldCommit(), p.localCommit)
+	if err != nil {
+		return nil, err
+	}
+	return append(messages, commitMessages...), nil
+}
+
 func (p *PrePushHook) runningOnDeletedRef() bool {
 	return p.localCommit == EmptySha
 }
@@ -77,10 +131,34 @@ func (p *PrePushHook) runningOnNewRef() bool {
 	return p.remoteCommit == EmptySha
 }
 
-func (p *PrePushHook) getRepoAdditionsFrom(oldCommit, newCommit string) []gitrepo.Addition {
+//When the old commit is missing from a shallow clone, the changes made after the commit the shallow history stops at
+//are checked instead. Files a partial clone has not fetched are not checked, so that pushing does not fetch them.
+//When recursing into submodules, the changes in the commits that submodules were made to point at are checked too.
+func (p *PrePushHook) getRepoAdditionsFrom(oldCommit, newCommit string) ([]gitrepo.Addition, error) {
 	wd, _ := os.Getwd()
 	repo := gitrepo.RepoLocatedAt(wd)
-	return repo.AdditionsWithinRange(oldCommit, newCommit)
+	additions, skipped, err := repo.AvailableAdditionsWithinRange(oldCommit, newCommit)
+	if errors.Is(err, gitrepo.ErrShallowHistory) {
+		boundary, boundaryErr := repo.ShallowBoundaryOf(newCommit)
+		if boundaryErr != nil || len(boundary) == 0 {
+			return nil, err
+		}
+		p.shallowBoundary = boundary[0]
+		printWarning("%s is not part of this shallow clone, so only the changes made after %s were checked. "+
+			"Run 'git fetch --unshallow' to check the changes made before.", oldCommit, p.shallowBoundary)
+		oldCommit = p.shallowBoundary
+		additions, skipped, err = repo.AvailableAdditionsWithinRange(oldCommit, newCommit)
+	}
+	if len(skipped) > 0 {
+		printWarning("the contents of %d files were not fetched by this partial clone, so they were not checked: %v",
+			len(skipped), skipped)
+	}
+	if err == nil && options.RecurseSubmodules {
+		var inSubmodules []gitrepo.Addition
+		inSubmodules, err = submoduleAdditions(repo, oldCommit, newCommit, "")
+		additions = append(additions, inSubmodules...)
+	}
+	return additions, err
 }
 
 func readRefAndSha(file io.Reader) (string, string, string, string) {
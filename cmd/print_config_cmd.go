@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"talisman/talismanrc"
+	"text/tabwriter"
+)
+
+type PrintConfigCmd struct {
+	tRC *talismanrc.TalismanRC
+}
+
+// NewPrintConfigCmd returns a command that shows the settings in effect once overrides are applied
+func NewPrintConfigCmd(tRC *talismanrc.TalismanRC) *PrintConfigCmd {
+	return &PrintConfigCmd{tRC: tRC}
+}
+
+// Run prints every overridable setting, its resolved value and where that value came from
+func (p *PrintConfigCmd) Run() int {
+	writer := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(writer, "SETTING\tVALUE\tSOURCE\tENVIRONMENT VARIABLE")
+	for _, setting := range p.tRC.ResolvedSettings() {
+		value := setting.Value
+		if value == "" {
+			value = "-"
+		}
+		fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n", setting.Key, value, setting.Source, setting.EnvironmentVariable)
+	}
+	if err := writer.Flush(); err != nil {
+		return EXIT_FAILURE
+	}
+	return EXIT_SUCCESS
+}
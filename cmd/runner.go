@@ -14,8 +14,11 @@ import (
 // runner represents a single run of the validations for a given commit range
 type runner struct {
 	additions []gitrepo.Addition
+	messages  []gitrepo.Message
 	results   *helpers.DetectionResults
 	mode      string
+	// attribute, when set, attributes each finding to the commit that introduced it
+	attribute func(gitrepo.FilePath, helpers.Details) *gitrepo.Attribution
 }
 
 // NewRunner returns a new runner.
@@ -29,19 +32,32 @@ func NewRunner(additions []gitrepo.Addition, mode string) *runner {
 
 // Run will validate the commit range for errors and return either COMPLETED_SUCCESSFULLY or COMPLETED_WITH_ERRORS
 func (r *runner) Run(tRC *talismanrc.TalismanRC, promptContext prompt.PromptContext) int {
-	wd, _ := os.Getwd()
-	repo := gitrepo.RepoLocatedAt(wd)
-	ie := helpers.BuildIgnoreEvaluator(r.mode, tRC, repo)
+	ie := r.ignoreEvaluator(tRC)
 
 	setCustomSeverities(tRC)
 	additionsToScan := tRC.RemoveScopedFiles(r.additions)
+	r.results.TrackRenames(additionsToScan)
 
-	detector.DefaultChain(tRC, ie).Test(additionsToScan, tRC, r.results)
+	detector.DefaultChain(tRC, ie).WithMessages(tRC, r.messages).Test(additionsToScan, tRC, r.results)
+	if r.attribute != nil {
+		r.results.Attribute(r.attribute)
+	}
 	r.printReport(promptContext)
 	exitStatus := r.exitStatus()
 	return exitStatus
 }
 
+// ignoreEvaluator applies the ignores of .talismanrc, with checksums calculated from the files of the repository, or
+// from the files being checked when they were read without git. Commit messages never match the checksums of files,
+// so the message hooks leave the files of the repository alone.
+func (r *runner) ignoreEvaluator(tRC *talismanrc.TalismanRC) helpers.IgnoreEvaluator {
+	if r.mode == "pattern" || isMessageHook(r.mode) {
+		return helpers.BuildFilesystemIgnoreEvaluator(tRC, r.additions)
+	}
+	wd, _ := os.Getwd()
+	return helpers.BuildIgnoreEvaluator(r.mode, tRC, gitrepo.RepoLocatedAt(wd))
+}
+
 func setCustomSeverities(tRC *talismanrc.TalismanRC) {
 	for _, cs := range tRC.CustomSeverities {
 		severity.SeverityConfiguration[cs.Detector] = cs.Severity
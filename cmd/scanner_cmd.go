@@ -3,6 +3,10 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
 	"talisman/detector"
 	"talisman/detector/helpers"
 	"talisman/gitrepo"
@@ -10,6 +14,7 @@ import (
 	"talisman/scanner"
 	"talisman/talismanrc"
 	"talisman/utility"
+	"time"
 
 	logr "github.com/sirupsen/logrus"
 )
@@ -19,21 +24,73 @@ const (
 )
 
 type ScannerCmd struct {
-	additions       []gitrepo.Addition
+	blobs           []scanner.Blob
+	messages        []gitrepo.Message
+	reader          gitrepo.BatchReader
 	results         *helpers.DetectionResults
 	reportDirectory string
 	ignoreEvaluator helpers.IgnoreEvaluator
 	tRC             *talismanrc.TalismanRC
+	cache           *scanner.Cache
+	checkpoint      *scanner.Checkpoint
+	availability    scanner.Availability
+	missingBlobs    []scanner.Blob
+	submodules      []string
+	stop            chan struct{}
+	stopOnce        sync.Once
 }
 
+// checkpointInterval is how often the progress of a scan is checkpointed
+var checkpointInterval = time.Minute
+
 // Run scans git commit history for potential secrets and returns 0 or 1 as exit code
 func (s *ScannerCmd) Run() int {
 	fmt.Printf("\n\n")
 	utility.CreateArt("Running Scan..")
+	s.warnAboutMissingHistory()
+
+	blobsToScan := s.checkpoint.Remaining(s.removeScopedBlobs())
+	resumed := s.checkpoint.Replay(s.results)
+	if resumed > 0 {
+		logr.Infof("resuming scan, %d blobs were scanned before the last checkpoint", resumed)
+	}
+	stopHandlingInterrupts := s.stopOnInterrupt()
+	defer stopHandlingInterrupts()
 
-	additionsToScan := s.tRC.RemoveScopedFiles(s.additions)
+	additions, streamErr := scanner.StreamAdditionsUntil(blobsToScan, s.reader, s.stop)
+	checkpointed, tested := 0, 0
+	lastCheckpoint := time.Now()
+	detector.DefaultChain(s.tRC, s.ignoreEvaluator).TestStream(additions, len(blobsToScan), s.tRC, s.results, func(testedSoFar int) {
+		tested = testedSoFar
+		if time.Since(lastCheckpoint) >= checkpointInterval {
+			s.saveCheckpoint(blobsToScan[checkpointed:tested])
+			checkpointed, lastCheckpoint = tested, time.Now()
+		}
+	})
+	if err := streamErr(); err != nil {
+		s.saveCheckpoint(blobsToScan[checkpointed:tested])
+		return gitErrorExitCode(err)
+	}
+	if tested < len(blobsToScan) {
+		s.results.Coverage = s.availability.Coverage(resumed+tested, s.missingBlobs)
+		return s.interrupted(blobsToScan[checkpointed:tested])
+	}
 
-	detector.DefaultChain(s.tRC, s.ignoreEvaluator).Test(additionsToScan, s.tRC, s.results)
+	detector.NewChain(s.ignoreEvaluator).WithMessages(s.tRC, s.messages).Test(nil, s.tRC, s.results)
+	reused := 0
+	if s.cache != nil {
+		s.cache.Record(s.results)
+		reused = s.cache.Replay(s.results)
+		logr.Infof("reused scan results of %d blobs from the scan cache", reused)
+		if err := s.cache.Save(); err != nil {
+			logr.Warnf("unable to save scan cache: %v", err)
+		}
+	}
+	s.results.Coverage = s.availability.Coverage(resumed+tested+reused, s.missingBlobs)
+	if err := s.checkpoint.Remove(); err != nil {
+		logr.Warnf("%v", err)
+	}
+	scanner.AttributeFindings(s.results, s.submodules...)
 	reportsPath, err := report.GenerateReport(s.results, s.reportDirectory)
 	if err != nil {
 		logr.Errorf("error while generating report: %v", err)
@@ -44,6 +101,77 @@ func (s *ScannerCmd) Run() int {
 	return s.exitStatus()
 }
 
+// interrupted checkpoints and reports what was scanned before the scan was stopped.
+// The scan cache is left as it was, as it only records complete scans.
+func (s *ScannerCmd) interrupted(scannedSinceCheckpoint []scanner.Blob) int {
+	s.saveCheckpoint(scannedSinceCheckpoint)
+	scanner.AttributeFindings(s.results, s.submodules...)
+	reportsPath, err := report.GenerateReport(s.results, s.reportDirectory)
+	if err != nil {
+		logr.Errorf("error while generating report: %v", err)
+		return EXIT_FAILURE
+	}
+	fmt.Printf("\nScan interrupted. Please check '%s' folder for the report of what was scanned so far, "+
+		"and run the scan again with --resume to continue from where it stopped\n\n", reportsPath)
+	return EXIT_FAILURE
+}
+
+// warnAboutMissingHistory tells what part of the history cannot be scanned, as it is missing from a shallow or
+// partial clone
+func (s *ScannerCmd) warnAboutMissingHistory() {
+	if len(s.availability.ShallowBoundary) > 0 {
+		printWarning("this is a shallow clone, so history before %s was not scanned. "+
+			"Run 'git fetch --unshallow' to scan the whole history.", strings.Join(s.availability.ShallowBoundary, ", "))
+	}
+	if len(s.missingBlobs) > 0 {
+		printWarning("this is a partial clone, so %d file versions that were not fetched were not scanned, such as %s. "+
+			"They are listed in the coverage of the report.", len(s.missingBlobs), s.missingBlobs[0].Path)
+	}
+}
+
+func (s *ScannerCmd) saveCheckpoint(scannedSinceCheckpoint []scanner.Blob) {
+	s.checkpoint.Record(scannedSinceCheckpoint, s.results)
+	if err := s.checkpoint.Save(); err != nil {
+		logr.Warnf("unable to checkpoint scan: %v", err)
+	}
+}
+
+// stopOnInterrupt stops the scan when talisman is interrupted or terminated, so that what was scanned until then
+// is checkpointed and reported. It returns a function that stops handling the signals.
+func (s *ScannerCmd) stopOnInterrupt() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case received := <-signals:
+			logr.Warnf("received %v, stopping the scan", received)
+			s.interrupt()
+		case <-done:
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(done)
+	}
+}
+
+func (s *ScannerCmd) interrupt() {
+	s.stopOnce.Do(func() { close(s.stop) })
+}
+
+// removeScopedBlobs leaves out blobs of files in the configured scopes, before their contents are read
+func (s *ScannerCmd) removeScopedBlobs() []scanner.Blob {
+	var blobsToScan []scanner.Blob
+	for _, blob := range s.blobs {
+		addition := gitrepo.NewScannerAddition(blob.Path, blob.Commits, nil)
+		if len(s.tRC.RemoveScopedFiles([]gitrepo.Addition{addition})) > 0 {
+			blobsToScan = append(blobsToScan, blob)
+		}
+	}
+	return blobsToScan
+}
+
 func (s *ScannerCmd) exitStatus() int {
 	if s.results.HasFailures() {
 		return EXIT_FAILURE
@@ -52,19 +180,94 @@ func (s *ScannerCmd) exitStatus() int {
 }
 
 // NewScannerCmd Returns a new scanner command
-func NewScannerCmd(ignoreHistory bool, tRC *talismanrc.TalismanRC, reportDirectory string) *ScannerCmd {
+func NewScannerCmd(ignoreHistory bool, tRC *talismanrc.TalismanRC, reportDirectory string) (*ScannerCmd, error) {
 	repoRoot, _ := os.Getwd()
 	reader := gitrepo.NewBatchGitObjectHashReader(repoRoot)
-	additions := scanner.GetAdditions(ignoreHistory, reader)
+	history := historyToScan(ignoreHistory)
+	cache := scanCache(history, tRC)
+	var blobs []scanner.Blob
+	var err error
+	if cache != nil {
+		blobs, err = scanner.ListBlobsUsingCache(cache)
+	} else {
+		blobs, err = scanner.ListBlobs(history)
+	}
+	if err != nil {
+		return nil, err
+	}
+	messages, err := scanner.ListMessages(history)
+	if err != nil {
+		return nil, err
+	}
+	if history.Deep {
+		blobs = append(blobs, scanner.ListDeepBlobs(history)...)
+	}
+	availability, err := scanner.CheckAvailability(history)
+	if err != nil {
+		return nil, err
+	}
+	blobs, missingBlobs := availability.Available(blobs)
+	if cache != nil {
+		cache.LeaveUnscanned(missingBlobs)
+	}
+	var submodules []string
+	if options.RecurseSubmodules {
+		if blobs, reader, submodules, err = withSubmodules(blobs, reader, history); err != nil {
+			return nil, err
+		}
+	}
+	checkpointKey := scanner.CheckpointKey(tRC, Version, history)
+	checkpoint := scanner.NewCheckpoint(reportDirectory, checkpointKey)
+	if options.Resume {
+		checkpoint = scanner.LoadCheckpoint(reportDirectory, checkpointKey)
+	}
 	ignoreEvaluator := helpers.ScanHistoryEvaluator()
 	if ignoreHistory {
 		ignoreEvaluator = helpers.BuildIgnoreEvaluator("default", tRC, gitrepo.RepoLocatedAt(repoRoot))
 	}
 	return &ScannerCmd{
-		additions:       additions,
+		blobs:           blobs,
+		messages:        messages,
+		reader:          reader,
 		results:         helpers.NewDetectionResults(),
 		reportDirectory: reportDirectory,
 		ignoreEvaluator: ignoreEvaluator,
 		tRC:             tRC,
+		cache:           cache,
+		checkpoint:      checkpoint,
+		availability:    availability,
+		missingBlobs:    missingBlobs,
+		submodules:      submodules,
+		stop:            make(chan struct{}),
+	}, nil
+}
+
+// historyToScan returns the part of the git history selected on the command line
+func historyToScan(ignoreHistory bool) scanner.History {
+	return scanner.History{
+		Revisions: options.Revisions,
+		Since:     options.Since,
+		Until:     options.Until,
+		Paths:     options.Paths,
+		HeadOnly:  ignoreHistory,
+		Deep:      options.DeepScan,
+	}
+}
+
+// scanCache returns the cache of earlier history scans, or nil when it should not be used.
+// The cache only describes complete scans, so it is not used when scanning part of the history.
+func scanCache(history scanner.History, tRC *talismanrc.TalismanRC) *scanner.Cache {
+	if !history.IsComplete() || options.NoCache {
+		return nil
+	}
+	directory := options.CacheDirectory
+	if directory == "" {
+		var err error
+		directory, err = scanner.DefaultCacheDirectory()
+		if err != nil {
+			logr.Warnf("scanning without a cache: %v", err)
+			return nil
+		}
 	}
+	return scanner.LoadCache(directory, scanner.CacheKey(tRC, Version))
 }
@@ -2,7 +2,12 @@ package main
 
 import (
 	"os"
+	"os/exec"
+	"path/filepath"
+	"talisman/detector/helpers"
 	"talisman/git_testing"
+	"talisman/gitrepo"
+	"talisman/scanner"
 	"talisman/talismanrc"
 	"testing"
 
@@ -16,7 +21,8 @@ func TestScannerCmdRunsSuccessfully(t *testing.T) {
 		git.AddAndcommit("*", "Start of Scan")
 		os.Chdir(git.Root())
 
-		scannerCmd := NewScannerCmd(true, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd, err := NewScannerCmd(true, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
 		scannerCmd.Run()
 		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 0 since no secret is found")
 	})
@@ -33,7 +39,8 @@ func TestScannerCmdDetectsSecretAndFails(t *testing.T) {
 		git.AddAndcommit("*", "Start of Scan")
 		os.Chdir(git.Root())
 
-		scannerCmd := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
 		scannerCmd.Run()
 		assert.Equal(t, 1, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 1 since secret present in history")
 	})
@@ -48,7 +55,8 @@ func TestScannerCmdAddingSecretKeyShouldExitZeroIfFileIsWithinConfiguredScope(t
 		os.Chdir(git.Root())
 
 		tRC := &talismanrc.TalismanRC{ScopeConfig: []talismanrc.ScopeConfig{{ScopeName: "go"}}}
-		scannerCmd := NewScannerCmd(false, tRC, git.Root())
+		scannerCmd, err := NewScannerCmd(false, tRC, git.Root())
+		assert.NoError(t, err)
 		scannerCmd.Run()
 		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 0 since no secret is found")
 	})
@@ -67,7 +75,8 @@ func TestScannerCmdDetectsSecretAndIgnoresWhileRunningInIgnoreHistoryModeWithVal
 				{FileName: "go.sum", Checksum: "582093519ae682d5170aecc9b935af7e90ed528c577ecd2c9dd1fad8f4924ab9"},
 				{FileName: "go.mod", Checksum: "8a03b9b61c505ace06d590d2b9b4f4b6fa70136e14c26875ced149180e00d1af"},
 			}}
-		scannerCmd := NewScannerCmd(true, tRC, git.Root())
+		scannerCmd, err := NewScannerCmd(true, tRC, git.Root())
+		assert.NoError(t, err)
 		scannerCmd.Run()
 		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 0 since secrets file ignore is enabled")
 	})
@@ -86,8 +95,280 @@ func TestScannerCmdDetectsSecretWhileRunningNormalScanMode(t *testing.T) {
 				{FileName: "go.sum", Checksum: "582093519ae682d5170aecc9b935af7e90ed528c577ecd2c9dd1fad8f4924ab9"},
 				{FileName: "go.mod", Checksum: "8a03b9b61c505ace06d590d2b9b4f4b6fa70136e14c26875ced149180e00d1af"},
 			}}
-		scannerCmd := NewScannerCmd(false, tRC, git.Root())
+		scannerCmd, err := NewScannerCmd(false, tRC, git.Root())
+		assert.NoError(t, err)
 		scannerCmd.Run()
 		assert.Equal(t, 1, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 1 because file ignore is disabled when scanning history")
 	})
 }
+
+func TestScannerCmdReusesResultsFromTheScanCache(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "Initial Commit")
+		git.RemoveFile("some-dir/file-with-secret.txt")
+		git.AddAndcommit("*", "Removed secret")
+		os.Chdir(git.Root())
+
+		firstScan, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		firstScan.Run()
+		assert.FileExists(t, filepath.Join(git.Root(), ".git", "talisman", scanner.CacheFileName))
+
+		git.CreateFileWithContents("some-dir/safe-file.txt", "safeContents")
+		git.AddAndcommit("some-dir/safe-file.txt", "Start of Scan")
+		secondScan, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		secondScan.Run()
+
+		assert.Len(t, secondScan.blobs, 1, "Expected only the blob added since the last scan to be scanned")
+		assert.Equal(t, 1, secondScan.exitStatus(), "Expected the secret found by the earlier scan to be reported again")
+	})
+}
+
+func TestScannerCmdRescansEverythingWhenConfigurationChanges(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("go.sum", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "go sum file")
+		os.Chdir(git.Root())
+
+		firstScan, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		firstScan.Run()
+		assert.Equal(t, 1, firstScan.exitStatus())
+
+		tRC := &talismanrc.TalismanRC{ScopeConfig: []talismanrc.ScopeConfig{{ScopeName: "go"}}}
+		secondScan, err := NewScannerCmd(false, tRC, git.Root())
+		assert.NoError(t, err)
+		secondScan.Run()
+
+		assert.Equal(t, len(firstScan.blobs), len(secondScan.blobs), "Expected every blob to be scanned again")
+		assert.Equal(t, 0, secondScan.exitStatus(), "Expected results cached under the earlier configuration not to be reused")
+	})
+}
+
+func TestScannerCmdDoesNotUseTheScanCacheWhenDisabled(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/safe-file.txt", "safeContents")
+		git.AddAndcommit("*", "Start of Scan")
+		os.Chdir(git.Root())
+		options.NoCache = true
+		defer func() { options.NoCache = false }()
+
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		scannerCmd.Run()
+
+		assert.NoFileExists(t, filepath.Join(git.Root(), ".git", "talisman", scanner.CacheFileName))
+	})
+}
+
+func TestScannerCmdScansOnlyFilesIntroducedByARevisionRange(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "Secret before the release")
+		release := git.LatestCommit()
+		git.CreateFileWithContents("some-dir/safe-file.txt", "safeContents")
+		git.AddAndcommit("some-dir/safe-file.txt", "Safe change after the release")
+		os.Chdir(git.Root())
+		options.Revisions = []string{release + "..HEAD"}
+		defer func() { options.Revisions = nil }()
+
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		scannerCmd.Run()
+
+		assert.Len(t, scannerCmd.blobs, 1, "Expected only the file changed after the release to be scanned")
+		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected the secret added before the release not to be reported")
+	})
+}
+
+func TestScannerCmdScansOnlyFilesMatchingThePathFilter(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("other-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.CreateFileWithContents("some-dir/safe-file.txt", "safeContents")
+		git.AddAndcommit("*", "Initial Commit")
+		os.Chdir(git.Root())
+		options.Paths = []string{"some-dir/"}
+		defer func() { options.Paths = nil }()
+
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		scannerCmd.Run()
+
+		assert.Len(t, scannerCmd.blobs, 1)
+		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected files outside the path filter not to be scanned")
+		assert.NoFileExists(t, filepath.Join(git.Root(), ".git", "talisman", scanner.CacheFileName), "Expected partial scans not to use the scan cache")
+	})
+}
+
+func TestScannerCmdScansOnlyCommitsMadeBeforeUntil(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "Initial Commit")
+		os.Chdir(git.Root())
+		options.Until = "2000-01-01"
+		defer func() { options.Until = "" }()
+
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		scannerCmd.Run()
+
+		assert.Empty(t, scannerCmd.blobs)
+		assert.Equal(t, 0, scannerCmd.exitStatus())
+	})
+}
+
+func TestScannerCmdFindsSecretsOnlyLeftInTheReflogWithDeepScan(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("*", "Commit secret")
+		os.Chdir(git.Root())
+		_, err := exec.Command("git", "reset", "--hard", "HEAD~1").CombinedOutput()
+		assert.NoError(t, err)
+
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		scannerCmd.Run()
+		assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected the regular scan not to look at the reflog")
+
+		options.DeepScan = true
+		defer func() { options.DeepScan = false }()
+		deepScannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		deepScannerCmd.Run()
+		assert.Equal(t, 1, deepScannerCmd.exitStatus(), "Expected the deep scan to find the secret in the reflog")
+		assert.NotEmpty(t, deepScannerCmd.results.GetFailures("reflog:some-dir/file-with-secret.txt"))
+	})
+}
+
+func TestScannerCmdDetectsSecretInACommitMessage(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/safe-file.txt", "safeContents")
+		git.AddAndcommit("*", "Configure access with "+awsAccessKeyIDExample)
+		os.Chdir(git.Root())
+
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, git.Root())
+		assert.NoError(t, err)
+		scannerCmd.Run()
+		assert.Equal(t, 1, scannerCmd.exitStatus(), "Expected ScannerCmd.exitStatus() to return 1 since a commit message contains a secret")
+		assert.NotEmpty(t, scannerCmd.results.GetFailures(gitrepo.Message{Kind: gitrepo.CommitMessage, ID: git.LatestCommit()}.Path()))
+	})
+}
+
+func TestScannerCmdReportsWhatWasScannedWhenInterruptedAndResumes(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("some-dir/file-with-secret.txt", "Commit secret")
+		os.Chdir(git.Root())
+		reportDirectory := t.TempDir()
+
+		interruptedCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, reportDirectory)
+		assert.NoError(t, err)
+		interruptedCmd.interrupt()
+		assert.Equal(t, 1, interruptedCmd.Run(), "Expected an interrupted scan to fail")
+		assert.FileExists(t, filepath.Join(reportDirectory, "talisman_reports", "data", "report.json"))
+		assert.FileExists(t, filepath.Join(reportDirectory, scanner.CheckpointFileName))
+
+		options.Resume = true
+		defer func() { options.Resume = false }()
+		resumedCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, reportDirectory)
+		assert.NoError(t, err)
+		resumedCmd.Run()
+		assert.Equal(t, 1, resumedCmd.exitStatus(), "Expected the resumed scan to find the secret")
+		assert.NoFileExists(t, filepath.Join(reportDirectory, scanner.CheckpointFileName), "Expected the checkpoint to be removed once the scan is complete")
+	})
+}
+
+func TestScannerCmdDoesNotRescanBlobsScannedBeforeTheCheckpoint(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("some-dir/file-with-secret.txt", "Commit secret")
+		os.Chdir(git.Root())
+		reportDirectory := t.TempDir()
+		options.Resume = true
+		defer func() { options.Resume = false }()
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, reportDirectory)
+		assert.NoError(t, err)
+		checkpoint := scanner.NewCheckpoint(reportDirectory, scanner.CheckpointKey(&talismanrc.TalismanRC{}, Version, historyToScan(false)))
+		checkpoint.Record(scannerCmd.blobs, helpers.NewDetectionResults())
+		assert.NoError(t, checkpoint.Save())
+
+		resumedCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, reportDirectory)
+		assert.NoError(t, err)
+		resumedCmd.Run()
+		assert.Equal(t, 0, resumedCmd.exitStatus(), "Expected every blob to be taken as scanned, with nothing found, from the checkpoint")
+	})
+}
+
+func TestScannerCmdAttributesFindingsToTheCommitThatIntroducedThem(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("simple-file")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("some-dir/file-with-secret.txt", "Commit secret")
+		os.Chdir(git.Root())
+		reportDirectory := t.TempDir()
+
+		scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, reportDirectory)
+		assert.NoError(t, err)
+		scannerCmd.Run()
+
+		attribution := scannerCmd.results.GetFailures("some-dir/file-with-secret.txt")[0].Attribution
+		assert.Equal(t, git.LatestCommit(), attribution.Commit)
+		assert.Equal(t, "Talisman Test User", attribution.Author)
+		assert.Equal(t, []string{"master"}, attribution.Branches)
+		assert.FileExists(t, filepath.Join(reportDirectory, "talisman_reports", "data", "authors.json"))
+	})
+}
+
+func TestScannerCmdRecordsTheHistoryMissingFromAPartialCloneInTheReport(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("simple-file", "safeContents")
+		git.AddAndcommit("simple-file", "initial commit")
+		git.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		git.AddAndcommit("some-dir/file-with-secret.txt", "Commit secret")
+		secretCommit := git.LatestCommit()
+		git.OverwriteFileContent("some-dir/file-with-secret.txt", "safeContents")
+		git.AddAndcommit("some-dir/file-with-secret.txt", "Remove secret")
+
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			os.Chdir(clone.Root())
+			reportDirectory := t.TempDir()
+
+			scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, reportDirectory)
+			assert.NoError(t, err)
+			scannerCmd.Run()
+
+			assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected the secret not to be found, as the clone has not fetched it")
+			coverage := scannerCmd.results.Coverage
+			if assert.NotNil(t, coverage) && assert.Len(t, coverage.SkippedFiles, 1) {
+				assert.False(t, coverage.Complete)
+				assert.Equal(t, 3, coverage.Commits)
+				assert.Equal(t, "some-dir/file-with-secret.txt", coverage.SkippedFiles[0].Path)
+				assert.Equal(t, []string{secretCommit}, coverage.SkippedFiles[0].Commits)
+			}
+			report, err := os.ReadFile(filepath.Join(reportDirectory, "talisman_reports", "data", "report.json"))
+			assert.NoError(t, err)
+			assert.Contains(t, string(report), `"skipped_files":[{"path":"some-dir/file-with-secret.txt"`)
+
+			fetch := exec.Command("git", "cat-file", "-p", coverage.SkippedFiles[0].Hash)
+			fetch.Dir = clone.Root()
+			assert.NoError(t, fetch.Run())
+			rescanCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, reportDirectory)
+			assert.NoError(t, err)
+			rescanCmd.Run()
+			assert.Equal(t, 1, rescanCmd.exitStatus(), "Expected the secret to be found once it is fetched, as the scan cache left it unscanned")
+			assert.True(t, rescanCmd.results.Coverage.Complete)
+		}, "--filter=blob:none")
+	})
+}
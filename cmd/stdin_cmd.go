@@ -0,0 +1,20 @@
+package main
+
+import (
+	"io"
+	"talisman/gitrepo"
+)
+
+// StdinCmd checks content piped to talisman, such as an editor buffer, as if it were a file with the given name
+type StdinCmd struct {
+	*runner
+}
+
+// NewStdinCmd reads all of the input as the contents of a file with the given name, which does not need to exist
+func NewStdinCmd(input io.Reader, fileName string) (*StdinCmd, error) {
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	return &StdinCmd{NewRunner([]gitrepo.Addition{gitrepo.NewAddition(fileName, data)}, "pattern")}, nil
+}
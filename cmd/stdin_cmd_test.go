@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"talisman/gitrepo"
+	"talisman/utility"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanningStdinChecksThePipedContentAsTheVirtualFile(t *testing.T) {
+	directory := t.TempDir()
+
+	assert.Equal(t, EXIT_SUCCESS, runFilesystemScan(directory, func() {
+		options.Stdin, options.StdinFilename, talismanInput = true, "notes.txt", strings.NewReader("safeContents")
+	}), "Expected run() to return 0 as the piped content is safe")
+	assert.Equal(t, EXIT_FAILURE, runFilesystemScan(directory, func() {
+		options.Stdin, options.StdinFilename, talismanInput = true, "notes.txt", strings.NewReader(awsAccessKeyIDExample)
+	}), "Expected run() to return 1 as the piped content holds a key")
+	assert.Equal(t, EXIT_FAILURE, runFilesystemScan(directory, func() {
+		options.Stdin, options.StdinFilename, talismanInput = true, "id_rsa", strings.NewReader("safeContents")
+	}), "Expected run() to return 1 as the virtual file name is that of a private key")
+}
+
+func TestScanningStdinMatchesIgnoresAgainstThePipedContentRatherThanTheFileOnDisk(t *testing.T) {
+	directory := t.TempDir()
+	writeFile(t, directory, "app.txt", "safeContents")
+	checksumOf := func(contents string) string {
+		additions := []gitrepo.Addition{gitrepo.NewAddition("app.txt", []byte(contents))}
+		return utility.NewAdditionsSHA256Hasher(additions).CollectiveSHA256Hash([]string{"app.txt"})
+	}
+
+	writeFile(t, directory, ".talismanrc", "fileignoreconfig:\n- filename: app.txt\n  checksum: "+checksumOf("safeContents")+"\n")
+	assert.Equal(t, EXIT_FAILURE, runFilesystemScan(directory, func() {
+		options.Stdin, options.StdinFilename, talismanInput = true, "app.txt", strings.NewReader(awsAccessKeyIDExample)
+	}), "Expected run() to return 1 as the ignore is for the content on disk, not the piped content")
+
+	writeFile(t, directory, ".talismanrc", "fileignoreconfig:\n- filename: app.txt\n  checksum: "+checksumOf(awsAccessKeyIDExample)+"\n")
+	assert.Equal(t, EXIT_SUCCESS, runFilesystemScan(directory, func() {
+		options.Stdin, options.StdinFilename, talismanInput = true, "app.txt", strings.NewReader(awsAccessKeyIDExample)
+	}), "Expected run() to return 0 as the ignore is for the piped content")
+}
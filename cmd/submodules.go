@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path"
+	"talisman/gitrepo"
+	"talisman/scanner"
+)
+
+// withSubmodules adds the blobs in the histories of the checked out submodules to the blobs of the superproject, and
+// returns them along with a reader that reads each blob from the repository holding it, and the paths of the submodules
+func withSubmodules(blobs []scanner.Blob, reader gitrepo.BatchReader, history scanner.History) ([]scanner.Blob, gitrepo.BatchReader, []string, error) {
+	wd, _ := os.Getwd()
+	superproject := gitrepo.RepoLocatedAt(wd)
+	submodules, err := superproject.Submodules()
+	if err != nil || len(submodules) == 0 {
+		return blobs, reader, nil, err
+	}
+	repositories := &repositoriesReader{readers: []gitrepo.BatchReader{reader}, readerOfBlob: map[string]gitrepo.BatchReader{}}
+	for _, submodule := range submodules {
+		submoduleBlobs, err := scanner.ListSubmoduleBlobs(history, submodule)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		submoduleReader := gitrepo.NewBatchGitObjectHashReader(superproject.Submodule(submodule).Root())
+		repositories.readers = append(repositories.readers, submoduleReader)
+		for _, blob := range submoduleBlobs {
+			repositories.readerOfBlob[blob.Hash] = submoduleReader
+		}
+		blobs = append(blobs, submoduleBlobs...)
+	}
+	return blobs, repositories, submodules, nil
+}
+
+// repositoriesReader reads each blob from the repository holding it, which is the superproject, read by the first
+// reader, unless the blob was listed in the history of one of its submodules
+type repositoriesReader struct {
+	readers      []gitrepo.BatchReader
+	readerOfBlob map[string]gitrepo.BatchReader
+}
+
+func (r *repositoriesReader) Start() error {
+	for i, reader := range r.readers {
+		if err := reader.Start(); err != nil {
+			for _, started := range r.readers[:i] {
+				started.Shutdown()
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *repositoriesReader) Read(hash string) ([]byte, error) {
+	if reader, ok := r.readerOfBlob[hash]; ok {
+		return reader.Read(hash)
+	}
+	return r.readers[0].Read(hash)
+}
+
+func (r *repositoriesReader) Shutdown() error {
+	var errs []error
+	for _, reader := range r.readers {
+		errs = append(errs, reader.Shutdown())
+	}
+	return errors.Join(errs...)
+}
+
+// submoduleAdditions returns the additions in the commits that submodules of the repository were made to point at
+// between oldCommit and newCommit, along with those of the submodules nested in them, with their paths given from the
+// top level of the repository, which is at prefix within the superproject. Submodules that are not checked out, or do
+// not hold those commits, are not checked.
+func submoduleAdditions(repo gitrepo.GitRepo, oldCommit string, newCommit string, prefix string) ([]gitrepo.Addition, error) {
+	updates, err := repo.SubmoduleUpdatesWithinRange(oldCommit, newCommit)
+	if err != nil {
+		return nil, err
+	}
+	var additions []gitrepo.Addition
+	for _, update := range updates {
+		submodule := repo.Submodule(update.Path)
+		oldSubmoduleCommit := update.OldCommit
+		if oldSubmoduleCommit == "" {
+			oldSubmoduleCommit = EmptyTreeSha
+		}
+		inSubmodule, err := submodule.AdditionsWithinRange(oldSubmoduleCommit, update.NewCommit)
+		if err == nil {
+			var nested []gitrepo.Addition
+			nested, err = submoduleAdditions(submodule, oldSubmoduleCommit, update.NewCommit, path.Join(prefix, update.Path))
+			inSubmodule = append(inSubmodule, nested...)
+		}
+		if err != nil {
+			printWarning("submodule %s was made to point at %s, whose changes could not be checked: %v",
+				path.Join(prefix, update.Path), update.NewCommit, err)
+			continue
+		}
+		for _, addition := range inSubmodule {
+			addition.Path = gitrepo.FilePath(path.Join(update.Path, string(addition.Path)))
+			if addition.RenamedFrom != "" {
+				addition.RenamedFrom = gitrepo.FilePath(path.Join(update.Path, string(addition.RenamedFrom)))
+			}
+			additions = append(additions, addition)
+		}
+	}
+	return additions, nil
+}
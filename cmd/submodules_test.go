@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"talisman/git_testing"
+	"talisman/gitrepo"
+	"talisman/prompt"
+	"talisman/talismanrc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHooksRunInASubdirectoryOfALinkedWorktreeReadTheTalismanrcAtItsTopLevel(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents(".talismanrc", "custom_patterns:\n- 'forbidden_[a-z]{8}'\n")
+		git.CreateFileWithContents("some-dir/simple-file", "safeContents")
+		git.AddAndcommit("*", "initial commit")
+
+		git.DoInWorktree(func(worktree *git_testing.GitTesting) {
+			worktree.CreateFileWithContents("some-dir/notes.txt", "forbidden_password")
+			worktree.Add("some-dir/notes.txt")
+			saved := options
+			defer func() { options = saved }()
+			options.Scan, options.ScanWithHtml, options.Pattern, options.Checksum, options.GitHook = false, false, "", "", PreCommit
+			wd, _ := os.Getwd()
+			os.Chdir(filepath.Join(worktree.Root(), "some-dir"))
+			defer os.Chdir(wd)
+
+			exitCode := run(prompt.NewPromptContext(false, prompt.NewPrompt()))
+
+			assert.Equal(t, EXIT_FAILURE, exitCode, "Expected run() to return 1 as the custom pattern of the worktree is found")
+		})
+	})
+}
+
+func TestScanningWithSubmodulesReportsFindingsInSubmodulesUnderTheirPath(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(library *git_testing.GitTesting) {
+		library.CreateFileWithContents("some-dir/file-with-secret.txt", awsAccessKeyIDExample)
+		library.AddAndcommit("some-dir/file-with-secret.txt", "Commit secret")
+		library.OverwriteFileContent("some-dir/file-with-secret.txt", "safeContents")
+		library.AddAndcommit("some-dir/file-with-secret.txt", "Remove secret")
+		git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+			git.CreateFileWithContents("simple-file", "safeContents")
+			git.AddAndcommit("simple-file", "initial commit")
+			git.AddSubmodule(library, "vendor/library")
+			git.Commit(".", "add library submodule")
+			saved, wd := options, ""
+			defer func() { options = saved }()
+			wd, _ = os.Getwd()
+			os.Chdir(git.Root())
+			defer os.Chdir(wd)
+
+			scannerCmd, err := NewScannerCmd(false, &talismanrc.TalismanRC{}, t.TempDir())
+			assert.NoError(t, err)
+			scannerCmd.Run()
+			assert.Equal(t, 0, scannerCmd.exitStatus(), "Expected the secret not to be found, as submodules are not scanned by default")
+
+			options.RecurseSubmodules, options.NoCache = true, true
+			scannerCmd, err = NewScannerCmd(false, &talismanrc.TalismanRC{}, t.TempDir())
+			assert.NoError(t, err)
+			scannerCmd.Run()
+			assert.Equal(t, 1, scannerCmd.exitStatus(), "Expected the secret in the history of the submodule to be found")
+			results := scannerCmd.results.Results
+			if assert.Len(t, results, 1) && assert.NotEmpty(t, results[0].FailureList) {
+				assert.Equal(t, gitrepo.FilePath("vendor/library/some-dir/file-with-secret.txt"), results[0].Filename)
+				if attribution := results[0].FailureList[0].Attribution; assert.NotNil(t, attribution) {
+					assert.Equal(t, library.EarliestCommit(), attribution.Commit)
+				}
+			}
+		})
+	})
+}
+
+func TestPushingWithSubmodulesChecksTheCommitsSubmodulesAreMadeToPointAt(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(library *git_testing.GitTesting) {
+		library.CreateFileWithContents("simple-file", "safeContents")
+		library.AddAndcommit("simple-file", "initial commit")
+		git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+			git.CreateFileWithContents("simple-file", "safeContents")
+			git.AddAndcommit("simple-file", "initial commit")
+			checkedOut := git.AddSubmodule(library, "vendor/library")
+			git.Commit(".", "add library submodule")
+			before := git.LatestCommit()
+			checkedOut.CreateFileWithContents("private.pem", "secret")
+			checkedOut.AddAndcommit("private.pem", "add private key")
+			git.AddAndcommit("vendor/library", "update library submodule")
+			saved := options
+			defer func() { options = saved }()
+
+			exitCode := runPrePushHookIn(git.Root(), before, git.LatestCommit())
+			assert.Equal(t, EXIT_SUCCESS, exitCode, "Expected run() to return 0 as submodules are not checked by default")
+
+			options.RecurseSubmodules = true
+			exitCode = runPrePushHookIn(git.Root(), before, git.LatestCommit())
+			assert.Equal(t, EXIT_FAILURE, exitCode, "Expected run() to return 1 as the submodule was made to point at a private key")
+			exitCode = runPrePushHookIn(git.Root(), EmptySha, git.LatestCommit())
+			assert.Equal(t, EXIT_FAILURE, exitCode, "Expected run() to return 1 as the new ref adds the submodule")
+		})
+	})
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"runtime/pprof"
 	"strings"
+	"talisman/filesystem"
+	"talisman/gitrepo"
 	"talisman/utility"
 	"time"
 
@@ -33,23 +35,59 @@ const (
 	PrePush = "pre-push"
 	//PreCommit : Const for name of of pre-commit hook
 	PreCommit = "pre-commit"
+	//CommitMsg : Const for name of commit-msg hook
+	CommitMsg = "commit-msg"
+	//PrepareCommitMsg : Const for name of prepare-commit-msg hook
+	PrepareCommitMsg = "prepare-commit-msg"
+	//Validate : Const for name of the subcommand that validates .talismanrc
+	Validate = "validate"
 	//EXIT_SUCCESS : Const to indicate successful talisman invocation
 	EXIT_SUCCESS = 0
 	//EXIT_FAILURE : Const to indicate failed successful invocation
 	EXIT_FAILURE = 1
+	//EXIT_GIT_ERROR : Const to indicate that a git command failed for a reason without an exit code of its own
+	EXIT_GIT_ERROR = 2
+	//EXIT_NOT_A_REPOSITORY : Const to indicate that talisman was run outside of a git repository
+	EXIT_NOT_A_REPOSITORY = 3
+	//EXIT_NO_COMMITS : Const to indicate that the repository has no commits yet
+	EXIT_NO_COMMITS = 4
+	//EXIT_SHALLOW_HISTORY : Const to indicate that the history needed is missing from a shallow clone
+	EXIT_SHALLOW_HISTORY = 5
+	//EXIT_MISSING_OBJECT : Const to indicate that a commit or object is missing from the repository
+	EXIT_MISSING_OBJECT = 6
 )
 
 var options struct {
-	Debug           bool
-	LogLevel        string
-	GitHook         string
-	Pattern         string
-	Scan            bool
-	IgnoreHistory   bool
-	Checksum        string
-	ReportDirectory string
-	ScanWithHtml    bool
-	ShouldProfile   bool
+	Debug             bool
+	LogLevel          string
+	GitHook           string
+	MessageFile       string
+	Pattern           string
+	Directory         string
+	Exclude           []string
+	Stdin             bool
+	StdinFilename     string
+	Image             string
+	Scan              bool
+	IgnoreHistory     bool
+	Revisions         []string
+	Since             string
+	Until             string
+	Paths             []string
+	DeepScan          bool
+	Checksum          string
+	ReportDirectory   string
+	CacheDirectory    string
+	NoCache           bool
+	Resume            bool
+	Blame             bool
+	NativeGit         bool
+	RecurseSubmodules bool
+	ScanWithHtml      bool
+	ShouldProfile     bool
+	Validate          bool
+	Set               []string
+	PrintConfig       bool
 }
 
 //var options Options
@@ -69,21 +107,69 @@ func init() {
 	flag.StringVarP(&options.Pattern,
 		"pattern", "p", "",
 		"pattern (glob-like) of files to scan (ignores githooks)")
+	flag.StringVar(&options.Directory,
+		"directory", "",
+		"scan the files in this directory tree without git, leaving out those excluded by its .gitignore files (ignores githooks)")
+	flag.StringSliceVar(&options.Exclude,
+		"exclude", nil,
+		"leave out files matching these patterns, written as in .gitignore, when scanning a --directory, --pattern or --image (comma separated)")
+	flag.BoolVar(&options.Stdin,
+		"stdin", false,
+		"scan the content piped to talisman as if it were the file named by --stdinFilename (ignores githooks)")
+	flag.StringVar(&options.StdinFilename,
+		"stdinFilename", "stdin",
+		"name of the file the content piped with --stdin is scanned as")
+	flag.StringVar(&options.Image,
+		"image", "",
+		"scan the layers of a container image saved to this tarball by docker save, or as an OCI image layout (ignores githooks)")
 	flag.StringVarP(&options.GitHook,
 		"githook", "g", PrePush,
-		"either pre-push or pre-commit")
+		"either pre-push, pre-commit, commit-msg or prepare-commit-msg")
 	flag.BoolVarP(&options.Scan,
 		"scan", "s", false,
 		"scanner scans the git commit history for potential secrets")
 	flag.BoolVarP(&options.IgnoreHistory,
 		"ignoreHistory", "^", false,
 		"scanner scans all files on current head, will not scan through git commit history")
+	flag.StringSliceVar(&options.Revisions,
+		"revisions", nil,
+		"scanner scans only these branches, tags, commits or revision ranges such as v1.0..v1.1 (comma separated)")
+	flag.StringVar(&options.Since,
+		"since", "",
+		"scanner scans only commits made after this date, e.g. --since=\"1 day ago\"")
+	flag.StringVar(&options.Until,
+		"until", "",
+		"scanner scans only commits made before this date")
+	flag.StringSliceVar(&options.Paths,
+		"paths", nil,
+		"scanner scans only files matching these patterns, written as in .talismanrc (comma separated)")
+	flag.BoolVar(&options.DeepScan,
+		"deepScan", false,
+		"scanner also scans stash entries, reflog entries, notes and unreachable objects")
 	flag.StringVarP(&options.Checksum,
 		"checksum", "c", "",
 		"checksum calculator calculates checksum and suggests .talismanrc entry")
 	flag.StringVarP(&options.ReportDirectory,
 		"reportDirectory", "r", "talisman_report",
 		"directory where the scan report will be stored")
+	flag.StringVar(&options.CacheDirectory,
+		"cacheDirectory", "",
+		"directory where the history scan cache is kept (default: .git/talisman)")
+	flag.BoolVar(&options.NoCache,
+		"noCache", false,
+		"scan the whole git commit history without using or updating the scan cache")
+	flag.BoolVar(&options.Blame,
+		"blame", false,
+		"pre-push hook reports the author of the commit that added each finding, using git blame")
+	flag.BoolVar(&options.Resume,
+		"resume", false,
+		"scanner continues an interrupted scan from its last checkpoint in the report directory")
+	flag.BoolVar(&options.NativeGit,
+		"nativeGit", false,
+		"read files and blobs directly from the repository instead of running git for each of them")
+	flag.BoolVar(&options.RecurseSubmodules,
+		"recurseSubmodules", false,
+		"scanner and pre-push hook also check the checked out submodules, and the commits pushed submodules are made to point at")
 	flag.BoolVarP(&options.ScanWithHtml,
 		"scanWithHtml", "w", false,
 		"generate html report (**Make sure you have installed talisman_html_report to use this, as mentioned in talisman Readme**)")
@@ -93,12 +179,19 @@ func init() {
 	flag.BoolVarP(&options.ShouldProfile,
 		"profile", "f", false,
 		"profile cpu and memory usage of talisman")
+	flag.StringArrayVar(&options.Set,
+		"set", nil,
+		"override a .talismanrc setting for this run, e.g. --set threshold=high (can be repeated)")
+	flag.BoolVar(&options.PrintConfig,
+		"print-config", false,
+		"print the resolved .talismanrc settings and where each value came from")
 }
 
 func main() {
 	flag.Parse()
+	options.Validate = flag.Arg(0) == Validate
 
-	if flag.NFlag() == 0 {
+	if flag.NFlag() == 0 && !options.Validate {
 		flag.PrintDefaults()
 		os.Exit(EXIT_SUCCESS)
 	}
@@ -109,10 +202,18 @@ func main() {
 	}
 
 	if options.GitHook != "" {
-		if !(options.GitHook == PreCommit || options.GitHook == PrePush) {
-			fmt.Println(fmt.Errorf("githook should be %s or %s, but got %s", PreCommit, PrePush, options.GitHook))
+		if !(options.GitHook == PreCommit || options.GitHook == PrePush || isMessageHook(options.GitHook)) {
+			fmt.Println(fmt.Errorf("githook should be %s, %s, %s or %s, but got %s", PreCommit, PrePush, CommitMsg, PrepareCommitMsg, options.GitHook))
 			os.Exit(EXIT_FAILURE)
 		}
+		if isMessageHook(options.GitHook) {
+			// git passes the file holding the commit message as the first argument of both message hooks
+			options.MessageFile = flag.Arg(0)
+			if options.MessageFile == "" {
+				fmt.Println(fmt.Errorf("%s hook needs the file holding the commit message", options.GitHook))
+				os.Exit(EXIT_FAILURE)
+			}
+		}
 	}
 
 	if options.ShouldProfile {
@@ -134,6 +235,7 @@ func run(promptContext prompt.PromptContext) (returnCode int) {
 	}
 
 	setLogLevel()
+	gitrepo.UseNativeReader(options.NativeGit)
 
 	if options.GitHook == "" {
 		options.GitHook = PrePush
@@ -144,45 +246,167 @@ func run(promptContext prompt.PromptContext) (returnCode int) {
 	_ = json.Unmarshal(optionsBytes, &fields)
 	log.WithFields(fields).Debug("Talisman execution environment")
 	defer utility.DestroyHashers()
-	if options.Checksum != "" {
+	if requiresRepository() {
+		if err := changeToTopLevel(); err != nil {
+			return gitErrorExitCode(err)
+		}
+	}
+	if options.Validate {
+		log.Infof("Validating %s", talismanrc.RCFileName)
+		return NewValidateCmd().Run()
+	} else if options.PrintConfig {
+		talismanrc, err := loadTalismanRC()
+		if err != nil {
+			return EXIT_FAILURE
+		}
+		return NewPrintConfigCmd(talismanrc).Run()
+	} else if options.Checksum != "" {
 		log.Infof("Running %s patterns against checksum calculator", options.Checksum)
 		return NewChecksumCmd(strings.Fields(options.Checksum)).Run()
 	} else if options.Scan {
 		log.Infof("Running scanner")
-		talismanrc, err := talismanrc.Load()
+		talismanrc, err := loadTalismanRC()
 		if err != nil {
 			return EXIT_FAILURE
 		}
-		return NewScannerCmd(options.IgnoreHistory, talismanrc, options.ReportDirectory).Run()
+		scannerCmd, err := NewScannerCmd(options.IgnoreHistory, talismanrc, options.ReportDirectory)
+		if err != nil {
+			return gitErrorExitCode(err)
+		}
+		return scannerCmd.Run()
 	} else if options.ScanWithHtml {
 		log.Infof("Running scanner with html report")
-		talismanrc, err := talismanrc.Load()
+		talismanrc, err := loadTalismanRC()
 		if err != nil {
 			return EXIT_FAILURE
 		}
-		return NewScannerCmd(options.IgnoreHistory, talismanrc, "talisman_html_report").Run()
+		scannerCmd, err := NewScannerCmd(options.IgnoreHistory, talismanrc, "talisman_html_report")
+		if err != nil {
+			return gitErrorExitCode(err)
+		}
+		return scannerCmd.Run()
 	} else if options.Pattern != "" {
 		log.Infof("Running scan for %s", options.Pattern)
-		talismanrc, err := talismanrc.Load()
+		talismanrc, err := loadTalismanRC()
+		if err != nil {
+			return EXIT_FAILURE
+		}
+		return NewPatternCmd(options.Pattern, filesystem.NewExcludes(".", options.Exclude...)).Run(talismanrc, promptContext)
+	} else if options.Directory != "" {
+		log.Infof("Running scan of directory %s", options.Directory)
+		talismanrc, err := loadTalismanRC()
 		if err != nil {
 			return EXIT_FAILURE
 		}
-		return NewPatternCmd(options.Pattern).Run(talismanrc, promptContext)
+		directoryCmd, err := NewDirectoryCmd(options.Directory, filesystem.NewExcludes(options.Directory, options.Exclude...))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "talisman: unable to read %s: %v\n", options.Directory, err)
+			return EXIT_FAILURE
+		}
+		return directoryCmd.Run(talismanrc, promptContext)
+	} else if options.Stdin {
+		log.Infof("Running scan of stdin as %s", options.StdinFilename)
+		talismanrc, err := loadTalismanRC()
+		if err != nil {
+			return EXIT_FAILURE
+		}
+		stdinCmd, err := NewStdinCmd(talismanInput, options.StdinFilename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "talisman: unable to read stdin: %v\n", err)
+			return EXIT_FAILURE
+		}
+		return stdinCmd.Run(talismanrc, promptContext)
+	} else if options.Image != "" {
+		log.Infof("Running scan of image %s", options.Image)
+		talismanrc, err := loadTalismanRC()
+		if err != nil {
+			return EXIT_FAILURE
+		}
+		imageCmd, err := NewImageCmd(options.Image, filesystem.NewExcludes(".", options.Exclude...), options.ReportDirectory)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "talisman: unable to read %s: %v\n", options.Image, err)
+			return EXIT_FAILURE
+		}
+		return imageCmd.Run(talismanrc)
 	} else if options.GitHook == PreCommit {
 		log.Infof("Running %s hook", options.GitHook)
-		talismanrc, err := talismanrc.Load()
+		talismanrc, err := loadTalismanRC()
+		if err != nil {
+			return EXIT_FAILURE
+		}
+		preCommitHook, err := NewPreCommitHook()
+		if err != nil {
+			return gitErrorExitCode(err)
+		}
+		return preCommitHook.Run(talismanrc, promptContext)
+	} else if isMessageHook(options.GitHook) {
+		log.Infof("Running %s hook", options.GitHook)
+		talismanrc, err := loadTalismanRC()
 		if err != nil {
 			return EXIT_FAILURE
 		}
-		return NewPreCommitHook().Run(talismanrc, promptContext)
+		commitMsgHook, err := NewCommitMsgHook(options.MessageFile, options.GitHook)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "talisman: unable to read the commit message: %v\n", err)
+			return EXIT_FAILURE
+		}
+		return commitMsgHook.Run(talismanrc, promptContext)
 	} else {
 		log.Infof("Running %s hook", options.GitHook)
-		talismanrc, err := talismanrc.Load()
+		talismanrc, err := loadTalismanRC()
 		if err != nil {
 			return EXIT_FAILURE
 		}
-		return NewPrePushHook(talismanInput).Run(talismanrc, promptContext)
+		prePushHook, err := NewPrePushHook(talismanInput)
+		if err != nil {
+			return gitErrorExitCode(err)
+		}
+		return prePushHook.Run(talismanrc, promptContext)
+	}
+}
+
+// requiresRepository reports whether the selected mode reads a git repository, as all but printing the configuration,
+// scanning files by pattern, in a directory or on stdin, and checking commit messages do. Validating does, so that the
+// .talismanrc at the top level is checked wherever in the working tree talisman is run.
+func requiresRepository() bool {
+	switch {
+	case options.PrintConfig:
+		return false
+	case options.Validate || options.Checksum != "" || options.Scan || options.ScanWithHtml:
+		return true
+	default:
+		return options.Pattern == "" && options.Directory == "" && !options.Stdin && options.Image == "" &&
+			!isMessageHook(options.GitHook)
+	}
+}
+
+// changeToTopLevel moves to the top level of the working tree talisman was run in, as git finds it from a
+// subdirectory, a linked worktree or a submodule, so that file paths and .talismanrc are read from there
+func changeToTopLevel() error {
+	wd, _ := os.Getwd()
+	repo, err := gitrepo.Locate(wd)
+	if err != nil {
+		return err
+	}
+	return os.Chdir(repo.Root())
+}
+
+// loadTalismanRC loads .talismanrc and applies the overrides from environment variables and --set on top of it
+func loadTalismanRC() (*talismanrc.TalismanRC, error) {
+	tRC, err := talismanrc.Load()
+	if err != nil {
+		return tRC, err
+	}
+	overrides := talismanrc.EnvironmentOverrides(os.LookupEnv)
+	commandLineOverrides, err := talismanrc.CommandLineOverrides(options.Set)
+	if err == nil {
+		err = tRC.ApplyOverrides(append(overrides, commandLineOverrides...))
+	}
+	if err != nil {
+		fmt.Println(fmt.Errorf("\n\x1b[1m\x1b[31mInvalid setting override: %s\x1b[0m\x1b[0m", err))
+		return tRC, err
 	}
+	return tRC, nil
 }
 
 func validateGitExecutable(fs afero.Fs, operatingSystem string) error {
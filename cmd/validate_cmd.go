@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"talisman/talismanrc"
+)
+
+type ValidateCmd struct{}
+
+// NewValidateCmd returns a command that checks the .talismanrc file against its schema
+func NewValidateCmd() *ValidateCmd {
+	return &ValidateCmd{}
+}
+
+// Run reports every problem found in the .talismanrc file and returns 0 only if there were none
+func (v *ValidateCmd) Run() int {
+	validationErrors, err := talismanrc.Validate()
+	if os.IsNotExist(err) {
+		wd, _ := os.Getwd()
+		fmt.Fprintf(os.Stderr, "talisman: no %s found in %s\n", talismanrc.RCFileName, wd)
+		return EXIT_FAILURE
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "talisman: unable to read %s: %v\n", talismanrc.RCFileName, err)
+		return EXIT_FAILURE
+	}
+	if len(validationErrors) > 0 {
+		fmt.Print(talismanrc.FormatValidationErrors(validationErrors))
+		return EXIT_FAILURE
+	}
+	fmt.Printf("%s is valid\n", talismanrc.RCFileName)
+	return EXIT_SUCCESS
+}
@@ -0,0 +1,178 @@
+package containerimage
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// archive is an image tarball whose entries, such as layers, are read in place rather than extracted
+type archive struct {
+	file *os.File
+	// temporary is the decompressed copy of a compressed tarball, which is removed when the archive is closed
+	temporary string
+	entries   map[string]archiveEntry
+	links     map[string]string
+}
+
+type archiveEntry struct {
+	offset, size int64
+}
+
+// openArchive indexes the entries of the tarball. A gzip compressed tarball, such as the output of
+// docker save | gzip, is decompressed to a temporary file first, as its entries cannot be read in place.
+func openArchive(tarballPath string) (*archive, error) {
+	file, err := os.Open(tarballPath)
+	if err != nil {
+		return nil, err
+	}
+	a := &archive{file: file, entries: map[string]archiveEntry{}, links: map[string]string{}}
+	if err := a.decompress(); err != nil {
+		a.close()
+		return nil, err
+	}
+	if err := a.index(); err != nil {
+		a.close()
+		return nil, fmt.Errorf("unable to read %s: %w", tarballPath, err)
+	}
+	return a, nil
+}
+
+func (a *archive) decompress() error {
+	magic := make([]byte, len(gzipMagic))
+	if _, err := io.ReadFull(a.file, magic); err != nil || !bytes.Equal(magic, gzipMagic) {
+		_, seekErr := a.file.Seek(0, io.SeekStart)
+		return seekErr
+	}
+	if _, err := a.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	decompressed, err := gzip.NewReader(a.file)
+	if err != nil {
+		return err
+	}
+	temporary, err := os.CreateTemp("", "talisman-image-*.tar")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(temporary, decompressed)
+	a.file.Close()
+	a.file, a.temporary = temporary, temporary.Name()
+	if err != nil {
+		return err
+	}
+	_, err = temporary.Seek(0, io.SeekStart)
+	return err
+}
+
+// index records where the contents of each file in the tarball start, by counting the bytes the tar reader has read
+// once it has read the header of the file
+func (a *archive) index() error {
+	counter := &countingReader{reader: a.file}
+	entries := tar.NewReader(counter)
+	for {
+		header, err := entries.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		name := cleanName(header.Name)
+		switch header.Typeflag {
+		case tar.TypeReg:
+			a.entries[name] = archiveEntry{offset: counter.read, size: header.Size}
+		case tar.TypeSymlink:
+			a.links[name] = cleanName(path.Join(path.Dir(name), header.Linkname))
+		}
+	}
+}
+
+func (a *archive) resolve(name string) (archiveEntry, bool) {
+	name = cleanName(name)
+	for followed := 0; followed < 8; followed++ {
+		if entry, ok := a.entries[name]; ok {
+			return entry, true
+		}
+		target, ok := a.links[name]
+		if !ok {
+			break
+		}
+		name = target
+	}
+	return archiveEntry{}, false
+}
+
+func (a *archive) has(name string) bool {
+	_, ok := a.resolve(name)
+	return ok
+}
+
+// open returns the contents of an entry of the tarball
+func (a *archive) open(name string) (*io.SectionReader, error) {
+	entry, ok := a.resolve(name)
+	if !ok {
+		return nil, fmt.Errorf("the image tarball has no %s", name)
+	}
+	return io.NewSectionReader(a.file, entry.offset, entry.size), nil
+}
+
+func (a *archive) readJSON(name string, value interface{}) error {
+	contents, err := a.open(name)
+	if err != nil {
+		return err
+	}
+	if err := json.NewDecoder(contents).Decode(value); err != nil {
+		return fmt.Errorf("unable to read %s: %w", name, err)
+	}
+	return nil
+}
+
+func (a *archive) close() error {
+	err := a.file.Close()
+	if a.temporary != "" {
+		os.Remove(a.temporary)
+	}
+	return err
+}
+
+// decompressed returns the tar of a layer, which image tarballs hold either as is or gzip compressed
+func decompressed(layer io.Reader) (io.Reader, error) {
+	buffered := bufio.NewReader(layer)
+	magic, _ := buffered.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		return gzip.NewReader(buffered)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return nil, fmt.Errorf("zstd compressed layers are not supported")
+	}
+	return buffered, nil
+}
+
+// cleanName returns the path of an entry of a tar without a leading ./ or /
+func cleanName(name string) string {
+	return strings.TrimPrefix(path.Clean("/"+name), "/")
+}
+
+type countingReader struct {
+	reader io.Reader
+	read   int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.read += int64(n)
+	return n, err
+}
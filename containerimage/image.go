@@ -0,0 +1,328 @@
+package containerimage
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"talisman/detector/helpers"
+	"talisman/filesystem"
+	"talisman/gitrepo"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	dockerManifestFile = "manifest.json"
+	ociIndexFile       = "index.json"
+	whiteoutPrefix     = ".wh."
+)
+
+var indexMediaTypes = map[string]bool{
+	"application/vnd.oci.image.index.v1+json":                   true,
+	"application/vnd.docker.distribution.manifest.list.v2+json": true,
+}
+
+// Image is a container image saved to a tarball, either by docker save or as an OCI image layout, whose layers are
+// read from the tarball without a registry or a container runtime
+type Image struct {
+	archive *archive
+	layers  []*Layer
+}
+
+// Layer is a layer of an image, in the order the image applies them
+type Layer struct {
+	// Digest identifies the layer, by the digest of its blob, or by the digest of its tar for the layer.tar files of
+	// older docker save tarballs
+	Digest string
+	// Instruction is the instruction that created the layer, as recorded in the history of the image config
+	Instruction string
+	// Files is the number of files of the layer that are scanned, once they are counted
+	Files int
+
+	path string
+}
+
+type descriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type dockerManifest struct {
+	Config string
+	Layers []string
+}
+
+type ociIndex struct {
+	Manifests []descriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	Config descriptor   `json:"config"`
+	Layers []descriptor `json:"layers"`
+}
+
+type imageConfig struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+	History []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	} `json:"history"`
+}
+
+// Open reads the manifests of the image tarball at tarballPath. When the tarball holds several images, or an image
+// for several platforms, the layers of all of them that the tarball holds are read, each layer once.
+func Open(tarballPath string) (*Image, error) {
+	a, err := openArchive(tarballPath)
+	if err != nil {
+		return nil, err
+	}
+	image := &Image{archive: a}
+	switch {
+	case a.has(dockerManifestFile):
+		err = image.readDockerManifest()
+	case a.has(ociIndexFile):
+		err = image.readOCIIndex(ociIndexFile)
+	default:
+		err = fmt.Errorf("%s is neither a docker save tarball nor an OCI image layout", tarballPath)
+	}
+	if err != nil {
+		a.close()
+		return nil, err
+	}
+	return image, nil
+}
+
+// Close removes what was written to read the tarball
+func (i *Image) Close() error {
+	return i.archive.close()
+}
+
+// Layers returns the layers of the image
+func (i *Image) Layers() []*Layer {
+	return i.layers
+}
+
+func (i *Image) readDockerManifest() error {
+	var manifests []dockerManifest
+	if err := i.archive.readJSON(dockerManifestFile, &manifests); err != nil {
+		return err
+	}
+	for _, manifest := range manifests {
+		config, err := i.readConfig(manifest.Config)
+		if err != nil {
+			return err
+		}
+		instructions := config.instructions()
+		for n, layerPath := range manifest.Layers {
+			digest := blobDigest(layerPath)
+			if digest == "" && n < len(config.RootFS.DiffIDs) {
+				digest = config.RootFS.DiffIDs[n]
+			}
+			if digest == "" {
+				digest = layerPath
+			}
+			i.addLayer(&Layer{Digest: digest, Instruction: instruction(instructions, n), path: layerPath})
+		}
+	}
+	return nil
+}
+
+// readOCIIndex reads the manifests an index of an OCI image layout points at, following nested indexes, such as
+// those of images built for several platforms. Manifests the layout does not hold, such as those of platforms that
+// were not pulled, are skipped.
+func (i *Image) readOCIIndex(indexPath string) error {
+	var index ociIndex
+	if err := i.archive.readJSON(indexPath, &index); err != nil {
+		return err
+	}
+	for _, manifest := range index.Manifests {
+		manifestPath := blobPath(manifest.Digest)
+		if !i.archive.has(manifestPath) {
+			log.Debugf("skipping manifest %s, which the image layout does not hold", manifest.Digest)
+			continue
+		}
+		var err error
+		if indexMediaTypes[manifest.MediaType] {
+			err = i.readOCIIndex(manifestPath)
+		} else {
+			err = i.readOCIManifest(manifestPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Image) readOCIManifest(manifestPath string) error {
+	var manifest ociManifest
+	if err := i.archive.readJSON(manifestPath, &manifest); err != nil {
+		return err
+	}
+	config, err := i.readConfig(blobPath(manifest.Config.Digest))
+	if err != nil {
+		return err
+	}
+	instructions := config.instructions()
+	for n, layer := range manifest.Layers {
+		layerPath := blobPath(layer.Digest)
+		if !i.archive.has(layerPath) {
+			log.Warnf("skipping layer %s, which the image layout does not hold", layer.Digest)
+			continue
+		}
+		i.addLayer(&Layer{Digest: layer.Digest, Instruction: instruction(instructions, n), path: layerPath})
+	}
+	return nil
+}
+
+func (i *Image) readConfig(configPath string) (imageConfig, error) {
+	var config imageConfig
+	err := i.archive.readJSON(configPath, &config)
+	return config, err
+}
+
+// addLayer adds a layer unless it was already added, as layers shared by the images of a tarball are stored once
+func (i *Image) addLayer(layer *Layer) {
+	for _, added := range i.layers {
+		if added.path == layer.path {
+			return
+		}
+	}
+	i.layers = append(i.layers, layer)
+}
+
+// CountFiles counts the files of each layer that are not excluded, and returns how many there are in all
+func (i *Image) CountFiles(excludes *filesystem.Excludes) (int, error) {
+	total := 0
+	for _, layer := range i.layers {
+		layer.Files = 0
+		err := i.readLayer(layer, excludes, func(string, *tar.Reader) error {
+			layer.Files++
+			return nil
+		})
+		if err != nil {
+			return 0, err
+		}
+		total += layer.Files
+	}
+	return total, nil
+}
+
+// StreamFiles reads the files of the layers that are not excluded, one at a time, as additions whose paths are
+// prefixed with the label of the layer. Files that later layers delete are read too, as the layers that hold them
+// are still part of the image. It returns a function that tells, once the additions are all received, whether the
+// layers could be read.
+func (i *Image) StreamFiles(excludes *filesystem.Excludes) (<-chan gitrepo.Addition, func() error) {
+	additions := make(chan gitrepo.Addition)
+	var streamErr error
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(additions)
+		for _, layer := range i.layers {
+			label := helpers.LayerLabel(layer.Digest)
+			streamErr = i.readLayer(layer, excludes, func(filePath string, contents *tar.Reader) error {
+				data, err := io.ReadAll(contents)
+				if err != nil {
+					return err
+				}
+				additions <- gitrepo.NewAddition(label+":"+filePath, data)
+				return nil
+			})
+			if streamErr != nil {
+				return
+			}
+		}
+	}()
+	return additions, func() error {
+		<-done
+		return streamErr
+	}
+}
+
+// readLayer calls read with the path and the contents of each regular file of the layer that is not excluded.
+// The whiteout files a layer uses to delete the files of the layers below it are left out.
+func (i *Image) readLayer(layer *Layer, excludes *filesystem.Excludes, read func(filePath string, contents *tar.Reader) error) error {
+	blob, err := i.archive.open(layer.path)
+	if err != nil {
+		return err
+	}
+	layerTar, err := decompressed(blob)
+	if err != nil {
+		return fmt.Errorf("unable to read layer %s: %w", layer.Digest, err)
+	}
+	entries := tar.NewReader(layerTar)
+	for {
+		header, err := entries.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("unable to read layer %s: %w", layer.Digest, err)
+		}
+		filePath := cleanName(header.Name)
+		if header.Typeflag != tar.TypeReg || strings.HasPrefix(path.Base(filePath), whiteoutPrefix) {
+			continue
+		}
+		if excludes.ExcludedFile(filePath) {
+			log.Debugf("skipping excluded %s in layer %s", filePath, layer.Digest)
+			continue
+		}
+		if err := read(filePath, entries); err != nil {
+			return err
+		}
+	}
+}
+
+// instructions returns the instructions that created the layers of the image, in order. Instructions that did not
+// create a layer, such as ENV, are left out.
+func (c imageConfig) instructions() []string {
+	var instructions []string
+	for _, entry := range c.History {
+		if !entry.EmptyLayer {
+			instructions = append(instructions, cleanInstruction(entry.CreatedBy))
+		}
+	}
+	return instructions
+}
+
+// cleanInstruction returns the instruction as written in the Dockerfile, where the history records it as the shell
+// command that ran it, as the classic builder does, or with the shell RUN used, as BuildKit does
+func cleanInstruction(createdBy string) string {
+	instruction := strings.TrimSpace(createdBy)
+	if nop, found := strings.CutPrefix(instruction, "/bin/sh -c #(nop)"); found {
+		instruction = strings.TrimSpace(nop)
+	} else if command, found := strings.CutPrefix(instruction, "/bin/sh -c "); found {
+		instruction = "RUN " + command
+	}
+	instruction = strings.TrimSuffix(instruction, " # buildkit")
+	instruction = strings.Replace(instruction, "RUN /bin/sh -c ", "RUN ", 1)
+	return strings.TrimSpace(instruction)
+}
+
+func instruction(instructions []string, n int) string {
+	if n < len(instructions) {
+		return instructions[n]
+	}
+	return ""
+}
+
+// blobPath returns the path of a blob within an OCI image layout, from its digest
+func blobPath(digest string) string {
+	algorithm, hex, _ := strings.Cut(digest, ":")
+	return path.Join("blobs", algorithm, hex)
+}
+
+// blobDigest returns the digest of a blob from its path within an OCI image layout, which newer docker save tarballs
+// also are, or nothing for a path outside of it
+func blobDigest(blobPath string) string {
+	parts := strings.Split(cleanName(blobPath), "/")
+	if len(parts) != 3 || parts[0] != "blobs" {
+		return ""
+	}
+	return parts[1] + ":" + parts[2]
+}
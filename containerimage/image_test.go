@@ -0,0 +1,194 @@
+package containerimage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"talisman/filesystem"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type file struct {
+	name     string
+	contents string
+}
+
+func tarOf(files ...file) []byte {
+	var buffer bytes.Buffer
+	writer := tar.NewWriter(&buffer)
+	for _, f := range files {
+		writer.WriteHeader(&tar.Header{Name: f.name, Mode: 0644, Size: int64(len(f.contents)), Typeflag: tar.TypeReg})
+		writer.Write([]byte(f.contents))
+	}
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func gzipped(data []byte) []byte {
+	var buffer bytes.Buffer
+	writer := gzip.NewWriter(&buffer)
+	writer.Write(data)
+	writer.Close()
+	return buffer.Bytes()
+}
+
+func digestOf(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func jsonOf(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func writeTarball(t *testing.T, data []byte) string {
+	tarballPath := filepath.Join(t.TempDir(), "image.tar")
+	assert.NoError(t, os.WriteFile(tarballPath, data, 0644))
+	return tarballPath
+}
+
+var history = []map[string]interface{}{
+	{"created_by": "/bin/sh -c #(nop) ADD file:4b03b5f551e3fbdf47ec609712007327828f7530cc3455c43bbcdcaf449a75a9 in / "},
+	{"created_by": "/bin/sh -c #(nop)  ENV NODE_ENV=production", "empty_layer": true},
+	{"created_by": "/bin/sh -c #(nop) COPY file:8c3b5f0e2f5d in /app/.npmrc "},
+	{"created_by": "RUN /bin/sh -c rm /app/.npmrc && touch /app/index.js # buildkit"},
+}
+
+var (
+	baseLayer    = tarOf(file{"./etc/os-release", "ID=alpine"})
+	npmrcLayer   = tarOf(file{"app/.npmrc", "//registry.npmjs.org/:_authToken=secret"})
+	removalLayer = tarOf(file{"app/.wh..npmrc", ""}, file{"app/index.js", "console.log('hello')"})
+)
+
+func dockerSaveTarball() []byte {
+	config := jsonOf(map[string]interface{}{
+		"rootfs":  map[string]interface{}{"diff_ids": []string{digestOf(baseLayer), digestOf(npmrcLayer), digestOf(removalLayer)}},
+		"history": history,
+	})
+	return tarOf(
+		file{"manifest.json", jsonOf([]map[string]interface{}{{
+			"Config":   "config.json",
+			"RepoTags": []string{"app:latest"},
+			"Layers":   []string{"base/layer.tar", "npmrc/layer.tar", "removal/layer.tar"},
+		}})},
+		file{"config.json", config},
+		file{"base/layer.tar", string(baseLayer)},
+		file{"npmrc/layer.tar", string(npmrcLayer)},
+		file{"removal/layer.tar", string(removalLayer)},
+	)
+}
+
+func ociLayoutTarball() ([]byte, []string) {
+	var blobs []file
+	blob := func(data []byte) map[string]interface{} {
+		digest := digestOf(data)
+		blobs = append(blobs, file{blobPath(digest), string(data)})
+		return map[string]interface{}{"digest": digest, "size": len(data)}
+	}
+	var layers []interface{}
+	var layerDigests []string
+	for _, layer := range [][]byte{baseLayer, npmrcLayer, removalLayer} {
+		descriptor := blob(gzipped(layer))
+		layers = append(layers, descriptor)
+		layerDigests = append(layerDigests, descriptor["digest"].(string))
+	}
+	manifest := blob([]byte(jsonOf(map[string]interface{}{
+		"config": blob([]byte(jsonOf(map[string]interface{}{"history": history}))),
+		"layers": layers,
+	})))
+	missingPlatform := map[string]interface{}{"digest": digestOf([]byte("arm64 manifest")), "size": 14}
+	index := blob([]byte(jsonOf(map[string]interface{}{"manifests": []interface{}{manifest, missingPlatform}})))
+	index["mediaType"] = "application/vnd.oci.image.index.v1+json"
+	files := append(blobs,
+		file{"oci-layout", `{"imageLayoutVersion": "1.0.0"}`},
+		file{"index.json", jsonOf(map[string]interface{}{"manifests": []interface{}{index}})})
+	return tarOf(files...), layerDigests
+}
+
+func streamedFiles(t *testing.T, image *Image, excludes *filesystem.Excludes) map[string]string {
+	files := map[string]string{}
+	additions, streamErr := image.StreamFiles(excludes)
+	for addition := range additions {
+		files[string(addition.Path)] = string(addition.Data)
+	}
+	assert.NoError(t, streamErr())
+	return files
+}
+
+func TestReadingADockerSaveTarball(t *testing.T) {
+	image, err := Open(writeTarball(t, gzipped(dockerSaveTarball())))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer image.Close()
+
+	layers := image.Layers()
+	if !assert.Len(t, layers, 3) {
+		return
+	}
+	assert.Equal(t, digestOf(npmrcLayer), layers[1].Digest, "Expected layer.tar files to be identified by their diff id")
+	assert.Equal(t, "ADD file:4b03b5f551e3fbdf47ec609712007327828f7530cc3455c43bbcdcaf449a75a9 in /", layers[0].Instruction)
+	assert.Equal(t, "COPY file:8c3b5f0e2f5d in /app/.npmrc", layers[1].Instruction, "Expected the history entry of ENV to be skipped")
+	assert.Equal(t, "RUN rm /app/.npmrc && touch /app/index.js", layers[2].Instruction)
+
+	label := func(layer []byte) string { return digestOf(layer)[len("sha256:"):][:12] }
+	assert.Equal(t, map[string]string{
+		label(baseLayer) + ":etc/os-release":  "ID=alpine",
+		label(npmrcLayer) + ":app/.npmrc":     "//registry.npmjs.org/:_authToken=secret",
+		label(removalLayer) + ":app/index.js": "console.log('hello')",
+	}, streamedFiles(t, image, nil), "Expected the file a later layer deletes to be read, and whiteouts to be left out")
+}
+
+func TestReadingAnOCIImageLayout(t *testing.T) {
+	tarball, layerDigests := ociLayoutTarball()
+	image, err := Open(writeTarball(t, tarball))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer image.Close()
+
+	layers := image.Layers()
+	if !assert.Len(t, layers, 3, "Expected the manifest of the platform the layout does not hold to be skipped") {
+		return
+	}
+	for n, layer := range layers {
+		assert.Equal(t, layerDigests[n], layer.Digest)
+	}
+	assert.Equal(t, "COPY file:8c3b5f0e2f5d in /app/.npmrc", layers[1].Instruction)
+
+	total, err := image.CountFiles(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	assert.Len(t, streamedFiles(t, image, nil), 3)
+}
+
+func TestExcludedFilesOfLayersAreNotRead(t *testing.T) {
+	image, err := Open(writeTarball(t, dockerSaveTarball()))
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer image.Close()
+	excludes := filesystem.NewExcludes(".", "/etc", "*.js")
+
+	total, err := image.CountFiles(excludes)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []int{0, 1, 0}, []int{image.Layers()[0].Files, image.Layers()[1].Files, image.Layers()[2].Files})
+	assert.Len(t, streamedFiles(t, image, excludes), 1)
+}
+
+func TestOpeningATarballThatIsNotAnImage(t *testing.T) {
+	_, err := Open(writeTarball(t, tarOf(file{"README.md", "not an image"})))
+
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "neither a docker save tarball nor an OCI image layout")
+	}
+}
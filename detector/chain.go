@@ -6,7 +6,11 @@ import (
 	"talisman/detector/filecontent"
 	"talisman/detector/filename"
 	"talisman/detector/helpers"
+	"talisman/detector/kubernetes"
+	"talisman/detector/metadata"
 	"talisman/detector/pattern"
+	"talisman/detector/privatekey"
+	"talisman/detector/terraform"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
 	"talisman/utility"
@@ -28,15 +32,39 @@ func NewChain(ignoreEvaluator helpers.IgnoreEvaluator) *Chain {
 	return &result
 }
 
-// DefaultChain returns a DetectorChain with pre-configured detectors
+// DefaultChain returns a DetectorChain with pre-configured detectors, leaving out each one disabled by its name
 func DefaultChain(tRC *talismanrc.TalismanRC, ignoreEvaluator helpers.IgnoreEvaluator) *Chain {
 	chain := NewChain(ignoreEvaluator)
-	chain.AddDetector(filename.DefaultFileNameDetector(tRC.Threshold))
-	chain.AddDetector(filecontent.NewFileContentDetector(tRC))
-	chain.AddDetector(pattern.NewPatternDetector(tRC.CustomPatterns))
+	if tRC.IsDetectorEnabled("filename") {
+		chain.AddDetector(filename.FileNameDetectorFor(tRC))
+	}
+	if tRC.IsDetectorEnabled("filecontent") {
+		chain.AddDetector(filecontent.NewFileContentDetector(tRC))
+	}
+	if tRC.IsDetectorEnabled("pattern") {
+		chain.AddDetector(pattern.NewPatternDetector(tRC.CustomPatterns))
+	}
+	if tRC.IsDetectorEnabled("kubernetes") {
+		chain.AddDetector(kubernetes.NewSecretDetector(tRC))
+	}
+	if tRC.IsDetectorEnabled("terraform") {
+		chain.AddDetector(terraform.NewStateDetector())
+	}
+	if tRC.IsDetectorEnabled("privatekey") {
+		chain.AddDetector(privatekey.NewKeyDetector())
+	}
 	return chain
 }
 
+// WithMessages adds a detector that tests the commit messages and tag annotations, unless the metadata detector is
+// disabled or there are no messages
+func (dc *Chain) WithMessages(tRC *talismanrc.TalismanRC, messages []gitrepo.Message) *Chain {
+	if len(messages) > 0 && tRC.IsDetectorEnabled("metadata") {
+		dc.AddDetector(metadata.NewMessageDetector(tRC, messages))
+	}
+	return dc
+}
+
 // AddDetector adds the detector that is passed in to the chain
 func (dc *Chain) AddDetector(d detector.Detector) *Chain {
 	dc.detectors = append(dc.detectors, d)
@@ -51,10 +79,47 @@ func (dc *Chain) Test(additions []gitrepo.Addition, talismanRC *talismanrc.Talis
 	total := len(additions) * len(dc.detectors)
 	progressBar := utility.GetProgressBar(os.Stdout, "Talisman Scan")
 	progressBar.Start(total)
-	for _, v := range dc.detectors {
-		v.Test(dc.ignoreEvaluator, additions, talismanRC, result, func() {
-			progressBar.Increment()
-		})
+	dc.testBatch(additions, talismanRC, result, progressBar.Increment)
+	progressBar.Finish()
+}
+
+// streamBatchSize is the number of streamed additions that are tested together.
+// It bounds both the number of additions held in memory and the number tested concurrently.
+const streamBatchSize = 64
+
+// TestStream validates additions as they are received, a batch at a time, so that only one batch of additions is held
+// in memory. Results are collected as each batch is tested, and the sender is held back while a batch is tested.
+// total is the number of additions that will be sent, used to report progress.
+// batchTested, if given, is called after each batch with the number of additions tested so far.
+func (dc *Chain) TestStream(additions <-chan gitrepo.Addition, total int, talismanRC *talismanrc.TalismanRC, result *helpers.DetectionResults, batchTested func(tested int)) {
+	log.Printf("Number of files to scan: %d\n", total)
+	log.Printf("Number of detectors: %d\n", len(dc.detectors))
+	progressBar := utility.GetProgressBar(os.Stdout, "Talisman Scan")
+	progressBar.Start(total * len(dc.detectors))
+	tested := 0
+	testBatch := func(batch []gitrepo.Addition) {
+		dc.testBatch(batch, talismanRC, result, progressBar.Increment)
+		tested += len(batch)
+		if batchTested != nil {
+			batchTested(tested)
+		}
+	}
+	batch := make([]gitrepo.Addition, 0, streamBatchSize)
+	for addition := range additions {
+		batch = append(batch, addition)
+		if len(batch) == streamBatchSize {
+			testBatch(batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		testBatch(batch)
 	}
 	progressBar.Finish()
 }
+
+func (dc *Chain) testBatch(additions []gitrepo.Addition, talismanRC *talismanrc.TalismanRC, result *helpers.DetectionResults, additionTested func()) {
+	for _, v := range dc.detectors {
+		v.Test(dc.ignoreEvaluator, additions, talismanRC, result, additionTested)
+	}
+}
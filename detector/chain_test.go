@@ -1,12 +1,21 @@
 package detector
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
 	"io/ioutil"
+	"talisman/detector/detector"
 	"talisman/detector/filecontent"
 	"talisman/detector/filename"
 	"talisman/detector/helpers"
+	"talisman/detector/kubernetes"
 	"talisman/detector/pattern"
+	"talisman/detector/privatekey"
 	"talisman/detector/severity"
+	"talisman/detector/terraform"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
 	"testing"
@@ -52,11 +61,11 @@ func TestValidationChainWithFailingValidationAlwaysFails(t *testing.T) {
 func TestDefaultChainShouldCreateChainSpecifiedModeAndPresetDetectors(t *testing.T) {
 	talismanRC := &talismanrc.TalismanRC{
 		Threshold:      severity.Medium,
-		CustomPatterns: []talismanrc.PatternString{"AKIA*"},
+		CustomPatterns: []talismanrc.CustomPattern{{Regex: "AKIA*"}},
 	}
 	ie := helpers.BuildIgnoreEvaluator("pre-push", talismanRC, gitrepo.RepoLocatedAt("."))
 	v := DefaultChain(talismanRC, ie)
-	assert.Equal(t, 3, len(v.detectors))
+	assert.Equal(t, 6, len(v.detectors))
 
 	defaultFileNameDetector := filename.DefaultFileNameDetector(talismanRC.Threshold)
 	assert.Equal(t, defaultFileNameDetector, v.detectors[0])
@@ -66,4 +75,96 @@ func TestDefaultChainShouldCreateChainSpecifiedModeAndPresetDetectors(t *testing
 
 	expectedPatternDetector := pattern.NewPatternDetector(talismanRC.CustomPatterns)
 	assert.Equal(t, expectedPatternDetector, v.detectors[2])
+
+	expectedSecretDetector := kubernetes.NewSecretDetector(talismanRC)
+	assert.Equal(t, expectedSecretDetector, v.detectors[3])
+	assert.Equal(t, terraform.NewStateDetector(), v.detectors[4])
+	assert.Equal(t, privatekey.NewKeyDetector(), v.detectors[5])
+}
+
+func TestDefaultChainShouldLeaveOutDisabledDetectors(t *testing.T) {
+	talismanRC := &talismanrc.TalismanRC{DisabledDetectors: []string{"filecontent", "kubernetes", "terraform", "privatekey"}}
+	ie := helpers.BuildIgnoreEvaluator("pre-push", talismanRC, gitrepo.RepoLocatedAt("."))
+	v := DefaultChain(talismanRC, ie)
+
+	assert.Equal(t, 2, len(v.detectors), "Expected only the filename and pattern detectors to be added")
+	assert.Equal(t, filename.DefaultFileNameDetector(talismanRC.Threshold), v.detectors[0])
+	assert.Equal(t, pattern.NewPatternDetector(talismanRC.CustomPatterns), v.detectors[1])
+}
+
+func TestDefaultChainShouldKeepTheOtherContentDetectorsWhenFileContentIsDisabled(t *testing.T) {
+	talismanRC := &talismanrc.TalismanRC{DisabledDetectors: []string{"filecontent", "pattern"}}
+	ie := helpers.BuildIgnoreEvaluator("pre-push", talismanRC, gitrepo.RepoLocatedAt("."))
+	v := DefaultChain(talismanRC, ie)
+
+	assert.Equal(t, []detector.Detector{
+		filename.DefaultFileNameDetector(talismanRC.Threshold),
+		kubernetes.NewSecretDetector(talismanRC),
+		terraform.NewStateDetector(),
+		privatekey.NewKeyDetector(),
+	}, v.detectors)
+}
+
+// batchRecordingDetection fails every addition it is given and remembers the size of each batch
+type batchRecordingDetection struct {
+	batchSizes *[]int
+}
+
+func (b batchRecordingDetection) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	*b.batchSizes = append(*b.batchSizes, len(currentAdditions))
+	for _, addition := range currentAdditions {
+		result.Fail(addition.Path, "filecontent", "FAILED BY DESIGN", addition.Commits, severity.Low)
+		additionCompletionCallback()
+	}
+}
+
+func TestValidationChainTestsStreamedAdditionsInBoundedBatches(t *testing.T) {
+	ie := helpers.BuildIgnoreEvaluator("pre-push", nil, gitrepo.RepoLocatedAt("."))
+	var batchSizes []int
+	v := NewChain(ie)
+	v.AddDetector(batchRecordingDetection{&batchSizes})
+	total := streamBatchSize*2 + 1
+	additions := make(chan gitrepo.Addition)
+	go func() {
+		for i := 0; i < total; i++ {
+			additions <- gitrepo.NewScannerAddition(fmt.Sprintf("file-%d", i), []string{"commit"}, nil)
+		}
+		close(additions)
+	}()
+	results := helpers.NewDetectionResults()
+
+	var testedAfterEachBatch []int
+
+	v.TestStream(additions, total, &talismanrc.TalismanRC{}, results, func(tested int) {
+		testedAfterEachBatch = append(testedAfterEachBatch, tested)
+	})
+
+	assert.Equal(t, []int{streamBatchSize, streamBatchSize, 1}, batchSizes)
+	assert.Equal(t, []int{streamBatchSize, streamBatchSize * 2, total}, testedAfterEachBatch)
+	assert.Len(t, results.Results, total, "Expected results of every batch to be collected")
+}
+
+func TestDefaultChainOnlyWarnsAboutEncryptedPrivateKeysAboveAMediumThreshold(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 1024)
+	if !assert.NoError(t, err) {
+		return
+	}
+	//lint:ignore SA1019 keys encrypted the legacy way are still generated by openssl genrsa -traditional
+	block, err := x509.EncryptPEMBlock(rand.Reader, "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(key), []byte("passphrase"), x509.PEMCipherAES128)
+	if !assert.NoError(t, err) {
+		return
+	}
+	additions := []gitrepo.Addition{gitrepo.NewAddition("deploy.txt", pem.EncodeToMemory(block))}
+
+	for threshold, shouldFail := range map[severity.Severity]bool{severity.Medium: true, severity.High: false} {
+		tRC := &talismanrc.TalismanRC{Threshold: threshold}
+		results := helpers.NewDetectionResults()
+		DefaultChain(tRC, helpers.BuildIgnoreEvaluator("default", tRC, gitrepo.RepoLocatedAt("."))).Test(additions, tRC, results)
+
+		assert.Equal(t, shouldFail, results.HasFailures(), "threshold %v", threshold)
+		assert.True(t, results.HasFailures() || results.HasWarnings(), "threshold %v", threshold)
+		for _, failure := range results.GetFailures("deploy.txt") {
+			assert.Equal(t, "File holds an encrypted PKCS#1 RSA private key of unknown size", failure.Message)
+		}
+	}
 }
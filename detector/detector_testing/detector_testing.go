@@ -0,0 +1,26 @@
+package detector_testing
+
+import (
+	"talisman/detector/detector"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+)
+
+// ResultsOf returns the results of testing a single file with the detector, applying the ignores of tRC
+func ResultsOf(d detector.Detector, fileName string, content []byte, tRC *talismanrc.TalismanRC) *helpers.DetectionResults {
+	results := helpers.NewDetectionResults()
+	additions := []gitrepo.Addition{gitrepo.NewAddition(fileName, content)}
+	ie := helpers.BuildIgnoreEvaluator("default", tRC, gitrepo.RepoLocatedAt("."))
+	d.Test(ie, additions, tRC, results, func() {})
+	return results
+}
+
+// FailureMessagesOf returns the messages of the failures reported for a file, in the order they were reported
+func FailureMessagesOf(results *helpers.DetectionResults, fileName string) []string {
+	var messages []string
+	for _, failure := range results.GetFailures(gitrepo.FilePath(fileName)) {
+		messages = append(messages, failure.Message)
+	}
+	return messages
+}
@@ -0,0 +1,124 @@
+package encrypted
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	gitCryptMagic = "\x00GITCRYPT\x00"
+	ageMagic      = "age-encryption.org/v1\n"
+)
+
+var (
+	ansibleVault = regexp.MustCompile(`[ \t]*\$ANSIBLE_VAULT;[^\n]*\n(?:[ \t]*[0-9a-fA-F]+[ \t]*(?:\n|$))+`)
+	ageArmor     = regexp.MustCompile(`(?s)-----BEGIN AGE ENCRYPTED FILE-----.*?-----END AGE ENCRYPTED FILE-----`)
+	pgpMessage   = regexp.MustCompile(`(?s)-----BEGIN PGP MESSAGE-----.*?-----END PGP MESSAGE-----`)
+	// the starts of the metadata SOPS adds to the YAML, JSON and INI files it encrypts, the dotenv files it encrypts
+	// holding a line of metadata for each field, and the ends of the YAML and INI blocks
+	sopsYAMLBlock    = regexp.MustCompile(`(?m)^sops:[ \t]*$`)
+	sopsJSONBlock    = regexp.MustCompile(`"sops"\s*:\s*\{`)
+	sopsINIBlock     = regexp.MustCompile(`(?m)^\[sops\][ \t]*$`)
+	sopsDotenvMAC    = regexp.MustCompile(`(?m)^sops_mac=`)
+	sopsDotenvLine   = regexp.MustCompile(`(?m)^sops_\w*=.*$`)
+	yamlTopLevelLine = regexp.MustCompile(`(?m)^\S`)
+	iniSection       = regexp.MustCompile(`(?m)^\[`)
+	sopsValue        = regexp.MustCompile(`ENC\[[A-Z0-9_]+,data:[^\]]*\]`)
+	// sopsKeyField matches the fields of the metadata holding the data key encrypted for each recipient, and the
+	// fingerprints and public keys of the recipients, which are safe to commit
+	sopsKeyField = regexp.MustCompile(`(?m)((?:\b|_)(?:enc|fp|recipient)["']?\s*[:=]\s*)["']?[A-Za-z0-9+/=_-]+["']?`)
+)
+
+// RemoveCiphertext removes what was encrypted at rest with SOPS, ansible-vault, git-crypt or age from content, so that
+// ciphertext is not reported as encoded text. Files encrypted by git-crypt, and binary age files, are encrypted as a
+// whole. Ansible vaults and age armor are removed wherever they are, such as in the !vault values of YAML files.
+// Only the encrypted values of SOPS files are removed, along with the encrypted data keys in their metadata, so that
+// values left unencrypted, such as those of keys with the unencrypted suffix, are still checked whatever their name.
+func RemoveCiphertext(content string) string {
+	if strings.HasPrefix(content, gitCryptMagic) || strings.HasPrefix(content, ageMagic) {
+		log.Debug("Not checking file content as it is encrypted by git-crypt or age")
+		return ""
+	}
+	if strings.Contains(content, "$ANSIBLE_VAULT;") {
+		content = ansibleVault.ReplaceAllString(content, "")
+	}
+	if strings.Contains(content, "-----BEGIN AGE ENCRYPTED FILE-----") {
+		content = ageArmor.ReplaceAllString(content, "")
+	}
+	if blocks := sopsMetadataBlocks(content); len(blocks) > 0 {
+		content = removeSOPSKeys(content, blocks)
+		content = sopsValue.ReplaceAllString(content, "")
+	}
+	return content
+}
+
+// sopsMetadataBlocks returns the start and end of each block of SOPS metadata in content, in the order they appear
+func sopsMetadataBlocks(content string) [][]int {
+	var blocks [][]int
+	for _, start := range sopsYAMLBlock.FindAllStringIndex(content, -1) {
+		blocks = append(blocks, []int{start[0], blockEnd(content, start[1], yamlTopLevelLine)})
+	}
+	for _, start := range sopsINIBlock.FindAllStringIndex(content, -1) {
+		blocks = append(blocks, []int{start[0], blockEnd(content, start[1], iniSection)})
+	}
+	for _, start := range sopsJSONBlock.FindAllStringIndex(content, -1) {
+		blocks = append(blocks, []int{start[0], jsonObjectEnd(content, start[1]-1)})
+	}
+	if sopsDotenvMAC.MatchString(content) {
+		blocks = append(blocks, sopsDotenvLine.FindAllStringIndex(content, -1)...)
+	}
+	sort.Slice(blocks, func(i, j int) bool { return blocks[i][0] < blocks[j][0] })
+	return blocks
+}
+
+// blockEnd returns where the block whose first line ends at from ends, that is at the next line matching next
+func blockEnd(content string, from int, next *regexp.Regexp) int {
+	if found := next.FindStringIndex(content[from:]); found != nil {
+		return from + found[0]
+	}
+	return len(content)
+}
+
+// jsonObjectEnd returns the end of the JSON object opened at start, skipping the braces in its strings
+func jsonObjectEnd(content string, start int) int {
+	depth := 0
+	inString := false
+	for i := start; i < len(content); i++ {
+		switch c := content[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+	return len(content)
+}
+
+// removeSOPSKeys removes the encrypted data keys, and the recipients they are encrypted for, from the blocks of
+// metadata of content, leaving the fields of the same names elsewhere to be checked
+func removeSOPSKeys(content string, blocks [][]int) string {
+	var result strings.Builder
+	end := 0
+	for _, block := range blocks {
+		if block[0] < end {
+			continue
+		}
+		result.WriteString(content[end:block[0]])
+		metadata := pgpMessage.ReplaceAllString(content[block[0]:block[1]], "")
+		result.WriteString(sopsKeyField.ReplaceAllString(metadata, "$1"))
+		end = block[1]
+	}
+	result.WriteString(content[end:])
+	return result.String()
+}
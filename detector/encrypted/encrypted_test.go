@@ -0,0 +1,103 @@
+package encrypted
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const sopsYAML = `database:
+    password: ENC[AES256_GCM,data:p673w5GD5xmhI2a8,iv:Jn4SkOPcQPwJOLwaxt0gEFhTwmq3TwC8qS8qlp3IUA8=,tag:mXuFHbEOuWGc7ezrECOXvA==,type:str]
+    host_unencrypted: db.internal
+    token_unencrypted: ghp_leakedBecauseOfTheSuffix
+sops:
+    kms:
+        - arn: arn:aws:kms:eu-west-1:123456789012:key/0e4d7c1c-5b2b-4f5a-a5f3-7d0c6e5b6f2a
+          created_at: "2024-01-01T00:00:00Z"
+          enc: AQICAHhgd4hOYFLE2h4iKXpVrm0fUCRbxv5vJCl+S5HxEL0GJwGXGqa0x1mFrCPAPy0sHqZOAAAAfjB8BgkqhkiG9w0BBwagbzBtAgEAMGgGCSqGSIb3DQEHATAeBglghkgBZQMEAS4wEQQM
+          aws_profile: ""
+    age:
+        - recipient: age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p
+          enc: |
+            -----BEGIN AGE ENCRYPTED FILE-----
+            YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBWc2dXbXN1WkVMMXhxRmxh
+            -----END AGE ENCRYPTED FILE-----
+    pgp:
+        - created_at: "2024-01-01T00:00:00Z"
+          enc: |
+            -----BEGIN PGP MESSAGE-----
+
+            hQIMA0t4uZHfl9qgAQ//UvGAwGePyHuf2/zayWcloGaDs0MzI+zw6CmXvMRNPUsA
+            -----END PGP MESSAGE-----
+          fp: 85D77543B3D624B63CEA9E6DBC17301B491B3F21
+    lastmodified: "2024-01-01T00:00:00Z"
+    mac: ENC[AES256_GCM,data:Zm9vYmFy,iv:aXY=,tag:dGFn,type:str]
+    unencrypted_suffix: _unencrypted
+    version: 3.8.1
+`
+
+func TestRemovingTheEncryptedValuesOfSOPSFiles(t *testing.T) {
+	remaining := RemoveCiphertext(sopsYAML)
+
+	for _, ciphertext := range []string{"ENC[", "AQICAHhgd4hOYFLE2h4iKXpVrm0f", "YWdlLWVuY3J5cHRpb24", "hQIMA0t4uZHfl9qg",
+		"85D77543B3D624B63CEA9E6DBC17301B491B3F21", "age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"} {
+		assert.NotContains(t, remaining, ciphertext)
+	}
+	assert.Contains(t, remaining, "token_unencrypted: ghp_leakedBecauseOfTheSuffix", "Expected unencrypted values to be kept")
+	assert.Contains(t, remaining, "arn: arn:aws:kms:eu-west-1:123456789012:key/0e4d7c1c-5b2b-4f5a-a5f3-7d0c6e5b6f2a")
+}
+
+func TestRemovingTheEncryptedValuesOfSOPSDotenvAndJSONFiles(t *testing.T) {
+	dotenv := "API_KEY=ENC[AES256_GCM,data:cGFzc3dvcmQ=,iv:aXY=,tag:dGFn,type:str]\n" +
+		"sops_kms__list_0__map_enc=AQICAHhgd4hOYFLE2h4iKXpVrm0fUCRbxv5vJCl\n" +
+		"sops_mac=ENC[AES256_GCM,data:Zm9vYmFy,iv:aXY=,tag:dGFn,type:str]\n" +
+		"sops_version=3.8.1\n"
+	json := `{"password": "ENC[AES256_GCM,data:cGFzc3dvcmQ=,iv:aXY=,tag:dGFn,type:str]",
+	"sops": {"kms": [{"enc": "AQICAHhgd4hOYFLE2h4iKXpVrm0fUCRbxv5vJCl", "arn": "arn:aws:kms:key"}], "version": "3.8.1"}}`
+
+	assert.Equal(t, "API_KEY=\nsops_kms__list_0__map_enc=\nsops_mac=\nsops_version=3.8.1\n", RemoveCiphertext(dotenv))
+	assert.Equal(t, `{"password": "",
+	"sops": {"kms": [{"enc": , "arn": "arn:aws:kms:key"}], "version": "3.8.1"}}`, RemoveCiphertext(json))
+}
+
+func TestFieldsNamedLikeSOPSKeysAreOnlyRemovedFromTheMetadata(t *testing.T) {
+	yaml := "database:\n    password_enc: hunter2\n    fp: 85D77543B3D624B63CEA9E6DBC17301B491B3F21\n" + sopsYAML[len("database:\n"):]
+	json := `{"password_enc": "hunter2", "sops": {"kms": [{"enc": "AQICAHhgd4hOYFLE2h4iKXpVrm0fUCRbxv5vJCl", "arn": "arn:aws:kms:key"}],
+	"note": "a } in a string"}, "recipient": "ops@example.com"}`
+	ini := "[database]\npassword_enc = hunter2\n[sops]\nkms__list_0__map_enc = AQICAHhgd4hOYFLE2h4iKXpVrm0fUCRbxv5vJCl\nversion = 3.8.1\n"
+
+	remainingYAML := RemoveCiphertext(yaml)
+	assert.Contains(t, remainingYAML, "database:\n    password_enc: hunter2\n    fp: 85D77543B3D624B63CEA9E6DBC17301B491B3F21\n")
+	assert.NotContains(t, remainingYAML, "AQICAHhgd4hOYFLE2h4iKXpVrm0f")
+	assert.Equal(t, `{"password_enc": "hunter2", "sops": {"kms": [{"enc": , "arn": "arn:aws:kms:key"}],
+	"note": "a } in a string"}, "recipient": "ops@example.com"}`, RemoveCiphertext(json))
+	assert.Equal(t, "[database]\npassword_enc = hunter2\n[sops]\nkms__list_0__map_enc = \nversion = 3.8.1\n", RemoveCiphertext(ini))
+}
+
+func TestEncryptedValuesAreKeptInFilesWithoutSOPSMetadata(t *testing.T) {
+	content := "enc: AQICAHhgd4hOYFLE2h4iKXpVrm0fUCRbxv5vJCl\n"
+
+	assert.Equal(t, content, RemoveCiphertext(content))
+}
+
+func TestRemovingAnsibleVaults(t *testing.T) {
+	vault := "$ANSIBLE_VAULT;1.1;AES256\n" +
+		"62313365396662343061393464336163383764373764613633653634306231386433626436623361\n" +
+		"6134333665353966363534333632666535333761666131620a663537646436643839616531643561\n"
+	inline := "user: deploy\n" +
+		"password: !vault |\n" +
+		"          $ANSIBLE_VAULT;1.2;AES256;production\n" +
+		"          62313365396662343061393464336163383764373764613633653634306231386433626436623361\n" +
+		"          6134333665353966363534333632666535333761666131620a663537646436643839616531643561\n" +
+		"api_token: plaintext\n"
+
+	assert.Equal(t, "", RemoveCiphertext(vault))
+	assert.Equal(t, "user: deploy\npassword: !vault |\napi_token: plaintext\n", RemoveCiphertext(inline))
+}
+
+func TestRemovingFilesEncryptedByGitCryptOrAge(t *testing.T) {
+	assert.Equal(t, "", RemoveCiphertext("\x00GITCRYPT\x00\x8a\x12\xffciphertext"))
+	assert.Equal(t, "", RemoveCiphertext("age-encryption.org/v1\n-> X25519 SVrzdFfkPxf0LPHOUGB1gNb9E5Vr8EUDa9kxRnXDDi8\n"))
+	assert.Equal(t, "before\n\nafter", RemoveCiphertext("before\n-----BEGIN AGE ENCRYPTED FILE-----\n"+
+		"YWdlLWVuY3J5cHRpb24ub3JnL3YxCi0+IFgyNTUxOSBWc2dXbXN1WkVMMXhxRmxh\n-----END AGE ENCRYPTED FILE-----\nafter"))
+}
@@ -8,7 +8,7 @@ import (
 )
 
 const BASE64_CHARS = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789+/="
-const BASE64_ENTROPY_THRESHOLD = 4.5
+const BASE64_ENTROPY_THRESHOLD = talismanrc.DefaultBase64EntropyThreshold
 const MIN_BASE64_SECRET_LENGTH = 20
 
 type Base64Detector struct {
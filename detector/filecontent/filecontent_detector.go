@@ -5,7 +5,9 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"talisman/detector/encrypted"
 	"talisman/detector/helpers"
+	"talisman/detector/privatekey"
 	"talisman/detector/severity"
 	"talisman/gitrepo"
 	"talisman/talismanrc"
@@ -71,6 +73,7 @@ func (ct contentType) getMessageFormat() string {
 type content struct {
 	name        gitrepo.FileName
 	path        gitrepo.FilePath
+	commits     []string
 	contentType contentType
 	results     []string
 	severity    severity.Severity
@@ -119,11 +122,12 @@ func (fc *FileContentDetector) Test(comparator helpers.IgnoreEvaluator, currentA
 				data := []byte(content)
 				addition.Data = data
 			}
-			addition.Data = []byte(talismanRC.RemoveAllowedPatterns(addition))
+			addition.Data = []byte(privatekey.RemoveKeys(encrypted.RemoveCiphertext(talismanRC.RemoveAllowedPatterns(addition))))
 			for _, ct := range contentTypes {
 				contents <- content{
 					name:        addition.Name,
 					path:        addition.Path,
+					commits:     addition.Commits,
 					contentType: ct.contentType,
 					results:     fc.detectFile(addition.Data, ct.fn),
 					severity:    ct.severity,
@@ -170,9 +174,9 @@ func processContent(c content, threshold severity.Severity, result *helpers.Dete
 				"filePath": c.path,
 			}).Info(c.contentType.getInfo())
 			if string(c.name) == talismanrc.RCFileName || !c.severity.ExceedsThreshold(threshold) {
-				result.Warn(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), formatForReporting(res)), []string{}, c.severity)
+				result.Warn(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), formatForReporting(res)), append([]string{}, c.commits...), c.severity)
 			} else {
-				result.Fail(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), formatForReporting(res)), []string{}, c.severity)
+				result.Fail(c.path, "filecontent", fmt.Sprintf(c.contentType.getMessageFormat(), formatForReporting(res)), append([]string{}, c.commits...), c.severity)
 			}
 		}
 	}
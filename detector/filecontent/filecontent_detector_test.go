@@ -274,3 +274,28 @@ func getFailureMessages(results *helpers.DetectionResults, filePath gitrepo.File
 	}
 	return failureMessages
 }
+
+func TestShouldNotFlagCiphertextOfFilesEncryptedAtRest(t *testing.T) {
+	vault := "$ANSIBLE_VAULT;1.1;AES256\n" +
+		"62313365396662343061393464336163383764373764613633653634306231386433626436623361\n" +
+		"6134333665353966363534333632666535333761666131620a663537646436643839616531643561\n"
+	sops := "password: ENC[AES256_GCM,data:p673w5GD5xmhI2a8,iv:Jn4SkOPcQPwJOLwaxt0gEFhTwmq3TwC8qS8qlp3IUA8=,tag:mXuFHbEOuWGc7ezrECOXvA==,type:str]\n" +
+		"token_unencrypted: %s\n" +
+		"sops:\n" +
+		"    mac: ENC[AES256_GCM,data:Zm9vYmFyYmF6,iv:Jn4SkOPcQPwJOLwaxt0gEFhTwmq3TwC8qS8qlp3IUA8=,tag:dGFn,type:str]\n"
+
+	for name, content := range map[string]string{"vault.yml": vault, "secrets.enc.yaml": fmt.Sprintf(sops, "db.internal")} {
+		results := helpers.NewDetectionResults()
+		additions := []gitrepo.Addition{gitrepo.NewAddition(name, []byte(content))}
+		NewFileContentDetector(emptyTalismanRC).
+			Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, results, dummyCallback)
+		assert.False(t, results.HasFailures(), "Expected the ciphertext of %s not to be reported", name)
+	}
+
+	results := helpers.NewDetectionResults()
+	leaked := fmt.Sprintf(sops, "QWJjZGVmZ2hpamtsbW5vcHFyc3R1dnd4eXo0NTY3ODkwMTIz")
+	additions := []gitrepo.Addition{gitrepo.NewAddition("secrets.enc.yaml", []byte(leaked))}
+	NewFileContentDetector(emptyTalismanRC).
+		Test(defaultIgnoreEvaluator, additions, emptyTalismanRC, results, dummyCallback)
+	assert.True(t, results.HasFailures(), "Expected the value SOPS left unencrypted to be checked")
+}
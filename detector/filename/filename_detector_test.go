@@ -4,10 +4,17 @@ package filename
 //https://github.com/jandre/safe-commit-hook
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"math/big"
 	"regexp"
 	"talisman/detector/helpers"
 	"talisman/detector/severity"
 	"testing"
+	"time"
 
 	"talisman/gitrepo"
 	"talisman/talismanrc"
@@ -46,6 +53,25 @@ func TestShouldFlagPotentialPrivateKeys(t *testing.T) {
 	shouldFail("foo.keypair", "^.+\\.key(pair)?$", severity.Low, t)
 }
 
+func TestShouldNotFlagKeyFilesHoldingOnlyCertificates(t *testing.T) {
+	signer, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{SerialNumber: big.NewInt(1), NotBefore: time.Now(), NotAfter: time.Now().Add(time.Hour)}
+	der, _ := x509.CreateCertificate(rand.Reader, template, template, &signer.PublicKey, signer)
+	sec1, _ := x509.MarshalECPrivateKey(signer)
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	key := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1})
+	additions := []gitrepo.Addition{
+		gitrepo.NewAddition("ca.pem", certificate),
+		gitrepo.NewAddition("server.key", append(certificate, key...)),
+	}
+	results := helpers.NewDetectionResults()
+
+	DefaultFileNameDetector(severity.Low).Test(defaultIgnoreEvaluator, additions, talismanRC, results, func() {})
+
+	assert.Empty(t, results.GetFailures("ca.pem"), "Expected a certificate not to fail")
+	assert.NotEmpty(t, results.GetFailures("server.key"), "Expected a private key to still fail")
+}
+
 func TestShouldFlagPotentialKeyBundles(t *testing.T) {
 	shouldFail("foo.pkcs12", "^.+\\.pkcs12$", severity.Low, t)
 	shouldFail("foo.pfx", "^.+\\.pfx$", severity.Low, t)
@@ -216,3 +242,54 @@ func additionsNamed(names ...string) []gitrepo.Addition {
 	}
 	return result
 }
+
+func TestShouldNotFlagFilesMatchingDisabledPatterns(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{DisabledFilenamePatterns: []string{"EnvFile"}}
+	results := helpers.NewDetectionResults()
+
+	FileNameDetectorFor(tRC).
+		Test(defaultIgnoreEvaluator, additionsNamed("environment.ts", "id_rsa"), tRC, results, func() {})
+
+	assert.Empty(t, results.GetFailures("environment.ts"), "Expected environment.ts not to fail as the EnvFile pattern is disabled")
+	assert.NotEmpty(t, results.GetFailures("id_rsa"), "Expected id_rsa to still fail")
+}
+
+func TestEachPatternCanBeDisabledByItsIDInTalismanRC(t *testing.T) {
+	for _, pattern := range filenamePatterns {
+		errors := talismanrc.ValidateContents(talismanrc.RCFileName, []byte("disabled_filename_patterns: ["+pattern.ID+"]"))
+		assert.Empty(t, errors, pattern.ID)
+	}
+}
+
+func TestShouldOnlyNotFlagTheKeyFilesOfADisabledPattern(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{DisabledFilenamePatterns: []string{"Ed25519File"}}
+	results := helpers.NewDetectionResults()
+
+	FileNameDetectorFor(tRC).
+		Test(defaultIgnoreEvaluator, additionsNamed("id_ed25519", "id_ecdsa", "id_dsa"), tRC, results, func() {})
+
+	assert.Empty(t, results.GetFailures("id_ed25519"))
+	assert.NotEmpty(t, results.GetFailures("id_ecdsa"))
+	assert.NotEmpty(t, results.GetFailures("id_dsa"))
+}
+
+func TestShouldFlagFilesMatchingCustomPatterns(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		Threshold: severity.Medium,
+		CustomFilenamePatterns: []talismanrc.CustomFilenamePattern{
+			{ID: "TFVars", Description: "Terraform variables", Regex: `^.*\.tfvars$`},
+			{Regex: `^generated_.*$`, Severity: severity.Low},
+		},
+	}
+	results := helpers.NewDetectionResults()
+
+	FileNameDetectorFor(tRC).
+		Test(defaultIgnoreEvaluator, additionsNamed("prod.tfvars", "generated_config.ts"), tRC, results, func() {})
+
+	failures := results.GetFailures("prod.tfvars")
+	assert.Len(t, failures, 1)
+	assert.Equal(t, `The file name "prod.tfvars" failed checks against the pattern ^.*\.tfvars$ (Terraform variables)`, failures[0].Message)
+	assert.Equal(t, severity.High, failures[0].Severity)
+	assert.Empty(t, results.GetFailures("generated_config.ts"), "Expected low severity custom pattern to only warn")
+	assert.True(t, results.HasWarnings())
+}
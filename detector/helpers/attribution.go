@@ -0,0 +1,107 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"talisman/gitrepo"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+const (
+	findingKindFailure = "failure"
+	findingKindWarning = "warning"
+)
+
+// AuthorFindings are the failures and warnings introduced by one author
+type AuthorFindings struct {
+	Author   string              `json:"author"`
+	Email    string              `json:"email"`
+	Findings []AttributedFinding `json:"findings"`
+}
+
+// AttributedFinding is a failure or warning along with the file it was found in
+type AttributedFinding struct {
+	Filename gitrepo.FilePath `json:"filename"`
+	Kind     string           `json:"kind"`
+	Details
+}
+
+// Attribute sets the attribution of every failure and warning to the one attribute returns for it, if any
+func (r *DetectionResults) Attribute(attribute func(filePath gitrepo.FilePath, details Details) *gitrepo.Attribution) {
+	for i := range r.Results {
+		result := &r.Results[i]
+		for j := range result.FailureList {
+			result.FailureList[j].Attribution = attribute(result.Filename, result.FailureList[j])
+		}
+		for j := range result.WarningList {
+			result.WarningList[j].Attribution = attribute(result.Filename, result.WarningList[j])
+		}
+	}
+}
+
+// ByAuthor groups the failures and warnings that have an attribution by the email of their author
+func (r *DetectionResults) ByAuthor() []AuthorFindings {
+	byEmail := map[string]*AuthorFindings{}
+	var emails []string
+	add := func(filePath gitrepo.FilePath, kind string, details Details) {
+		if details.Attribution == nil {
+			return
+		}
+		author, ok := byEmail[details.Attribution.Email]
+		if !ok {
+			author = &AuthorFindings{Author: details.Attribution.Author, Email: details.Attribution.Email}
+			byEmail[details.Attribution.Email] = author
+			emails = append(emails, details.Attribution.Email)
+		}
+		author.Findings = append(author.Findings, AttributedFinding{Filename: filePath, Kind: kind, Details: details})
+	}
+	for _, result := range r.Results {
+		for _, failure := range result.FailureList {
+			add(result.Filename, findingKindFailure, failure)
+		}
+		for _, warning := range result.WarningList {
+			add(result.Filename, findingKindWarning, warning)
+		}
+	}
+	sort.Strings(emails)
+	authors := make([]AuthorFindings, 0, len(emails))
+	for _, email := range emails {
+		authors = append(authors, *byEmail[email])
+	}
+	return authors
+}
+
+// ReportByAuthor prints a table of the attributed failures and warnings, grouped by their author
+func (r *DetectionResults) ReportByAuthor() {
+	authors := r.ByAuthor()
+	if len(authors) == 0 {
+		return
+	}
+	var data [][]string
+	for _, author := range authors {
+		for _, finding := range author.Findings {
+			commit := finding.Attribution.Commit
+			if len(commit) > 8 {
+				commit = commit[:8]
+			}
+			data = append(data, []string{
+				fmt.Sprintf("%s <%s>", author.Author, author.Email),
+				string(finding.Filename),
+				fmt.Sprintf("%s %s", commit, finding.Attribution.Date),
+				strings.Join(finding.Attribution.Branches, "\n"),
+				finding.Severity.String(),
+			})
+		}
+	}
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Author", "File", "Introduced in", "Branches", "Severity"})
+	table.SetRowLine(true)
+	table.SetAutoMergeCells(true)
+	fmt.Printf("\n\x1b[1m\x1b[31mFindings by author:\x1b[0m\x1b[0m\n")
+	table.AppendBulk(data)
+	table.Render()
+	fmt.Println()
+}
@@ -0,0 +1,51 @@
+package helpers
+
+import (
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttributeSetsTheAttributionOfFailuresAndWarnings(t *testing.T) {
+	results := NewDetectionResults()
+	results.Fail("a.txt", "filecontent", "secret", []string{"c1"}, severity.High)
+	results.Warn("a.txt", "filesize", "large", []string{"c2"}, severity.Low)
+	results.Ignore("b.txt", "filecontent")
+
+	results.Attribute(func(filePath gitrepo.FilePath, details Details) *gitrepo.Attribution {
+		return &gitrepo.Attribution{Commit: details.Commits[0], Email: string(filePath)}
+	})
+
+	assert.Equal(t, &gitrepo.Attribution{Commit: "c1", Email: "a.txt"}, results.GetFailures("a.txt")[0].Attribution)
+	assert.Equal(t, &gitrepo.Attribution{Commit: "c2", Email: "a.txt"}, results.Results[0].WarningList[0].Attribution)
+}
+
+func TestByAuthorGroupsAttributedFindingsByEmail(t *testing.T) {
+	results := NewDetectionResults()
+	results.Fail("a.txt", "filecontent", "secret", []string{"c1"}, severity.High)
+	results.Fail("b.txt", "filecontent", "secret", []string{"c2"}, severity.High)
+	results.Warn("c.txt", "filecontent", "maybe a secret", []string{"c3"}, severity.Low)
+	results.Fail("d.txt", "filename", "unattributed", nil, severity.High)
+	authors := map[string]gitrepo.Attribution{
+		"c1": {Commit: "c1", Author: "Bob", Email: "bob@example.com"},
+		"c2": {Commit: "c2", Author: "Alice", Email: "alice@example.com"},
+		"c3": {Commit: "c3", Author: "Bob", Email: "bob@example.com"},
+	}
+	results.Attribute(func(_ gitrepo.FilePath, details Details) *gitrepo.Attribution {
+		if len(details.Commits) == 0 {
+			return nil
+		}
+		attribution := authors[details.Commits[0]]
+		return &attribution
+	})
+
+	byAuthor := results.ByAuthor()
+
+	assert.Len(t, byAuthor, 2)
+	assert.Equal(t, "alice@example.com", byAuthor[0].Email)
+	assert.Equal(t, gitrepo.FilePath("b.txt"), byAuthor[0].Findings[0].Filename)
+	assert.Equal(t, "Bob", byAuthor[1].Author)
+	assert.Equal(t, []string{findingKindFailure, findingKindWarning}, []string{byAuthor[1].Findings[0].Kind, byAuthor[1].Findings[1].Kind})
+}
@@ -0,0 +1,24 @@
+package helpers
+
+// Coverage records how much of the history a scan was able to read. Shallow clones stop at some commits, and partial
+// clones leave out objects that are only fetched when they are read, which a scan does not do.
+type Coverage struct {
+	// Complete is true when nothing selected for the scan was missing from the repository
+	Complete bool `json:"complete"`
+	// Commits is the number of commits the scan walked
+	Commits int `json:"commits"`
+	// ScannedBlobs is the number of file versions the scan read
+	ScannedBlobs int `json:"scanned_blobs"`
+	// ShallowBoundary lists the commits of a shallow clone whose parents are missing, so that history before them
+	// was not scanned
+	ShallowBoundary []string `json:"shallow_boundary,omitempty"`
+	// SkippedFiles lists the file versions a partial clone left out, which were not scanned
+	SkippedFiles []SkippedFile `json:"skipped_files,omitempty"`
+}
+
+// SkippedFile is a version of a file that could not be scanned
+type SkippedFile struct {
+	Path    string   `json:"path"`
+	Hash    string   `json:"hash"`
+	Commits []string `json:"commits"`
+}
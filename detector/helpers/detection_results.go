@@ -5,6 +5,7 @@ import (
 	"os"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"talisman/detector/severity"
 	"talisman/gitrepo"
@@ -24,6 +25,8 @@ type Details struct {
 	Message  string            `json:"message"`
 	Commits  []string          `json:"commits"`
 	Severity severity.Severity `json:"severity,omitempty"`
+	// Attribution is the commit that introduced the finding and its author, when known
+	Attribution *gitrepo.Attribution `json:"attribution,omitempty"`
 }
 
 type ResultsDetails struct {
@@ -52,6 +55,12 @@ type ResultsSummary struct {
 type DetectionResults struct {
 	Summary ResultsSummary   `json:"summary"`
 	Results []ResultsDetails `json:"results"`
+	// Coverage is how much of the history a scan was able to read, which is left out of the results of hooks
+	Coverage *Coverage `json:"coverage,omitempty"`
+	// Layers are the layers of the container image that was scanned, if any
+	Layers []ImageLayer `json:"layers,omitempty"`
+
+	renames map[gitrepo.FilePath]gitrepo.FilePath
 }
 
 func (r *DetectionResults) getResultDetailsForFilePath(fileName gitrepo.FilePath) *ResultsDetails {
@@ -70,6 +79,18 @@ func NewDetectionResults() *DetectionResults {
 			FailureTypes{0, 0, 0, 0, 0},
 		},
 		make([]ResultsDetails, 0),
+		nil,
+		nil,
+		make(map[gitrepo.FilePath]gitrepo.FilePath),
+	}
+}
+
+// TrackRenames records the paths that renamed additions had before, so that ignore entries can follow them
+func (r *DetectionResults) TrackRenames(additions []gitrepo.Addition) {
+	for _, addition := range additions {
+		if addition.RenamedFrom != "" {
+			r.renames[addition.Path] = addition.RenamedFrom
+		}
 	}
 }
 
@@ -89,12 +110,12 @@ func (r *DetectionResults) Fail(filePath gitrepo.FilePath, category string, mess
 				}
 			}
 			if !isEntryPresentForGivenCategoryAndMessage {
-				r.Results[resultIndex].FailureList = append(r.Results[resultIndex].FailureList, Details{category, message, commits, severity})
+				r.Results[resultIndex].FailureList = append(r.Results[resultIndex].FailureList, Details{Category: category, Message: message, Commits: commits, Severity: severity})
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		failureDetails := Details{category, message, commits, severity}
+		failureDetails := Details{Category: category, Message: message, Commits: commits, Severity: severity}
 		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
 		resultDetails.FailureList = append(resultDetails.FailureList, failureDetails)
 		r.Results = append(r.Results, resultDetails)
@@ -115,12 +136,12 @@ func (r *DetectionResults) Warn(filePath gitrepo.FilePath, category string, mess
 				}
 			}
 			if !isEntryPresentForGivenCategoryAndMessage {
-				r.Results[resultIndex].WarningList = append(r.Results[resultIndex].WarningList, Details{category, message, commits, severity})
+				r.Results[resultIndex].WarningList = append(r.Results[resultIndex].WarningList, Details{Category: category, Message: message, Commits: commits, Severity: severity})
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		warningDetails := Details{category, message, commits, severity}
+		warningDetails := Details{Category: category, Message: message, Commits: commits, Severity: severity}
 		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
 		resultDetails.WarningList = append(resultDetails.WarningList, warningDetails)
 		r.Results = append(r.Results, resultDetails)
@@ -144,13 +165,13 @@ func (r *DetectionResults) Ignore(filePath gitrepo.FilePath, category string) {
 				}
 			}
 			if !isEntryPresentForGivenCategory {
-				detail := Details{category, "", make([]string, 0), severity.Low}
+				detail := Details{Category: category, Commits: make([]string, 0), Severity: severity.Low}
 				r.Results[resultIndex].IgnoreList = append(r.Results[resultIndex].IgnoreList, detail)
 			}
 		}
 	}
 	if !isFilePresentInResults {
-		ignoreDetails := Details{category, "", make([]string, 0), severity.Low}
+		ignoreDetails := Details{Category: category, Commits: make([]string, 0), Severity: severity.Low}
 		resultDetails := ResultsDetails{filePath, make([]Details, 0), make([]Details, 0), make([]Details, 0)}
 		resultDetails.IgnoreList = append(resultDetails.IgnoreList, ignoreDetails)
 		r.Results = append(r.Results, resultDetails)
@@ -164,12 +185,13 @@ func (r *DetectionResults) updateResultsSummary(category string, decr bool) {
 		val = -1
 	}
 	switch category {
-	case "filecontent":
-		r.Summary.Types.Filecontent += val
 	case "filename":
 		r.Summary.Types.Filename += val
 	case "filesize":
 		r.Summary.Types.Filesize += val
+	default:
+		// filecontent, and the detectors that inspect content for something in particular, such as kubernetes
+		r.Summary.Types.Filecontent += val
 	}
 }
 
@@ -233,6 +255,7 @@ func (r *DetectionResults) ReportWarnings() string {
 func (r *DetectionResults) Report(promptContext prompt.PromptContext, mode string) string {
 	var result string
 	var filePathsForFailures []string
+	var messagePathsForFailures []string
 	var data [][]string
 
 	table := tablewriter.NewWriter(os.Stdout)
@@ -241,7 +264,11 @@ func (r *DetectionResults) Report(promptContext prompt.PromptContext, mode strin
 
 	for _, resultDetails := range r.Results {
 		if len(resultDetails.FailureList) > 0 {
-			filePathsForFailures = append(filePathsForFailures, string(resultDetails.Filename))
+			if gitrepo.IsMessagePath(string(resultDetails.Filename)) {
+				messagePathsForFailures = append(messagePathsForFailures, string(resultDetails.Filename))
+			} else {
+				filePathsForFailures = append(filePathsForFailures, string(resultDetails.Filename))
+			}
 			failureData := r.ReportFileFailures(resultDetails.Filename)
 			data = append(data, failureData...)
 		}
@@ -254,25 +281,43 @@ func (r *DetectionResults) Report(promptContext prompt.PromptContext, mode strin
 		table.AppendBulk(data)
 		table.Render()
 		fmt.Println()
+		r.ReportByAuthor()
+		if len(messagePathsForFailures) > 0 {
+			printMessageIgnoreSuggestion(utility.UniqueItems(messagePathsForFailures))
+		}
 		r.suggestTalismanRC(filePathsForFailures, promptContext, mode)
 	}
 	return result
 }
 
 func (r *DetectionResults) suggestTalismanRC(filePaths []string, promptContext prompt.PromptContext, mode string) {
+	talismanrcConfig, _ := talismanrc.Load()
 	var entriesToAdd []talismanrc.FileIgnoreConfig
+	entriesToRename := make(map[string]talismanrc.FileIgnoreConfig)
 	hasher := utility.MakeHasher(mode, ".")
 	for _, filePath := range filePaths {
-		currentChecksum := hasher.CollectiveSHA256Hash([]string{filePath})
-		fileIgnoreConfig := talismanrc.IgnoreFileWithChecksum(filePath, currentChecksum)
+		if existingEntry, renamed := r.ignoreForRenamedFile(filePath, talismanrcConfig); renamed {
+			renamedEntry := existingEntry
+			renamedEntry.FileName = filePath
+			renamedEntry.Checksum = checksumOf(hasher, filePath, existingEntry.GetChecksumVersion())
+			entriesToRename[existingEntry.GetFileName()] = renamedEntry
+			continue
+		}
+		checksumVersion := talismanrcConfig.GetChecksumVersion()
+		currentChecksum := checksumOf(hasher, filePath, checksumVersion)
+		fileIgnoreConfig := talismanrc.IgnoreFileWithVersionedChecksum(filePath, currentChecksum, checksumVersion)
 		entriesToAdd = append(entriesToAdd, fileIgnoreConfig)
 	}
 
 	if promptContext.Interactive && runtime.GOOS != "windows" {
+		confirmedRenames := getUserConfirmationForRenames(entriesToRename, promptContext)
 		confirmedEntries := getUserConfirmation(entriesToAdd, promptContext)
-		talismanrcConfig, _ := talismanrc.Load()
+		talismanrcConfig.RenameIgnores(confirmedRenames)
 		talismanrcConfig.AddIgnores(confirmedEntries)
 
+		for _, confirmedEntry := range confirmedRenames {
+			confirmedEntries = append(confirmedEntries, confirmedEntry)
+		}
 		for _, confirmedEntry := range confirmedEntries {
 			resultsDetails := r.getResultDetailsForFilePath(gitrepo.FilePath(confirmedEntry.GetFileName()))
 			for _, failure := range resultsDetails.FailureList {
@@ -285,12 +330,31 @@ func (r *DetectionResults) suggestTalismanRC(filePaths []string, promptContext p
 			logrus.Errorf("Error appending to talismanrc %v", output)
 		}
 	} else {
-		printTalismanIgnoreSuggestion(entriesToAdd)
+		printTalismanRenameSuggestion(entriesToRename)
+		if len(entriesToAdd) > 0 {
+			printTalismanIgnoreSuggestion(entriesToAdd)
+		}
 		return
 	}
 
 }
 
+// ignoreForRenamedFile returns the .talismanrc entry for the path a file was renamed from, if it has one
+func (r *DetectionResults) ignoreForRenamedFile(filePath string, tRC *talismanrc.TalismanRC) (talismanrc.FileIgnoreConfig, bool) {
+	renamedFrom, renamed := r.renames[gitrepo.FilePath(filePath)]
+	if !renamed {
+		return talismanrc.FileIgnoreConfig{}, false
+	}
+	return tRC.IgnoreFor(string(renamedFrom))
+}
+
+func checksumOf(hasher utility.SHA256Hasher, filePath string, checksumVersion int) string {
+	if checksumVersion == talismanrc.ChecksumVersionContent {
+		return hasher.ContentSHA256Hash([]string{filePath})
+	}
+	return hasher.CollectiveSHA256Hash([]string{filePath})
+}
+
 func getUserConfirmation(configs []talismanrc.FileIgnoreConfig, promptContext prompt.PromptContext) []talismanrc.FileIgnoreConfig {
 	confirmed := []talismanrc.FileIgnoreConfig{}
 	if len(configs) != 0 {
@@ -304,6 +368,51 @@ func getUserConfirmation(configs []talismanrc.FileIgnoreConfig, promptContext pr
 	return confirmed
 }
 
+func getUserConfirmationForRenames(renames map[string]talismanrc.FileIgnoreConfig, promptContext prompt.PromptContext) map[string]talismanrc.FileIgnoreConfig {
+	confirmed := make(map[string]talismanrc.FileIgnoreConfig)
+	if len(renames) != 0 {
+		fmt.Println("==== Interactively updating renamed files in talismanrc ====")
+	}
+	for _, previousFileName := range sortedKeys(renames) {
+		if confirmRename(previousFileName, renames[previousFileName], promptContext) {
+			confirmed[previousFileName] = renames[previousFileName]
+		}
+	}
+	return confirmed
+}
+
+func printTalismanRenameSuggestion(renames map[string]talismanrc.FileIgnoreConfig) {
+	for _, previousFileName := range sortedKeys(renames) {
+		config := renames[previousFileName]
+		bytes, _ := yaml.Marshal(&config)
+		fmt.Printf("\n\x1b[33m%s was renamed to %s. If its contents are still safe, consider replacing the entry for %s"+
+			" in .talismanrc with the following\x1b[0m\n\n", previousFileName, config.GetFileName(), previousFileName)
+		fmt.Println(string(bytes))
+	}
+}
+
+func sortedKeys(renames map[string]talismanrc.FileIgnoreConfig) []string {
+	var keys []string
+	for key := range renames {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// printMessageIgnoreSuggestion suggests .talismanrc entries for commit messages and tag annotations.
+// Messages are not files that a checksum can be calculated for, so the entries ignore the content detectors instead.
+func printMessageIgnoreSuggestion(messagePaths []string) {
+	var entries []talismanrc.FileIgnoreConfig
+	for _, messagePath := range messagePaths {
+		entries = append(entries, talismanrc.FileIgnoreConfig{FileName: messagePath, IgnoreDetectors: []string{"filecontent"}})
+	}
+	suggestString := fmt.Sprintf("\n\x1b[33mPlease reword the above message(s). If you are absolutely sure that you " +
+		"want to ignore them, consider pasting the following format in .talismanrc file in the project root\x1b[0m\n")
+	fmt.Println(suggestString)
+	fmt.Println(talismanrc.SuggestRCFor(entries))
+}
+
 func printTalismanIgnoreSuggestion(entriesToAdd []talismanrc.FileIgnoreConfig) {
 	ignoreEntries := talismanrc.SuggestRCFor(entriesToAdd)
 	suggestString := fmt.Sprintf("\n\x1b[33mIf you are absolutely sure that you want to ignore the " +
@@ -327,6 +436,20 @@ func confirm(config talismanrc.FileIgnoreConfig, promptContext prompt.PromptCont
 	return promptContext.Prompt.Confirm(confirmationString)
 }
 
+func confirmRename(previousFileName string, config talismanrc.FileIgnoreConfig, promptContext prompt.PromptContext) bool {
+	bytes, err := yaml.Marshal(&config)
+	if err != nil {
+		logrus.Errorf("error marshalling file ignore config: %s", err)
+	}
+
+	fmt.Println()
+	fmt.Println(string(bytes))
+
+	confirmationString := fmt.Sprintf("Do you want to update %s to %s with above checksum in talismanrc ?", previousFileName, config.GetFileName())
+
+	return promptContext.Prompt.Confirm(confirmationString)
+}
+
 // ReportFileFailures adds a string to table documenting the various failures detected on the supplied FilePath by all detectors in the current run
 func (r *DetectionResults) ReportFileFailures(filePath gitrepo.FilePath) [][]string {
 	failureList := r.getResultDetailsForFilePath(filePath).FailureList
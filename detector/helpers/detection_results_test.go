@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"strings"
 	"talisman/detector/severity"
+	"talisman/gitrepo"
 	mock "talisman/internal/mock/prompt"
 	"talisman/prompt"
 	"talisman/talismanrc"
@@ -232,3 +233,72 @@ version: "1.0"
 	err = fs.Remove(talismanrc.RCFileName)
 	assert.NoError(t, err)
 }
+
+func TestTalismanRCSuggestionForRenamedFiles(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	prompter := mock.NewMockPrompt(ctrl)
+	fs := afero.NewMemMapFs()
+	talismanrc.SetFs__(fs)
+
+	existingContent := `fileignoreconfig:
+- filename: old/some_file.pem
+  checksum: 123444ddssa75333b25b6275f97680604add51b84eb8f4a3b9dcbbc652e6f27ac
+  checksum_version: 2
+  ignore_detectors:
+  - filename
+checksum_version: 2
+`
+	renamedAddition := gitrepo.NewAddition("some_file.pem", nil)
+	renamedAddition.RenamedFrom = "old/some_file.pem"
+
+	_ = afero.WriteFile(fs, talismanrc.RCFileName, []byte(existingContent), 0666)
+	t.Run("when user confirms, entry for the previous path should be updated", func(t *testing.T) {
+		promptContext := prompt.NewPromptContext(true, prompter)
+		prompter.EXPECT().Confirm("Do you want to update old/some_file.pem to some_file.pem with above checksum in talismanrc ?").Return(true)
+		prompter.EXPECT().Confirm("Do you want to add another.pem with above checksum in talismanrc ?").Return(true)
+		results := NewDetectionResults()
+		results.TrackRenames([]gitrepo.Addition{renamedAddition})
+		results.Fail("some_file.pem", "filecontent", "Bomb", []string{}, severity.Low)
+		results.Fail("another.pem", "filecontent", "password", []string{}, severity.Low)
+
+		expectedFileContent := `fileignoreconfig:
+- filename: another.pem
+  checksum: cd372fb85148700fa88095e3492d3f9f5beb43e555e5ff26d95f5a6adc36f8e6
+  checksum_version: 2
+- filename: some_file.pem
+  checksum: cd372fb85148700fa88095e3492d3f9f5beb43e555e5ff26d95f5a6adc36f8e6
+  checksum_version: 2
+  ignore_detectors:
+  - filename
+checksum_version: 2
+version: "1.0"
+`
+		results.Report(promptContext, "default")
+		bytesFromFile, err := afero.ReadFile(fs, talismanrc.RCFileName)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedFileContent, string(bytesFromFile))
+		assert.False(t, results.HasFailures())
+	})
+
+	_ = afero.WriteFile(fs, talismanrc.RCFileName, []byte(existingContent), 0666)
+	t.Run("when user declines, entry for the previous path should be kept", func(t *testing.T) {
+		promptContext := prompt.NewPromptContext(true, prompter)
+		prompter.EXPECT().Confirm("Do you want to update old/some_file.pem to some_file.pem with above checksum in talismanrc ?").Return(false)
+		results := NewDetectionResults()
+		results.TrackRenames([]gitrepo.Addition{renamedAddition})
+		results.Fail("some_file.pem", "filecontent", "Bomb", []string{}, severity.Low)
+
+		results.Report(promptContext, "default")
+		bytesFromFile, err := afero.ReadFile(fs, talismanrc.RCFileName)
+
+		assert.NoError(t, err)
+		assert.Equal(t, existingContent, string(bytesFromFile))
+		assert.True(t, results.HasFailures())
+	})
+
+	err := fs.Remove(talismanrc.RCFileName)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Finding is something a detector found in an addition, along with the severity it is reported with
+type Finding struct {
+	Message  string
+	Severity severity.Severity
+}
+
+// ReportFindings tests each addition with find, and reports what is found under the name of the detector: as a failure
+// when its severity exceeds the threshold, or as a warning otherwise. The additions that .talismanrc ignores for the
+// detector are recorded as ignored instead, when anything is found in them.
+func ReportFindings(detectorName string, find func(gitrepo.Addition) []Finding, comparator IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *DetectionResults, additionCompletionCallback func()) {
+	for _, addition := range currentAdditions {
+		findings := find(addition)
+		if len(findings) == 0 {
+			additionCompletionCallback()
+			continue
+		}
+		if comparator.ShouldIgnore(addition, detectorName) {
+			log.WithFields(log.Fields{
+				"filePath": addition.Path,
+				"detector": detectorName,
+			}).Info("Ignoring addition as it was specified to be ignored.")
+			result.Ignore(addition.Path, detectorName)
+			additionCompletionCallback()
+			continue
+		}
+		for _, finding := range findings {
+			log.WithFields(log.Fields{
+				"filePath": addition.Path,
+				"detector": detectorName,
+				"message":  finding.Message,
+			}).Info("Failing file for what the detector found in it.")
+			if finding.Severity.ExceedsThreshold(ignoreConfig.Threshold) {
+				result.Fail(addition.Path, detectorName, finding.Message, addition.Commits, finding.Severity)
+			} else {
+				result.Warn(addition.Path, detectorName, finding.Message, addition.Commits, finding.Severity)
+			}
+		}
+		additionCompletionCallback()
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"talisman/gitrepo"
 	"talisman/talismanrc"
 	"talisman/utility"
+
+	"github.com/sirupsen/logrus"
 )
 
 type IgnoreEvaluator interface {
@@ -33,23 +35,48 @@ type ignoreEvaluator struct {
 func BuildIgnoreEvaluator(hasherMode string, talismanRC *talismanrc.TalismanRC, repo gitrepo.GitRepo) IgnoreEvaluator {
 	wd, _ := os.Getwd()
 	hasher := utility.MakeHasher(hasherMode, wd)
-	allTrackedFiles := append(repo.TrackedFilesAsAdditions(), repo.StagedAdditions()...)
+	trackedFiles, err := repo.TrackedFilesAsAdditions()
+	if err != nil {
+		logrus.Warnf("unable to list tracked files, ignores with checksums will not match them: %v", err)
+	}
+	stagedFiles, err := repo.StagedAdditions()
+	if err != nil {
+		logrus.Warnf("unable to list staged files, ignores with checksums will not match them: %v", err)
+	}
+	allTrackedFiles := append(trackedFiles, stagedFiles...)
 	calculator := checksumcalculator.NewChecksumCalculator(hasher, allTrackedFiles)
 	return &ignoreEvaluator{calculator: calculator, talismanRC: talismanRC}
 }
 
+// Returns an IgnoreEvaluator around the rules defined in the current .talismanrc file, for files read without git,
+// whose checksums are calculated from the contents that were read, which for piped content is not that of the file
+func BuildFilesystemIgnoreEvaluator(talismanRC *talismanrc.TalismanRC, files []gitrepo.Addition) IgnoreEvaluator {
+	calculator := checksumcalculator.NewChecksumCalculator(utility.NewAdditionsSHA256Hasher(files), files)
+	return &ignoreEvaluator{calculator: calculator, talismanRC: talismanRC}
+}
+
 // ShouldIgnore returns true if the talismanRC indicates that a Detector should ignore an Addition
 func (ie *ignoreEvaluator) ShouldIgnore(addition gitrepo.Addition, detectorType string) bool {
 	return ie.talismanRC.Deny(addition, detectorType) || ie.isScanNotRequired(addition)
 }
 
-// isScanNotRequired returns true if an Addition's checksum matches one ignored by the .talismanrc file
+// isScanNotRequired returns true if an Addition's checksum matches one ignored by the .talismanrc file.
+// Entries with content-only checksums also apply to an Addition renamed from a file they matched.
 func (ie *ignoreEvaluator) isScanNotRequired(addition gitrepo.Addition) bool {
 	for _, ignore := range ie.talismanRC.FileIgnoreConfig {
 		if addition.Matches(ignore.GetFileName()) {
+			if ignore.IsContentChecksum() {
+				return ignore.ChecksumMatches(ie.calculator.CalculateChecksumForPattern(ignore.GetFileName(), ignore.GetChecksumVersion()))
+			}
 			currentCollectiveChecksum := ie.calculator.CalculateCollectiveChecksumForPattern(ignore.GetFileName())
 			return ignore.ChecksumMatches(currentCollectiveChecksum)
 		}
 	}
+	for _, ignore := range ie.talismanRC.FileIgnoreConfig {
+		if ignore.IsContentChecksum() && addition.RenamedFromMatches(ignore.GetFileName()) {
+			currentChecksum := ie.calculator.CalculateChecksumForPattern(string(addition.Path), ignore.GetChecksumVersion())
+			return ignore.ChecksumMatches(currentChecksum)
+		}
+	}
 	return false
 }
@@ -51,6 +51,66 @@ func TestIsScanNotRequired(t *testing.T) {
 		assert.True(t, required)
 	})
 
+	t.Run("should use the checksum scheme recorded for the entry", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		checksumCalculator := mockchecksumcalculator.NewMockChecksumCalculator(ctrl)
+		ignoreConfig := talismanrc.TalismanRC{
+			FileIgnoreConfig: []talismanrc.FileIgnoreConfig{
+				{
+					FileName:        "some.txt",
+					Checksum:        "content-sha",
+					ChecksumVersion: talismanrc.ChecksumVersionContent,
+				},
+			},
+		}
+		ie := ignoreEvaluator{calculator: checksumCalculator, talismanRC: &ignoreConfig}
+		addition := gitrepo.Addition{Name: "some.txt", Path: "some.txt"}
+		checksumCalculator.EXPECT().CalculateChecksumForPattern("some.txt", talismanrc.ChecksumVersionContent).Return("content-sha")
+
+		required := ie.isScanNotRequired(addition)
+
+		assert.True(t, required)
+	})
+
+	t.Run("should follow renames for entries with content checksums", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		checksumCalculator := mockchecksumcalculator.NewMockChecksumCalculator(ctrl)
+		ignoreConfig := talismanrc.TalismanRC{
+			FileIgnoreConfig: []talismanrc.FileIgnoreConfig{
+				{
+					FileName:        "old/cert.pem",
+					Checksum:        "content-sha",
+					ChecksumVersion: talismanrc.ChecksumVersionContent,
+				},
+			},
+		}
+		ie := ignoreEvaluator{calculator: checksumCalculator, talismanRC: &ignoreConfig}
+		addition := gitrepo.Addition{Name: "cert.pem", Path: "new/cert.pem", RenamedFrom: "old/cert.pem"}
+		checksumCalculator.EXPECT().CalculateChecksumForPattern("new/cert.pem", talismanrc.ChecksumVersionContent).Return("content-sha")
+
+		required := ie.isScanNotRequired(addition)
+
+		assert.True(t, required)
+	})
+
+	t.Run("should not follow renames for entries with path and content checksums", func(t *testing.T) {
+		ignoreConfig := talismanrc.TalismanRC{
+			FileIgnoreConfig: []talismanrc.FileIgnoreConfig{
+				{
+					FileName: "old/cert.pem",
+					Checksum: "sha1",
+				},
+			},
+		}
+		ie := ignoreEvaluator{nil, &ignoreConfig}
+		addition := gitrepo.Addition{Name: "cert.pem", Path: "new/cert.pem", RenamedFrom: "old/cert.pem"}
+
+		required := ie.isScanNotRequired(addition)
+
+		assert.False(t, required)
+	})
 }
 
 type sillyChecksumCalculator struct{}
@@ -58,7 +118,10 @@ type sillyChecksumCalculator struct{}
 func (scc *sillyChecksumCalculator) CalculateCollectiveChecksumForPattern(fileNamePattern string) string {
 	return "silly"
 }
-func (scc *sillyChecksumCalculator) SuggestTalismanRC(fileNamePatterns []string) string {
+func (scc *sillyChecksumCalculator) CalculateChecksumForPattern(fileNamePattern string, checksumVersion int) string {
+	return "silly"
+}
+func (scc *sillyChecksumCalculator) SuggestTalismanRC(fileNamePatterns []string, checksumVersion int) string {
 	return ""
 }
 
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"talisman/gitrepo"
+
+	"github.com/olekukonko/tablewriter"
+)
+
+// ImageLayer is a layer of a container image that was scanned. The files of a layer are given in the results by
+// their path within the layer, prefixed with the label of the layer, as in "4f2a9c0b1d3e:etc/app/.npmrc".
+type ImageLayer struct {
+	// Digest is the digest of the layer as the image tarball holds it
+	Digest string `json:"digest"`
+	// Instruction is the instruction recorded in the history of the image as creating the layer, when known
+	Instruction string `json:"instruction,omitempty"`
+	// Files is the number of files of the layer that were scanned
+	Files int `json:"files"`
+}
+
+// LayerLabel is the short form of the digest of a layer that the paths of its files are prefixed with
+func LayerLabel(digest string) string {
+	_, hex, found := strings.Cut(digest, ":")
+	if !found {
+		hex = digest
+	}
+	if len(hex) > 12 {
+		hex = hex[:12]
+	}
+	return hex
+}
+
+// imageFile returns the layer holding a file of an image, along with the path of the file within the layer
+func (r *DetectionResults) imageFile(filePath gitrepo.FilePath) (ImageLayer, string) {
+	label, pathInLayer, _ := strings.Cut(string(filePath), ":")
+	for _, layer := range r.Layers {
+		if LayerLabel(layer.Digest) == label {
+			return layer, pathInLayer
+		}
+	}
+	return ImageLayer{Digest: label}, pathInLayer
+}
+
+// ReportImageFailures prints the failures found in the files of a container image, along with the label of the layer
+// holding each file and the instruction that created the layer
+func (r *DetectionResults) ReportImageFailures() {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader([]string{"Layer", "Instruction", "File", "Errors", "Severity"})
+	table.SetRowLine(true)
+	for _, resultDetails := range r.Results {
+		layer, pathInLayer := r.imageFile(resultDetails.Filename)
+		for _, failure := range r.ReportFileFailures(resultDetails.Filename) {
+			table.Append([]string{LayerLabel(layer.Digest), layer.Instruction, pathInLayer, failure[1], failure[2]})
+		}
+	}
+	fmt.Printf("\n\x1b[1m\x1b[31mTalisman Report:\x1b[0m\x1b[0m\n")
+	table.Render()
+	fmt.Println()
+}
@@ -0,0 +1,198 @@
+package kubernetes
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"talisman/detector/encrypted"
+	"talisman/detector/helpers"
+	"talisman/detector/pattern"
+	"talisman/detector/privatekey"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+// templatePlaceholder stands in for the actions of Helm templates, which are not valid YAML
+const templatePlaceholder = "TALISMAN_TEMPLATE"
+
+var (
+	manifestExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+	templateAction     = regexp.MustCompile(`{{.*?}}`)
+	// unsetValue matches values that are filled in when the manifest is applied, such as by Helm or envsubst, or that
+	// SOPS encrypted
+	unsetValue = regexp.MustCompile(`^\s*$|` + templatePlaceholder + `|\$\{[^}]*\}|^ENC\[[A-Za-z0-9_]+,data:.*\]$`)
+)
+
+// SecretDetector tests the Secret objects of Kubernetes manifests and Helm chart templates, whose values are only
+// base64 encoded in data, or in plain text in stringData
+type SecretDetector struct {
+	patterns *pattern.PatternDetector
+}
+
+// NewSecretDetector returns a SecretDetector that checks the values of Secrets with the pre-configured and custom patterns
+func NewSecretDetector(tRC *talismanrc.TalismanRC) *SecretDetector {
+	return &SecretDetector{patterns: pattern.NewPatternDetector(tRC.CustomPatterns)}
+}
+
+type manifest struct {
+	Kind     string `yaml:"kind"`
+	Metadata struct {
+		Name      string `yaml:"name"`
+		Namespace string `yaml:"namespace"`
+	} `yaml:"metadata"`
+	Data       map[string]interface{} `yaml:"data"`
+	StringData map[string]interface{} `yaml:"stringData"`
+	Sops       interface{}            `yaml:"sops"`
+	Items      []manifest             `yaml:"items"`
+}
+
+// secretValue is a value of a Secret that was set in the manifest
+type secretValue struct {
+	secret  string
+	key     string
+	field   string
+	keys    []string
+	matches []string
+}
+
+func (v secretValue) message() string {
+	encoding := "a plain text"
+	if v.field == "data" {
+		encoding = "a base64 encoded"
+	}
+	message := fmt.Sprintf("Kubernetes Secret %s holds %s value for key %s in %s", v.secret, encoding, v.key, v.field)
+	if len(v.keys) > 0 {
+		message += ", which holds " + v.keys[0]
+	} else if len(v.matches) > 0 {
+		message += fmt.Sprintf(", which matches a secret pattern: %s", formatForReporting(v.matches[0]))
+	}
+	return message
+}
+
+// Test fails the manifests holding Secrets with values set, naming the Secret and the key of each value.
+// SealedSecrets, SOPS encrypted manifests and values filled in by templates are not reported.
+func (sd *SecretDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	secretSeverity := severity.SeverityConfiguration["KubernetesSecret"]
+	find := func(addition gitrepo.Addition) []helpers.Finding {
+		if !isManifest(addition) {
+			return nil
+		}
+		var findings []helpers.Finding
+		for _, value := range sd.secretValues(encrypted.RemoveCiphertext(ignoreConfig.RemoveAllowedPatterns(addition))) {
+			findings = append(findings, helpers.Finding{Message: value.message(), Severity: secretSeverity})
+		}
+		return findings
+	}
+	helpers.ReportFindings("kubernetes", find, comparator, currentAdditions, ignoreConfig, result, additionCompletionCallback)
+}
+
+func isManifest(addition gitrepo.Addition) bool {
+	return manifestExtensions[strings.ToLower(filepath.Ext(string(addition.Name)))] &&
+		bytes.Contains(addition.Data, []byte("Secret"))
+}
+
+// secretValues returns the values set in the Secrets of each document of the content, which is not a manifest
+// unless it can be read as YAML
+func (sd *SecretDetector) secretValues(content string) []secretValue {
+	decoder := yaml.NewDecoder(strings.NewReader(withoutTemplateActions(content)))
+	var values []secretValue
+	for {
+		var document manifest
+		err := decoder.Decode(&document)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Debugf("not reading the rest of the file as Kubernetes manifests: %v", err)
+			break
+		}
+		values = append(values, sd.valuesOf(document)...)
+	}
+	return values
+}
+
+func (sd *SecretDetector) valuesOf(document manifest) []secretValue {
+	var values []secretValue
+	for _, item := range document.Items {
+		values = append(values, sd.valuesOf(item)...)
+	}
+	if document.Kind != "Secret" || document.Sops != nil {
+		return values
+	}
+	name := document.Metadata.Name
+	if document.Metadata.Namespace != "" {
+		name = document.Metadata.Namespace + "/" + name
+	}
+	for _, key := range sortedKeys(document.Data) {
+		encoded := fmt.Sprint(document.Data[key])
+		if unsetValue.MatchString(encoded) {
+			continue
+		}
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+		if err != nil {
+			decoded = []byte(encoded)
+		}
+		values = append(values, sd.value(name, key, "data", string(decoded)))
+	}
+	for _, key := range sortedKeys(document.StringData) {
+		value := fmt.Sprint(document.StringData[key])
+		if !unsetValue.MatchString(value) {
+			values = append(values, sd.value(name, key, "stringData", value))
+		}
+	}
+	return values
+}
+
+// value checks a value for private keys, such as that of a TLS Secret, and along with its key, so that patterns such
+// as those for passwords find it
+func (sd *SecretDetector) value(secret string, key string, field string, value string) secretValue {
+	return secretValue{
+		secret:  secret,
+		key:     key,
+		field:   field,
+		keys:    privatekey.Describe([]byte(value)),
+		matches: sd.patterns.Matches(fmt.Sprintf("%s: %s", key, value)),
+	}
+}
+
+// withoutTemplateActions replaces the actions of Helm templates with a placeholder, and leaves out the lines holding
+// nothing but actions, such as {{- if .Values.enabled }}, so that templates can be read as YAML
+func withoutTemplateActions(content string) string {
+	if !strings.Contains(content, "{{") {
+		return content
+	}
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !templateAction.MatchString(line) {
+			lines = append(lines, line)
+		} else if strings.TrimSpace(templateAction.ReplaceAllString(line, "")) != "" {
+			lines = append(lines, templateAction.ReplaceAllString(line, templatePlaceholder))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatForReporting(input string) string {
+	if len(input) > 50 {
+		return input[:47] + "..."
+	}
+	return input
+}
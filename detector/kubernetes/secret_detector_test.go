@@ -0,0 +1,161 @@
+package kubernetes
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"talisman/detector/detector_testing"
+	"talisman/detector/severity"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var talismanRC = &talismanrc.TalismanRC{}
+
+const manifests = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  password: not-a-secret-in-a-config-map
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: db-credentials
+  namespace: production
+type: Opaque
+data:
+  username: YWRtaW4=
+  password: cGFzc3dvcmQ9czNjcjN0UGFzc3dvcmQ=
+  empty: ""
+stringData:
+  api-token: ghp_plaintextToken
+`
+
+func TestShouldFlagEachValueOfSecretsWithTheirNameAndKey(t *testing.T) {
+	results := detector_testing.ResultsOf(NewSecretDetector(talismanRC), "k8s/secrets.yaml", []byte(manifests), talismanRC)
+
+	assert.True(t, results.HasFailures(), "Expected a Secret with values to fail")
+	assert.Equal(t, []string{
+		"Kubernetes Secret production/db-credentials holds a base64 encoded value for key password in data, " +
+			"which matches a secret pattern: password: password=s3cr3tPassword",
+		"Kubernetes Secret production/db-credentials holds a base64 encoded value for key username in data",
+		"Kubernetes Secret production/db-credentials holds a plain text value for key api-token in stringData",
+	}, detector_testing.FailureMessagesOf(results, "k8s/secrets.yaml"))
+}
+
+func TestShouldNameThePrivateKeysOfTLSSecrets(t *testing.T) {
+	generated, err := rsa.GenerateKey(rand.Reader, 1024)
+	if !assert.NoError(t, err) {
+		return
+	}
+	key := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(generated)})
+	secret := "apiVersion: v1\nkind: Secret\nmetadata:\n  name: ingress-tls\ntype: kubernetes.io/tls\ndata:\n" +
+		"  tls.key: " + base64.StdEncoding.EncodeToString(key) + "\n"
+
+	results := detector_testing.ResultsOf(NewSecretDetector(talismanRC), "tls-secret.yaml", []byte(secret), talismanRC)
+
+	assert.Equal(t, []string{"Kubernetes Secret ingress-tls holds a base64 encoded value for key tls.key in data, " +
+		"which holds an unencrypted PKCS#1 RSA private key of 1024 bits"}, detector_testing.FailureMessagesOf(results, "tls-secret.yaml"))
+}
+
+func TestShouldNotFlagValuesFilledInByTemplates(t *testing.T) {
+	template := `{{- if .Values.secret.create }}
+apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ include "chart.fullname" . }}
+data:
+  password: {{ .Values.password | b64enc | quote }}
+stringData:
+  token: "${API_TOKEN}"
+  url: "https://{{ .Values.host }}/api"
+{{- end }}
+`
+	results := detector_testing.ResultsOf(NewSecretDetector(talismanRC), "chart/templates/secret.yaml", []byte(template), talismanRC)
+
+	assert.False(t, results.HasFailures(), "Expected values filled in by Helm or envsubst not to fail")
+}
+
+func TestShouldNotFlagSealedSecretsOrSOPSEncryptedSecrets(t *testing.T) {
+	sealedSecret := `apiVersion: bitnami.com/v1alpha1
+kind: SealedSecret
+metadata:
+  name: db-credentials
+spec:
+  encryptedData:
+    password: AgBy3i4OJSWK+PiTySYZZA9rO43cGDEq
+`
+	sopsSecret := `apiVersion: v1
+kind: Secret
+metadata:
+  name: db-credentials
+stringData:
+  password: ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]
+sops:
+  version: 3.7.3
+`
+	partiallySopsEncrypted := `apiVersion: v1
+kind: Secret
+metadata:
+  name: db-credentials
+stringData:
+  password: ENC[AES256_GCM,data:Tr7o=,iv:1=,tag:2=,type:str]
+`
+
+	assert.False(t, detector_testing.ResultsOf(NewSecretDetector(talismanRC), "sealed-secret.yaml", []byte(sealedSecret), talismanRC).HasFailures())
+	assert.False(t, detector_testing.ResultsOf(NewSecretDetector(talismanRC), "secret.enc.yaml", []byte(sopsSecret), talismanRC).HasFailures())
+	assert.False(t, detector_testing.ResultsOf(NewSecretDetector(talismanRC), "secret.yaml", []byte(partiallySopsEncrypted), talismanRC).HasFailures())
+}
+
+func TestShouldFlagSecretsInLists(t *testing.T) {
+	list := `{"apiVersion": "v1", "kind": "List", "items": [
+  {"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "token"}, "data": {"token": "c2VjcmV0"}}
+]}`
+
+	assert.Equal(t, []string{"Kubernetes Secret token holds a base64 encoded value for key token in data"},
+		detector_testing.FailureMessagesOf(detector_testing.ResultsOf(NewSecretDetector(talismanRC), "secrets.json", []byte(list), talismanRC), "secrets.json"))
+}
+
+func TestShouldNotReadFilesThatAreNotManifests(t *testing.T) {
+	assert.False(t, detector_testing.ResultsOf(NewSecretDetector(talismanRC), "notes.txt", []byte("kind: Secret\ndata:\n  password: c2VjcmV0\n"), talismanRC).HasFailures())
+	assert.False(t, detector_testing.ResultsOf(NewSecretDetector(talismanRC), "invalid.yaml", []byte("kind: Secret\n\tdata: ["), talismanRC).HasFailures())
+}
+
+func TestShouldWarnAboutSecretsBelowThreshold(t *testing.T) {
+	configured := severity.SeverityConfiguration["KubernetesSecret"]
+	defer func() { severity.SeverityConfiguration["KubernetesSecret"] = configured }()
+	severity.SeverityConfiguration["KubernetesSecret"] = severity.Medium
+
+	results := detector_testing.ResultsOf(NewSecretDetector(&talismanrc.TalismanRC{Threshold: severity.High}), "secret.yaml", []byte(manifests), &talismanrc.TalismanRC{Threshold: severity.High})
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasWarnings())
+}
+
+func TestShouldNotFlagManifestsIgnoredForTheKubernetesDetector(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{FileName: "secret.yaml", IgnoreDetectors: []string{"kubernetes"}}},
+	}
+	results := detector_testing.ResultsOf(NewSecretDetector(tRC), "secret.yaml", []byte(manifests), tRC)
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasIgnores())
+}
+
+func TestShouldReportSecretsUnderTheKubernetesDetectorRatherThanFileContent(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{FileName: "secret.yaml", Checksum: "outdated", IgnoreDetectors: []string{"filecontent"}}},
+	}
+	results := detector_testing.ResultsOf(NewSecretDetector(tRC), "secret.yaml", []byte(manifests), tRC)
+
+	assert.True(t, results.HasFailures(), "Expected ignoring the filecontent detector to leave Secrets reported")
+	for _, failure := range results.GetFailures("secret.yaml") {
+		assert.Equal(t, "kubernetes", failure.Category)
+	}
+}
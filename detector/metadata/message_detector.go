@@ -0,0 +1,51 @@
+package metadata
+
+import (
+	"talisman/detector/detector"
+	"talisman/detector/filecontent"
+	"talisman/detector/helpers"
+	"talisman/detector/pattern"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// MessageDetector tests commit messages and tag annotations with the file content and pattern detectors.
+// Findings are reported against the pseudo path of each message, such as commit:<sha>:message.
+type MessageDetector struct {
+	messages  []gitrepo.Message
+	detectors []detector.Detector
+}
+
+// NewMessageDetector returns a MessageDetector for the given messages
+func NewMessageDetector(tRC *talismanrc.TalismanRC, messages []gitrepo.Message) *MessageDetector {
+	return &MessageDetector{
+		messages: messages,
+		detectors: []detector.Detector{
+			filecontent.NewFileContentDetector(tRC),
+			pattern.NewPatternDetector(tRC.CustomPatterns),
+		},
+	}
+}
+
+// Test tests the messages the detector was created with. The additions passed in are file content and are not tested.
+func (md *MessageDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	log.Infof("Number of messages to scan: %d", len(md.messages))
+	additions := make([]gitrepo.Addition, len(md.messages))
+	for i, message := range md.messages {
+		additions[i] = message.Addition()
+	}
+	for _, d := range md.detectors {
+		d.Test(messageIgnoreEvaluator{comparator}, additions, ignoreConfig, result, func() {})
+	}
+}
+
+// messageIgnoreEvaluator also ignores the messages whose .talismanrc entry names the metadata detector
+type messageIgnoreEvaluator struct {
+	helpers.IgnoreEvaluator
+}
+
+func (ie messageIgnoreEvaluator) ShouldIgnore(addition gitrepo.Addition, detectorType string) bool {
+	return ie.IgnoreEvaluator.ShouldIgnore(addition, detectorType) || ie.IgnoreEvaluator.ShouldIgnore(addition, "metadata")
+}
@@ -0,0 +1,48 @@
+package metadata
+
+import (
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageDetectorReportsSecretsAgainstTheMessagePath(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+	messages := []gitrepo.Message{
+		{Kind: gitrepo.CommitMessage, ID: "abc123", Text: "Use accessKey=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"},
+		{Kind: gitrepo.TagAnnotation, ID: "v1.0", Text: "Release notes"},
+	}
+
+	NewMessageDetector(tRC, messages).Test(helpers.ScanHistoryEvaluator(), nil, tRC, results, func() {})
+
+	assert.NotEmpty(t, results.GetFailures("commit:abc123:message"))
+	assert.Empty(t, results.GetFailures("tag:v1.0:message"))
+}
+
+func TestMessageDetectorDoesNotTestFileContent(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{}
+	results := helpers.NewDetectionResults()
+	additions := []gitrepo.Addition{gitrepo.NewAddition("secret.txt", []byte("accessKey=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"))}
+
+	NewMessageDetector(tRC, nil).Test(helpers.ScanHistoryEvaluator(), additions, tRC, results, func() {})
+
+	assert.False(t, results.HasFailures())
+}
+
+func TestMessageDetectorDoesNotFlagMessagesIgnoredForTheMetadataDetector(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{FileName: "commit:abc123:message", IgnoreDetectors: []string{"metadata"}}},
+	}
+	results := helpers.NewDetectionResults()
+	messages := []gitrepo.Message{{Kind: gitrepo.CommitMessage, ID: "abc123", Text: "Use accessKey=wJalrXUtnFEMI/K7MDENG/bPxRfiCYEXAMPLEKEY"}}
+	ie := helpers.BuildIgnoreEvaluator("default", tRC, gitrepo.RepoLocatedAt("."))
+
+	NewMessageDetector(tRC, messages).Test(ie, nil, tRC, results, func() {})
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasIgnores())
+}
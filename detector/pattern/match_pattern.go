@@ -2,47 +2,164 @@ package pattern
 
 import (
 	"fmt"
+	"math"
 	"regexp"
+	"strings"
 	"talisman/detector/severity"
+	"talisman/gitrepo"
 	"talisman/talismanrc"
 
 	"github.com/sirupsen/logrus"
 )
 
 type PatternMatcher struct {
-	regexes []*severity.PatternSeverity
+	regexes []*patternRule
+}
+
+// patternRule is a pattern to look for, along with the conditions under which a match is reported
+type patternRule struct {
+	*severity.PatternSeverity
+	paths        []string
+	excludePaths []string
+	keywords     []string
+	entropy      float64
 }
 
 type DetectionsWithSeverity struct {
-	detections []string
-	severity   severity.Severity
+	detections  []string
+	severity    severity.Severity
+	id          string
+	description string
 }
 
 func (pm *PatternMatcher) check(content string, thresholdValue severity.Severity) []DetectionsWithSeverity {
+	return pm.detect(pm.regexes, content)
+}
+
+// checkAddition checks content against the patterns that apply to the path of the addition
+func (pm *PatternMatcher) checkAddition(addition gitrepo.Addition, content string, thresholdValue severity.Severity) []DetectionsWithSeverity {
+	var applicableRules []*patternRule
+	for _, rule := range pm.regexes {
+		if rule.appliesTo(addition) {
+			applicableRules = append(applicableRules, rule)
+		}
+	}
+	return pm.detect(applicableRules, content)
+}
+
+func (pm *PatternMatcher) detect(rules []*patternRule, content string) []DetectionsWithSeverity {
 	var detectionsWithSeverity []DetectionsWithSeverity
-	for _, pattern := range pm.regexes {
+	lowerCaseContent := ""
+	for _, rule := range rules {
+		if len(rule.keywords) > 0 {
+			if lowerCaseContent == "" {
+				lowerCaseContent = strings.ToLower(content)
+			}
+			if !rule.containsKeyword(lowerCaseContent) {
+				continue
+			}
+		}
 		var detected []string
-		regex := pattern.Pattern
+		regex := rule.Pattern
 		logrus.Debugf("checking for pattern %v", regex)
-		matches := regex.FindAllString(content, -1)
-		if matches != nil {
-			detected = append(detected, matches...)
-			detectionsWithSeverity = append(detectionsWithSeverity, DetectionsWithSeverity{detections: detected, severity: pattern.Severity})
+		for _, submatches := range regex.FindAllStringSubmatch(content, -1) {
+			if rule.hasEnoughEntropy(submatches) {
+				detected = append(detected, submatches[0])
+			}
+		}
+		if detected != nil {
+			detectionsWithSeverity = append(detectionsWithSeverity, DetectionsWithSeverity{
+				detections:  detected,
+				severity:    rule.Severity,
+				id:          rule.ID,
+				description: rule.Description,
+			})
 		}
 	}
 	return detectionsWithSeverity
 }
 
-func (pm *PatternMatcher) add(ps talismanrc.PatternString) {
-	re, err := regexp.Compile(fmt.Sprintf("(%s)", string(ps)))
+func (r *patternRule) appliesTo(addition gitrepo.Addition) bool {
+	for _, excludePath := range r.excludePaths {
+		if addition.Matches(excludePath) {
+			return false
+		}
+	}
+	if len(r.paths) == 0 {
+		return true
+	}
+	for _, path := range r.paths {
+		if addition.Matches(path) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *patternRule) containsKeyword(lowerCaseContent string) bool {
+	for _, keyword := range r.keywords {
+		if strings.Contains(lowerCaseContent, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasEnoughEntropy checks the first group captured by the user's regex, or the whole match if it captures nothing.
+// Custom regexes are wrapped in a group of their own, so the user's first group is the second submatch.
+func (r *patternRule) hasEnoughEntropy(submatches []string) bool {
+	if r.entropy <= 0 {
+		return true
+	}
+	candidate := submatches[0]
+	if len(submatches) > 2 && submatches[2] != "" {
+		candidate = submatches[2]
+	}
+	return shannonEntropy(candidate) >= r.entropy
+}
+
+func shannonEntropy(str string) float64 {
+	if str == "" {
+		return 0
+	}
+	frequencies := map[rune]int{}
+	total := 0
+	for _, c := range str {
+		frequencies[c]++
+		total++
+	}
+	entropy := 0.0
+	for _, count := range frequencies {
+		p := float64(count) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+func (pm *PatternMatcher) add(cp talismanrc.CustomPattern) {
+	re, err := regexp.Compile(fmt.Sprintf("(%s)", string(cp.Regex)))
 	if err != nil {
-		logrus.Warnf("ignoring invalid pattern '%s'", ps)
+		logrus.Warnf("ignoring invalid pattern '%s'", cp.Regex)
 		return
 	}
-	logrus.Infof("added custom pattern '%s' with high severity", ps)
-	pm.regexes = append(pm.regexes, &severity.PatternSeverity{Pattern: re, Severity: severity.SeverityConfiguration["CustomPattern"]})
+	patternSeverity := severity.SeverityConfiguration["CustomPattern"]
+	if cp.Severity != 0 {
+		patternSeverity = cp.Severity
+	}
+	logrus.Infof("added custom pattern '%s' with %s severity", cp.Regex, patternSeverity)
+	pm.regexes = append(pm.regexes, &patternRule{
+		PatternSeverity: &severity.PatternSeverity{ID: cp.ID, Description: cp.Description, Pattern: re, Severity: patternSeverity},
+		paths:           cp.Paths,
+		excludePaths:    cp.ExcludePaths,
+		keywords:        cp.Keywords,
+		entropy:         cp.Entropy,
+	})
 }
 
 func NewPatternMatcher(patterns []*severity.PatternSeverity) *PatternMatcher {
-	return &PatternMatcher{patterns}
+	rules := make([]*patternRule, len(patterns))
+	for i, pattern := range patterns {
+		rules[i] = &patternRule{PatternSeverity: pattern}
+	}
+	return &PatternMatcher{rules}
 }
@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"regexp"
 	"talisman/detector/severity"
+	"talisman/gitrepo"
 	"talisman/talismanrc"
 	"testing"
 
@@ -36,13 +37,47 @@ func TestShouldReturnStringWhenMatchedPasswordPattern(t *testing.T) {
 
 func TestShouldAddGoodPatternWithHighToMatcher(t *testing.T) {
 	pm := NewPatternMatcher([]*severity.PatternSeverity{})
-	pm.add(talismanrc.PatternString(testRegexpPwPattern))
+	pm.add(talismanrc.CustomPattern{Regex: talismanrc.PatternString(testRegexpPwPattern)})
 	detections := pm.check("pw\"  :  123456789", severity.Low)
 	assert.Equal(t, []DetectionsWithSeverity{{detections: []string{"pw\"  :  123456789"}, severity: severity.High}}, detections)
 }
 
 func TestShouldNotAddBadPatternToMatcher(t *testing.T) {
 	pm := NewPatternMatcher([]*severity.PatternSeverity{})
-	pm.add(`*a(crappy|regex`)
+	pm.add(talismanrc.CustomPattern{Regex: `*a(crappy|regex`})
 	assert.Equal(t, 0, len(pm.regexes))
 }
+
+func TestShouldAddNamedPatternWithItsOwnSeverity(t *testing.T) {
+	pm := NewPatternMatcher([]*severity.PatternSeverity{})
+	pm.add(talismanrc.CustomPattern{ID: "pw", Description: "password", Regex: talismanrc.PatternString(testRegexpPwPattern), Severity: severity.Low})
+	detections := pm.check("pw\"  :  123456789", severity.Low)
+	assert.Equal(t, []DetectionsWithSeverity{{detections: []string{"pw\"  :  123456789"}, severity: severity.Low, id: "pw", description: "password"}}, detections)
+}
+
+func TestShouldOnlyCheckPatternAgainstMatchingPaths(t *testing.T) {
+	pm := NewPatternMatcher([]*severity.PatternSeverity{})
+	pm.add(talismanrc.CustomPattern{Regex: "token-[a-z]+", Paths: []string{"*.yml"}, ExcludePaths: []string{"fixtures/"}})
+	content := "token-abcdef"
+
+	assert.Len(t, pm.checkAddition(gitrepo.NewAddition("config/app.yml", nil), content, severity.Low), 1)
+	assert.Empty(t, pm.checkAddition(gitrepo.NewAddition("config/app.json", nil), content, severity.Low))
+	assert.Empty(t, pm.checkAddition(gitrepo.NewAddition("fixtures/app.yml", nil), content, severity.Low))
+}
+
+func TestShouldOnlyCheckPatternWhenAKeywordIsPresent(t *testing.T) {
+	pm := NewPatternMatcher([]*severity.PatternSeverity{})
+	pm.add(talismanrc.CustomPattern{Regex: "[0-9a-f]{16}", Keywords: []string{"Stripe"}})
+
+	assert.Empty(t, pm.check("build: 0123456789abcdef", severity.Low))
+	assert.Len(t, pm.check("stripe_key: 0123456789abcdef", severity.Low), 1)
+}
+
+func TestShouldOnlyReportMatchesWhoseCapturedGroupHasEnoughEntropy(t *testing.T) {
+	pm := NewPatternMatcher([]*severity.PatternSeverity{})
+	pm.add(talismanrc.CustomPattern{Regex: "secret=([A-Za-z0-9]+)", Entropy: 3.5})
+
+	assert.Empty(t, pm.check("secret=aaaaaaaaaaaaaaaa", severity.Low))
+	detections := pm.check("secret=aaaaaaaaaaaaaaaa secret=q8Fz2LmX0pRt7WkY", severity.Low)
+	assert.Equal(t, []string{"secret=q8Fz2LmX0pRt7WkY"}, detections[0].detections)
+}
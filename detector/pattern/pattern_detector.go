@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"sync"
+	"talisman/detector/encrypted"
 	"talisman/detector/helpers"
 	"talisman/detector/severity"
 	"talisman/gitrepo"
@@ -25,7 +26,6 @@ var (
 		{Pattern: regexp.MustCompile(`(?i)(<ConsumerSecret>\S*</ConsumerSecret>)`), Severity: severity.SeverityConfiguration["ConsumerSecretParrern"]},
 		{Pattern: regexp.MustCompile(`(?i)(AWS[ \w]+key[ \w]+[:=])`), Severity: severity.SeverityConfiguration["AWSKeyPattern"]},
 		{Pattern: regexp.MustCompile(`(?i)(AWS[ \w]+secret[ \w]+[:=])`), Severity: severity.SeverityConfiguration["AWSSecretPattern"]},
-		{Pattern: regexp.MustCompile(`(?s)(BEGIN RSA PRIVATE KEY.*END RSA PRIVATE KEY)`), Severity: severity.SeverityConfiguration["RSAKeyPattern"]},
 	}
 )
 
@@ -50,7 +50,7 @@ func (detector PatternDetector) Test(comparator helpers.IgnoreEvaluator, current
 				ignoredFilePaths <- addition.Path
 				return
 			}
-			detections := detector.secretsPattern.check(ignoreConfig.RemoveAllowedPatterns(addition), ignoreConfig.Threshold)
+			detections := detector.secretsPattern.checkAddition(addition, encrypted.RemoveCiphertext(ignoreConfig.RemoveAllowedPatterns(addition)), ignoreConfig.Threshold)
 			matches <- match{name: addition.Name, path: addition.Path, detections: detections, commits: addition.Commits}
 		}(addition)
 	}
@@ -93,21 +93,44 @@ func (detector PatternDetector) processMatch(match match, result *helpers.Detect
 						"filePath": match.path,
 						"pattern":  detection,
 					}).Warn("Warning file as it matched pattern.")
-					result.Warn(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), match.commits, detectionWithSeverity.severity)
+					result.Warn(match.path, "filecontent", detectionWithSeverity.message(detection), match.commits, detectionWithSeverity.severity)
 				} else {
 					log.WithFields(log.Fields{
 						"filePath": match.path,
 						"pattern":  detection,
 					}).Info("Failing file as it matched pattern.")
-					result.Fail(match.path, "filecontent", fmt.Sprintf("Potential secret pattern : %s", detection), match.commits, detectionWithSeverity.severity)
+					result.Fail(match.path, "filecontent", detectionWithSeverity.message(detection), match.commits, detectionWithSeverity.severity)
 				}
 			}
 		}
 	}
 }
 
+// message describes a detection, naming the custom pattern that found it, if any
+func (d DetectionsWithSeverity) message(detection string) string {
+	switch {
+	case d.id != "" && d.description != "":
+		return fmt.Sprintf("Potential secret pattern %s (%s) : %s", d.id, d.description, detection)
+	case d.id != "":
+		return fmt.Sprintf("Potential secret pattern %s : %s", d.id, detection)
+	case d.description != "":
+		return fmt.Sprintf("Potential secret pattern (%s) : %s", d.description, detection)
+	}
+	return fmt.Sprintf("Potential secret pattern : %s", detection)
+}
+
+// Matches returns what the pre-configured and custom patterns find in content that is not a file of its own, such as
+// a value decoded from a file
+func (detector PatternDetector) Matches(content string) []string {
+	var matches []string
+	for _, detection := range detector.secretsPattern.check(content, severity.Low) {
+		matches = append(matches, detection.detections...)
+	}
+	return matches
+}
+
 // NewPatternDetector returns a PatternDetector that tests Additions against the pre-configured patterns
-func NewPatternDetector(custom []talismanrc.PatternString) *PatternDetector {
+func NewPatternDetector(custom []talismanrc.CustomPattern) *PatternDetector {
 	matcher := NewPatternMatcher(detectorPatterns)
 	for _, pattern := range custom {
 		matcher.add(pattern)
@@ -17,7 +17,7 @@ var defaultIgnoreEvaluator = helpers.BuildIgnoreEvaluator("default", talismanRC,
 var dummyCallback = func() {}
 
 var (
-	customPatterns []talismanrc.PatternString
+	customPatterns []talismanrc.CustomPattern
 )
 
 func ignoreEvaluatorWithTalismanRC(tRC *talismanrc.TalismanRC) helpers.IgnoreEvaluator {
@@ -52,9 +52,6 @@ func TestShouldDetectPasswordPatterns(t *testing.T) {
 
 	shouldPassDetectionOfSecretPattern(filename, []byte("<ConsumerKey>alksjdhfkjaklsdhflk12345adskjf</ConsumerKey>"), t)
 	shouldPassDetectionOfSecretPattern(filename, []byte("AWS key :"), t)
-	shouldPassDetectionOfSecretPattern(filename, []byte(`BEGIN RSA PRIVATE KEY-----
-	aghjdjadslgjagsfjlsgjalsgjaghjldasja
-	-----END RSA PRIVATE KEY`), t)
 	shouldPassDetectionOfSecretPattern(filename, []byte(`PWD=appropriate`), t)
 	shouldPassDetectionOfSecretPattern(filename, []byte(`pass=appropriate`), t)
 	shouldPassDetectionOfSecretPattern(filename, []byte(`adminpwd=appropriate`), t)
@@ -144,3 +141,16 @@ func getFailureMessage(results *helpers.DetectionResults, additions []gitrepo.Ad
 	}
 	return failureMessages[0]
 }
+
+func TestShouldReportIdAndDescriptionOfNamedCustomPattern(t *testing.T) {
+	results := helpers.NewDetectionResults()
+	filename := "deploy.sh"
+	additions := []gitrepo.Addition{gitrepo.NewAddition(filename, []byte("curl -H 'X-Acme: acme_0123456789'"))}
+	custom := []talismanrc.CustomPattern{
+		{ID: "acme-token", Description: "Acme API token", Regex: "acme_[0-9]{10}"},
+	}
+
+	NewPatternDetector(custom).Test(defaultIgnoreEvaluator, additions, talismanRC, results, dummyCallback)
+
+	assert.Equal(t, "Potential secret pattern acme-token (Acme API token) : acme_0123456789", getFailureMessage(results, additions))
+}
@@ -0,0 +1,40 @@
+package privatekey
+
+import (
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+)
+
+// KeyDetector fails the files holding private keys, whether PEM encoded, anywhere in the file, or DER encoded, and
+// PKCS#12 bundles. Certificates and public keys are not reported, whatever the name of the file.
+type KeyDetector struct{}
+
+// NewKeyDetector returns a KeyDetector
+func NewKeyDetector() *KeyDetector {
+	return &KeyDetector{}
+}
+
+// Test fails each private key found in the Additions, stating its format, type and size. Encrypted keys are reported
+// with the severity of EncryptedPrivateKey, as they are only as safe as their passphrase.
+func (kd *KeyDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	find := func(addition gitrepo.Addition) []helpers.Finding {
+		var findings []helpers.Finding
+		for _, found := range privateKeys(addition.Data) {
+			findings = append(findings, helpers.Finding{Message: "File holds " + found.String(), Severity: found.severity()})
+		}
+		return findings
+	}
+	helpers.ReportFindings("privatekey", find, comparator, currentAdditions, ignoreConfig, result, additionCompletionCallback)
+}
+
+func (k key) severity() severity.Severity {
+	switch {
+	case k.format == "PKCS#12":
+		return severity.SeverityConfiguration["PKCS12Bundle"]
+	case k.encrypted:
+		return severity.SeverityConfiguration["EncryptedPrivateKey"]
+	}
+	return severity.SeverityConfiguration["PrivateKey"]
+}
@@ -0,0 +1,314 @@
+package privatekey
+
+import (
+	"bytes"
+	"crypto/dsa"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+var (
+	pemBegin = []byte("-----BEGIN ")
+	// indentation matches the indentation of the lines of PEM blocks embedded in YAML files or source code
+	indentation = regexp.MustCompile(`(?m)^[ \t]+`)
+	// escapedNewline matches the line breaks of PEM blocks embedded in JSON or in string literals
+	escapedNewline = regexp.MustCompile(`\\(?:r\\)?n`)
+	// pemBegins and pemEnds match the first and last lines of PEM blocks that share their line with other text, such
+	// as the quotes of a string literal, so that they can be put on lines of their own
+	pemBegins = regexp.MustCompile(`([^\n])(-----BEGIN )`)
+	pemEnds   = regexp.MustCompile(`(-----END [A-Z0-9 #]+-----)([^\r\n])`)
+	// privateKeyHeader matches the first line of PEM private keys, including those that cannot be decoded, such as
+	// keys whose body was cut short
+	privateKeyHeader = regexp.MustCompile(`BEGIN ((?:[A-Z0-9]+ )*)PRIVATE KEY-----`)
+
+	// privateKeyBlock matches whole PEM private keys, whose bodies are reported by the KeyDetector rather than as
+	// encoded text
+	privateKeyBlock = regexp.MustCompile(`(?s)-----BEGIN (?:[A-Z0-9]+ )*PRIVATE KEY-----.*?-----END (?:[A-Z0-9]+ )*PRIVATE KEY-----`)
+
+	oidData       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidPBES2      = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	// oidPKCS12PBE is the arc of the password based encryption schemes of PKCS#12, such as pbeWithSHAAnd3-KeyTripleDES-CBC
+	oidPKCS12PBE = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 1}
+
+	// publicBlockTypes are the types of the PEM blocks that hold nothing that needs to be kept secret
+	publicBlockTypes = map[string]bool{
+		"CERTIFICATE":             true,
+		"TRUSTED CERTIFICATE":     true,
+		"CERTIFICATE REQUEST":     true,
+		"NEW CERTIFICATE REQUEST": true,
+		"X509 CRL":                true,
+		"PUBLIC KEY":              true,
+		"RSA PUBLIC KEY":          true,
+		"DH PARAMETERS":           true,
+		"EC PARAMETERS":           true,
+	}
+)
+
+// key describes a private key, or a PKCS#12 bundle, found in a file
+type key struct {
+	format    string
+	algorithm string
+	bits      int
+	encrypted bool
+	// undecodable is set for PEM private keys whose body cannot be decoded
+	undecodable bool
+}
+
+func (k key) String() string {
+	if k.format == "PKCS#12" {
+		return "a PKCS#12 bundle, which holds private keys along with their certificates"
+	}
+	if k.undecodable {
+		return "an undecodable PEM block of type " + k.format
+	}
+	description := "an unencrypted "
+	if k.encrypted {
+		description = "an encrypted "
+	}
+	description += k.format + " "
+	if k.algorithm != "" {
+		description += k.algorithm + " "
+	}
+	description += "private key"
+	if k.bits > 0 {
+		return fmt.Sprintf("%s of %d bits", description, k.bits)
+	}
+	if k.algorithm == "" {
+		return description + " of unknown type and size"
+	}
+	return description + " of unknown size"
+}
+
+// pkcs12 is the PFX structure of RFC 7292, which is all a PKCS#12 bundle can be told apart by without its password
+type pkcs12 struct {
+	Version  int
+	AuthSafe struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue `asn1:"tag:0,explicit,optional"`
+	}
+	MacData asn1.RawValue `asn1:"optional"`
+}
+
+// encryptedPKCS8 is the EncryptedPrivateKeyInfo structure of RFC 5958
+type encryptedPKCS8 struct {
+	Algorithm     pkix.AlgorithmIdentifier
+	EncryptedData []byte
+}
+
+// privateKeys returns the private keys held by data, either as PEM blocks, wherever they are in the file, or as the
+// DER encoding of a key or of a PKCS#12 bundle, which is the whole file
+func privateKeys(data []byte) []key {
+	if bytes.Contains(data, pemBegin) || privateKeyHeader.Match(data) {
+		var keys []key
+		decoded := map[string]int{}
+		for _, block := range pemBlocks(data) {
+			if found, ok := pemKey(block); ok {
+				keys = append(keys, found)
+				decoded[block.Type]++
+			}
+		}
+		for _, header := range privateKeyHeader.FindAllSubmatch(data, -1) {
+			blockType := string(header[1]) + "PRIVATE KEY"
+			if decoded[blockType] > 0 {
+				decoded[blockType]--
+				continue
+			}
+			keys = append(keys, undecodableKey(blockType))
+		}
+		return keys
+	}
+	if found, ok := derKey(data); ok {
+		return []key{found}
+	}
+	return nil
+}
+
+// Describe returns the description of each private key held by data, as the KeyDetector reports it, such as for values
+// decoded from a file rather than the file itself
+func Describe(data []byte) []string {
+	var descriptions []string
+	for _, found := range privateKeys(data) {
+		descriptions = append(descriptions, found.String())
+	}
+	return descriptions
+}
+
+// RemoveKeys removes the PEM private keys from content, so that their bodies are not also reported as encoded text,
+// with a severity that does not tell encrypted keys apart
+func RemoveKeys(content string) string {
+	if !strings.Contains(content, "PRIVATE KEY-----") {
+		return content
+	}
+	return privateKeyBlock.ReplaceAllString(content, "")
+}
+
+// HoldsOnlyPublicMaterial answers whether data is nothing but certificates, public keys and the like, either as PEM
+// blocks or as a DER encoded certificate, such as the .pem files of certificate authorities
+func HoldsOnlyPublicMaterial(data []byte) bool {
+	if !bytes.Contains(data, pemBegin) {
+		_, err := x509.ParseCertificate(data)
+		return len(data) > 0 && err == nil
+	}
+	if bytes.Contains(data, []byte("PRIVATE KEY")) {
+		return false
+	}
+	blocks := pemBlocks(data)
+	for _, block := range blocks {
+		if !publicBlockTypes[block.Type] {
+			return false
+		}
+	}
+	return len(blocks) > 0
+}
+
+func pemBlocks(data []byte) []*pem.Block {
+	var blocks []*pem.Block
+	rest := escapedNewline.ReplaceAll(data, []byte("\n"))
+	rest = pemEnds.ReplaceAll(pemBegins.ReplaceAll(rest, []byte("$1\n$2")), []byte("$1\n$2"))
+	rest = indentation.ReplaceAll(rest, nil)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return blocks
+		}
+		blocks = append(blocks, block)
+	}
+}
+
+func pemKey(block *pem.Block) (key, bool) {
+	encrypted := strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED")
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		found := key{format: "PKCS#1", algorithm: "RSA", encrypted: encrypted}
+		if parsed, err := x509.ParsePKCS1PrivateKey(block.Bytes); !encrypted && err == nil {
+			found.bits = parsed.N.BitLen()
+		}
+		return found, true
+	case "EC PRIVATE KEY":
+		found := key{format: "SEC 1", algorithm: "EC", encrypted: encrypted}
+		if parsed, err := x509.ParseECPrivateKey(block.Bytes); !encrypted && err == nil {
+			found.algorithm, found.bits = describe(parsed)
+		}
+		return found, true
+	case "DSA PRIVATE KEY":
+		found := key{format: "OpenSSL", algorithm: "DSA", encrypted: encrypted}
+		if parsed, err := ssh.ParseDSAPrivateKey(block.Bytes); !encrypted && err == nil {
+			found.bits = parsed.P.BitLen()
+		}
+		return found, true
+	case "PRIVATE KEY":
+		found := key{format: "PKCS#8"}
+		if parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+			found.algorithm, found.bits = describe(parsed)
+		}
+		return found, true
+	case "ENCRYPTED PRIVATE KEY":
+		return key{format: "PKCS#8", encrypted: true}, true
+	case "OPENSSH PRIVATE KEY":
+		return openSSHKey(block), true
+	case "PKCS12":
+		return key{format: "PKCS#12"}, true
+	}
+	if strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		return key{format: strings.TrimSuffix(block.Type, " PRIVATE KEY"), encrypted: encrypted}, true
+	}
+	return key{}, false
+}
+
+// undecodableKey describes a private key whose PEM block cannot be decoded, by the type of the block alone
+func undecodableKey(blockType string) key {
+	return key{format: blockType, encrypted: strings.HasPrefix(blockType, "ENCRYPTED "), undecodable: true}
+}
+
+// openSSHKey describes a key in the format of ssh-keygen, whose public key is left unencrypted even when the private
+// key is encrypted with a passphrase
+func openSSHKey(block *pem.Block) key {
+	found := key{format: "OpenSSH"}
+	parsed, err := ssh.ParseRawPrivateKey(pem.EncodeToMemory(block))
+	if err == nil {
+		found.algorithm, found.bits = describe(parsed)
+		return found
+	}
+	var missingPassphrase *ssh.PassphraseMissingError
+	if errors.As(err, &missingPassphrase) {
+		found.encrypted = true
+		if public, ok := missingPassphrase.PublicKey.(ssh.CryptoPublicKey); ok {
+			found.algorithm, found.bits = describe(public.CryptoPublicKey())
+		}
+		if found.algorithm == "" {
+			found.algorithm = missingPassphrase.PublicKey.Type()
+		}
+	}
+	return found
+}
+
+// derKey answers whether data is the DER encoding of a private key or of a PKCS#12 bundle
+func derKey(data []byte) (key, bool) {
+	if len(data) == 0 || data[0] != 0x30 {
+		return key{}, false
+	}
+	var bundle pkcs12
+	if rest, err := asn1.Unmarshal(data, &bundle); err == nil && len(rest) == 0 && bundle.Version == 3 &&
+		(bundle.AuthSafe.ContentType.Equal(oidData) || bundle.AuthSafe.ContentType.Equal(oidSignedData)) {
+		return key{format: "PKCS#12"}, true
+	}
+	if parsed, err := x509.ParsePKCS8PrivateKey(data); err == nil {
+		algorithm, bits := describe(parsed)
+		return key{format: "PKCS#8", algorithm: algorithm, bits: bits}, true
+	}
+	if parsed, err := x509.ParsePKCS1PrivateKey(data); err == nil {
+		return key{format: "PKCS#1", algorithm: "RSA", bits: parsed.N.BitLen()}, true
+	}
+	if parsed, err := x509.ParseECPrivateKey(data); err == nil {
+		algorithm, bits := describe(parsed)
+		return key{format: "SEC 1", algorithm: algorithm, bits: bits}, true
+	}
+	var encrypted encryptedPKCS8
+	if rest, err := asn1.Unmarshal(data, &encrypted); err == nil && len(rest) == 0 {
+		scheme := encrypted.Algorithm.Algorithm
+		if scheme.Equal(oidPBES2) || (len(scheme) == len(oidPKCS12PBE)+1 && scheme[:len(oidPKCS12PBE)].Equal(oidPKCS12PBE)) {
+			return key{format: "PKCS#8", encrypted: true}, true
+		}
+	}
+	return key{}, false
+}
+
+// describe returns the algorithm and the size of a private key, or of the public key of an encrypted private key
+func describe(parsed interface{}) (string, int) {
+	switch parsed := parsed.(type) {
+	case *rsa.PrivateKey:
+		return "RSA", parsed.N.BitLen()
+	case *rsa.PublicKey:
+		return "RSA", parsed.N.BitLen()
+	case *ecdsa.PrivateKey:
+		return "EC " + parsed.Curve.Params().Name, parsed.Curve.Params().BitSize
+	case *ecdsa.PublicKey:
+		return "EC " + parsed.Curve.Params().Name, parsed.Curve.Params().BitSize
+	case ed25519.PrivateKey, *ed25519.PrivateKey, ed25519.PublicKey:
+		return "Ed25519", 256
+	case *ecdh.PrivateKey:
+		if parsed.Curve() == ecdh.X25519() {
+			return "X25519", 256
+		}
+		return "ECDH", 0
+	case *dsa.PrivateKey:
+		return "DSA", parsed.P.BitLen()
+	case *dsa.PublicKey:
+		return "DSA", parsed.P.BitLen()
+	}
+	return "", 0
+}
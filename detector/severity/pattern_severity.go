@@ -5,8 +5,8 @@ import (
 )
 
 type PatternSeverity struct {
-	Pattern  *regexp.Regexp
-	Severity Severity
+	ID          string
+	Description string
+	Pattern     *regexp.Regexp
+	Severity    Severity
 }
-
-
@@ -5,8 +5,9 @@ var SeverityConfiguration = map[string]Severity{
 	"ConsumerSecretParrern":     High,
 	"AWSKeyPattern":             High,
 	"AWSSecretPattern":          High,
-	"RSAKeyPattern":             High,
 	"DSAFile":                   High,
+	"Ed25519File":               High,
+	"ECDSAFile":                 High,
 	"PrivateKeyFile":            High,
 	"PemFile":                   High,
 	"PpkFile":                   High,
@@ -27,9 +28,14 @@ var SeverityConfiguration = map[string]Severity{
 	"JenkinsPublishOverSSHFile": High,
 	"Base64Content":             High,
 	"HexContent":                High,
+	"KubernetesSecret":          High,
+	"TerraformState":            High,
+	"PrivateKey":                High,
+	"PKCS12Bundle":              High,
 	"s3Config":                  Medium,
 	"OpenVPNFile":               Medium,
 	"DatabaseYml":               Medium,
+	"EncryptedPrivateKey":       Medium,
 	"ShellHistory":              Low,
 	"ASCFile":                   Low,
 	"KDBFile":                   Low,
@@ -0,0 +1,281 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+)
+
+// maxReportedAttributes is the number of sensitive attributes reported for each file, beyond which they are counted
+const maxReportedAttributes = 20
+
+var (
+	// stateFileName matches state files, including the backups Terraform keeps, such as terraform.tfstate.backup and
+	// terraform.tfstate.1700000000.backup, and those of workspaces in terraform.tfstate.d
+	stateFileName = regexp.MustCompile(`\.tfstate($|\.)`)
+	planFileName  = regexp.MustCompile(`\.tfplan$`)
+)
+
+// StateDetector fails Terraform state files and plans, which hold every attribute of the resources Terraform manages
+// in plain text, such as the credentials of providers and generated passwords, whatever their content looks like
+type StateDetector struct{}
+
+// NewStateDetector returns a StateDetector
+func NewStateDetector() *StateDetector {
+	return &StateDetector{}
+}
+
+type state struct {
+	TerraformVersion string          `json:"terraform_version"`
+	Lineage          string          `json:"lineage"`
+	Resources        []stateResource `json:"resources"`
+	Outputs          map[string]struct {
+		Sensitive bool `json:"sensitive"`
+	} `json:"outputs"`
+}
+
+type stateResource struct {
+	Module    string `json:"module"`
+	Mode      string `json:"mode"`
+	Type      string `json:"type"`
+	Name      string `json:"name"`
+	Instances []struct {
+		IndexKey            interface{}  `json:"index_key"`
+		SensitiveAttributes [][]pathStep `json:"sensitive_attributes"`
+	} `json:"instances"`
+}
+
+// pathStep is a step of the path to a sensitive attribute, either the name of an attribute or an index into a
+// collection, such as {"type": "index", "value": {"value": 0, "type": "number"}}
+type pathStep struct {
+	Type  string          `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+type plan struct {
+	FormatVersion   string `json:"format_version"`
+	ResourceChanges []struct {
+		Address string `json:"address"`
+		Change  struct {
+			BeforeSensitive interface{} `json:"before_sensitive"`
+			AfterSensitive  interface{} `json:"after_sensitive"`
+		} `json:"change"`
+	} `json:"resource_changes"`
+	OutputChanges map[string]struct {
+		BeforeSensitive interface{} `json:"before_sensitive"`
+		AfterSensitive  interface{} `json:"after_sensitive"`
+	} `json:"output_changes"`
+	PlannedValues json.RawMessage `json:"planned_values"`
+	Configuration struct {
+		RootModule struct {
+			Variables map[string]struct {
+				Sensitive bool `json:"sensitive"`
+			} `json:"variables"`
+		} `json:"root_module"`
+	} `json:"configuration"`
+}
+
+// Test fails each state file and plan, naming the attributes, outputs and variables Terraform marked as sensitive
+func (sd *StateDetector) Test(comparator helpers.IgnoreEvaluator, currentAdditions []gitrepo.Addition, ignoreConfig *talismanrc.TalismanRC, result *helpers.DetectionResults, additionCompletionCallback func()) {
+	stateSeverity := severity.SeverityConfiguration["TerraformState"]
+	find := func(addition gitrepo.Addition) []helpers.Finding {
+		var findings []helpers.Finding
+		for _, message := range inspect(addition) {
+			findings = append(findings, helpers.Finding{Message: message, Severity: stateSeverity})
+		}
+		return findings
+	}
+	helpers.ReportFindings("terraform", find, comparator, currentAdditions, ignoreConfig, result, additionCompletionCallback)
+}
+
+// inspect returns why the addition must not be committed if it is a state file or a plan, recognised by its name or
+// its structure, or nothing otherwise
+func inspect(addition gitrepo.Addition) []string {
+	name := string(addition.Name)
+	if isBinaryPlan(addition.Data) {
+		return []string{"Terraform plan holds the prior state and the planned values of every resource, which can include credentials"}
+	}
+	if isJSONObject(addition.Data) {
+		var parsedState state
+		var parsedPlan plan
+		if json.Unmarshal(addition.Data, &parsedState) == nil && parsedState.TerraformVersion != "" && parsedState.Lineage != "" {
+			return messages("Terraform state", parsedState.sensitiveAttributes())
+		}
+		if json.Unmarshal(addition.Data, &parsedPlan) == nil && parsedPlan.FormatVersion != "" &&
+			(parsedPlan.ResourceChanges != nil || parsedPlan.PlannedValues != nil) {
+			return messages("Terraform plan", parsedPlan.sensitiveAttributes())
+		}
+	}
+	if stateFileName.MatchString(name) {
+		return messages("Terraform state", nil)
+	}
+	if planFileName.MatchString(name) {
+		return messages("Terraform plan", nil)
+	}
+	return nil
+}
+
+func messages(kind string, sensitive []string) []string {
+	messages := []string{fmt.Sprintf("%s holds every attribute of the resources it manages in plain text, "+
+		"which can include credentials, and should be kept in a remote backend instead", kind)}
+	for i, attribute := range sensitive {
+		if i == maxReportedAttributes {
+			messages = append(messages, fmt.Sprintf("%s holds %d more sensitive attributes", kind, len(sensitive)-i))
+			break
+		}
+		messages = append(messages, fmt.Sprintf("%s holds sensitive %s", kind, attribute))
+	}
+	return messages
+}
+
+// isJSONObject answers whether data could be the JSON of a state or a plan, before it is parsed
+func isJSONObject(data []byte) bool {
+	trimmed := bytes.TrimSpace(data)
+	return bytes.HasPrefix(trimmed, []byte("{")) &&
+		(bytes.Contains(trimmed, []byte(`"terraform_version"`)) || bytes.Contains(trimmed, []byte(`"format_version"`)))
+}
+
+// isBinaryPlan answers whether data is a plan saved by terraform plan -out, which is a zip archive holding the plan
+// along with the prior state
+func isBinaryPlan(data []byte) bool {
+	if !bytes.HasPrefix(data, []byte("PK\x03\x04")) {
+		return false
+	}
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return false
+	}
+	for _, file := range archive.File {
+		if file.Name == "tfplan" {
+			return true
+		}
+	}
+	return false
+}
+
+func (s state) sensitiveAttributes() []string {
+	var attributes []string
+	for _, resource := range s.Resources {
+		address := resource.Type + "." + resource.Name
+		if resource.Mode == "data" {
+			address = "data." + address
+		}
+		if resource.Module != "" {
+			address = resource.Module + "." + address
+		}
+		for _, instance := range resource.Instances {
+			instanceAddress := address + indexSuffix(instance.IndexKey)
+			for _, path := range instance.SensitiveAttributes {
+				attributes = append(attributes, "attribute "+instanceAddress+pathString(path))
+			}
+		}
+	}
+	var outputs []string
+	for name, output := range s.Outputs {
+		if output.Sensitive {
+			outputs = append(outputs, "output "+name)
+		}
+	}
+	sort.Strings(outputs)
+	return append(attributes, outputs...)
+}
+
+func (p plan) sensitiveAttributes() []string {
+	var attributes []string
+	for _, resource := range p.ResourceChanges {
+		seen := map[string]bool{}
+		for _, marks := range []interface{}{resource.Change.BeforeSensitive, resource.Change.AfterSensitive} {
+			for _, path := range sensitivePaths(marks, "") {
+				if !seen[path] {
+					seen[path] = true
+					attributes = append(attributes, "attribute "+resource.Address+path)
+				}
+			}
+		}
+	}
+	var outputs, variables []string
+	for name, change := range p.OutputChanges {
+		if change.BeforeSensitive == true || change.AfterSensitive == true {
+			outputs = append(outputs, "output "+name)
+		}
+	}
+	for name, variable := range p.Configuration.RootModule.Variables {
+		if variable.Sensitive {
+			variables = append(variables, "variable "+name)
+		}
+	}
+	sort.Strings(outputs)
+	sort.Strings(variables)
+	return append(append(attributes, outputs...), variables...)
+}
+
+// sensitivePaths returns the paths of the values marked as sensitive in the before_sensitive or after_sensitive of
+// a change in a plan, which mirror the values with true where they are sensitive
+func sensitivePaths(marks interface{}, path string) []string {
+	switch marks := marks.(type) {
+	case bool:
+		if marks {
+			return []string{path}
+		}
+	case map[string]interface{}:
+		var paths []string
+		for _, key := range sortedKeys(marks) {
+			paths = append(paths, sensitivePaths(marks[key], path+"."+key)...)
+		}
+		return paths
+	case []interface{}:
+		var paths []string
+		for i, element := range marks {
+			paths = append(paths, sensitivePaths(element, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return paths
+	}
+	return nil
+}
+
+func pathString(path []pathStep) string {
+	var builder strings.Builder
+	for _, step := range path {
+		switch step.Type {
+		case "get_attr":
+			var name string
+			json.Unmarshal(step.Value, &name)
+			builder.WriteString("." + name)
+		case "index":
+			var index struct {
+				Value interface{} `json:"value"`
+			}
+			json.Unmarshal(step.Value, &index)
+			builder.WriteString(indexSuffix(index.Value))
+		}
+	}
+	return builder.String()
+}
+
+func indexSuffix(key interface{}) string {
+	switch key := key.(type) {
+	case nil:
+		return ""
+	case string:
+		return fmt.Sprintf("[%q]", key)
+	default:
+		return fmt.Sprintf("[%v]", key)
+	}
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,215 @@
+package terraform
+
+import (
+	"archive/zip"
+	"bytes"
+	"talisman/detector/detector_testing"
+	"talisman/detector/severity"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var talismanRC = &talismanrc.TalismanRC{}
+
+const stateMessage = "Terraform state holds every attribute of the resources it manages in plain text, " +
+	"which can include credentials, and should be kept in a remote backend instead"
+
+const planMessage = "Terraform plan holds every attribute of the resources it manages in plain text, " +
+	"which can include credentials, and should be kept in a remote backend instead"
+
+const stateJSON = `{
+  "version": 4,
+  "terraform_version": "1.6.6",
+  "serial": 12,
+  "lineage": "3f4a9c1e-8f6b-2d7e-0a5c-9b1d2e3f4a5b",
+  "outputs": {
+    "endpoint": {"value": "db.internal", "type": "string"},
+    "admin_password": {"value": "hunter2", "type": "string", "sensitive": true}
+  },
+  "resources": [
+    {
+      "mode": "managed",
+      "type": "aws_db_instance",
+      "name": "main",
+      "provider": "provider[\"registry.terraform.io/hashicorp/aws\"]",
+      "instances": [
+        {
+          "schema_version": 2,
+          "attributes": {"identifier": "main", "password": "hunter2"},
+          "sensitive_attributes": [[{"type": "get_attr", "value": "password"}]]
+        }
+      ]
+    },
+    {
+      "module": "module.cache",
+      "mode": "managed",
+      "type": "random_password",
+      "name": "auth",
+      "instances": [
+        {
+          "index_key": "primary",
+          "attributes": {"result": "s3cr3t"},
+          "sensitive_attributes": [
+            [{"type": "get_attr", "value": "result"}],
+            [{"type": "get_attr", "value": "keepers"}, {"type": "index", "value": {"value": 0, "type": "number"}}]
+          ]
+        }
+      ]
+    },
+    {
+      "mode": "data",
+      "type": "aws_secretsmanager_secret_version",
+      "name": "api",
+      "instances": [{"attributes": {"secret_string": "token"}, "sensitive_attributes": []}]
+    }
+  ]
+}`
+
+const planJSON = `{
+  "format_version": "1.2",
+  "terraform_version": "1.6.6",
+  "variables": {"db_password": {"value": "hunter2"}},
+  "planned_values": {"root_module": {}},
+  "resource_changes": [
+    {
+      "address": "aws_db_instance.main",
+      "change": {
+        "actions": ["update"],
+        "before": {"password": "hunter1", "tags": {"team": "db"}},
+        "after": {"password": "hunter2", "tags": {"team": "db"}},
+        "before_sensitive": {"password": true, "tags": {}},
+        "after_sensitive": {"password": true, "tags": {"team": false}, "users": [false, true]}
+      }
+    }
+  ],
+  "output_changes": {
+    "admin_password": {"before_sensitive": true, "after_sensitive": true},
+    "endpoint": {"before_sensitive": false, "after_sensitive": false}
+  },
+  "configuration": {
+    "root_module": {
+      "variables": {"db_password": {"sensitive": true}, "region": {"default": "eu-west-1"}}
+    }
+  }
+}`
+
+func TestShouldFailStateFilesNamingTheirSensitiveAttributes(t *testing.T) {
+	results := detector_testing.ResultsOf(NewStateDetector(), "infra/terraform.tfstate", []byte(stateJSON), talismanRC)
+
+	assert.True(t, results.HasFailures(), "Expected a state file to fail")
+	assert.Equal(t, []string{
+		stateMessage,
+		"Terraform state holds sensitive attribute aws_db_instance.main.password",
+		`Terraform state holds sensitive attribute module.cache.random_password.auth["primary"].result`,
+		`Terraform state holds sensitive attribute module.cache.random_password.auth["primary"].keepers[0]`,
+		"Terraform state holds sensitive output admin_password",
+	}, detector_testing.FailureMessagesOf(results, "infra/terraform.tfstate"))
+}
+
+func TestShouldFailStateFilesWhateverTheirName(t *testing.T) {
+	results := detector_testing.ResultsOf(NewStateDetector(), "backup/prod.json", []byte(stateJSON), talismanRC)
+
+	assert.True(t, results.HasFailures(), "Expected a state file to be recognised by its structure")
+}
+
+func TestShouldFailJSONPlansNamingTheirSensitiveAttributesOutputsAndVariables(t *testing.T) {
+	results := detector_testing.ResultsOf(NewStateDetector(), "plan.json", []byte(planJSON), talismanRC)
+
+	assert.Equal(t, []string{
+		planMessage,
+		"Terraform plan holds sensitive attribute aws_db_instance.main.password",
+		"Terraform plan holds sensitive attribute aws_db_instance.main.users[1]",
+		"Terraform plan holds sensitive output admin_password",
+		"Terraform plan holds sensitive variable db_password",
+	}, detector_testing.FailureMessagesOf(results, "plan.json"))
+}
+
+func TestShouldFailStateBackupsAndPlansByTheirName(t *testing.T) {
+	for _, fileName := range []string{"terraform.tfstate.backup", "terraform.tfstate.1700000000.backup",
+		"terraform.tfstate.d/staging/terraform.tfstate"} {
+		results := detector_testing.ResultsOf(NewStateDetector(), fileName, []byte("not readable"), talismanRC)
+		assert.Equal(t, []string{stateMessage}, detector_testing.FailureMessagesOf(results, fileName), fileName)
+	}
+	results := detector_testing.ResultsOf(NewStateDetector(), "release.tfplan", []byte("not readable"), talismanRC)
+	assert.Equal(t, []string{planMessage}, detector_testing.FailureMessagesOf(results, "release.tfplan"))
+}
+
+func TestShouldFailPlansSavedByTerraform(t *testing.T) {
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, name := range []string{"tfplan", "tfstate", "tfconfig/main.tf"} {
+		file, err := writer.Create(name)
+		if !assert.NoError(t, err) {
+			return
+		}
+		file.Write([]byte("content"))
+	}
+	if !assert.NoError(t, writer.Close()) {
+		return
+	}
+
+	results := detector_testing.ResultsOf(NewStateDetector(), "out", archive.Bytes(), talismanRC)
+
+	assert.Equal(t, []string{"Terraform plan holds the prior state and the planned values of every resource, " +
+		"which can include credentials"}, detector_testing.FailureMessagesOf(results, "out"))
+}
+
+func TestShouldReportTheNumberOfSensitiveAttributesBeyondTheFirstOnes(t *testing.T) {
+	var sensitive []string
+	for i := 0; i < maxReportedAttributes+3; i++ {
+		sensitive = append(sensitive, "output value")
+	}
+
+	reported := messages("Terraform state", sensitive)
+
+	assert.Len(t, reported, maxReportedAttributes+2)
+	assert.Equal(t, "Terraform state holds 3 more sensitive attributes", reported[len(reported)-1])
+}
+
+func TestShouldNotFlagOtherFiles(t *testing.T) {
+	for fileName, content := range map[string]string{
+		"package.json":           `{"name": "app", "version": "1.0.0"}`,
+		"schema.json":            `{"format_version": "1.0", "fields": []}`,
+		".terraform.lock.hcl":    `provider "registry.terraform.io/hashicorp/aws" {}`,
+		"main.tf":                `resource "aws_db_instance" "main" {}`,
+		"docs/tfstate-remote.md": "Keep the terraform_version and lineage of the state in a remote backend",
+	} {
+		results := detector_testing.ResultsOf(NewStateDetector(), fileName, []byte(content), talismanRC)
+		assert.False(t, results.HasFailures(), fileName)
+	}
+}
+
+func TestShouldWarnAboutStateFilesBelowThreshold(t *testing.T) {
+	configured := severity.SeverityConfiguration["TerraformState"]
+	defer func() { severity.SeverityConfiguration["TerraformState"] = configured }()
+	severity.SeverityConfiguration["TerraformState"] = severity.Medium
+
+	results := detector_testing.ResultsOf(NewStateDetector(), "terraform.tfstate", []byte(stateJSON), &talismanrc.TalismanRC{Threshold: severity.High})
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasWarnings())
+}
+
+func TestShouldNotFlagStateFilesIgnoredForTheTerraformDetector(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{FileName: "terraform.tfstate", IgnoreDetectors: []string{"terraform"}}},
+	}
+	results := detector_testing.ResultsOf(NewStateDetector(), "terraform.tfstate", []byte(stateJSON), tRC)
+
+	assert.False(t, results.HasFailures())
+	assert.True(t, results.HasIgnores())
+}
+
+func TestShouldReportStateFilesUnderTheTerraformDetectorRatherThanFileContent(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{
+		FileIgnoreConfig: []talismanrc.FileIgnoreConfig{{FileName: "terraform.tfstate", Checksum: "outdated", IgnoreDetectors: []string{"filecontent"}}},
+	}
+	results := detector_testing.ResultsOf(NewStateDetector(), "terraform.tfstate", []byte(stateJSON), tRC)
+
+	assert.True(t, results.HasFailures(), "Expected ignoring the filecontent detector to leave state files reported")
+	for _, failure := range results.GetFailures("terraform.tfstate") {
+		assert.Equal(t, "terraform", failure.Category)
+	}
+}
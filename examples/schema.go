@@ -0,0 +1,8 @@
+package examples
+
+import _ "embed"
+
+// TalismanRCSchema is the JSON schema describing a valid .talismanrc file
+//
+//go:embed schema-store-talismanrc.json
+var TalismanRCSchema []byte
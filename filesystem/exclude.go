@@ -0,0 +1,137 @@
+package filesystem
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/bmatcuk/doublestar"
+)
+
+// IgnoreFileName is the name of the files whose exclude patterns are honoured in each directory that is walked
+const IgnoreFileName = ".gitignore"
+
+// Excludes decides which files and directories are left out, using patterns written as in .gitignore files.
+// Like git, the last pattern that matches a path decides whether it is excluded, so a pattern starting with ! can
+// include again what an earlier pattern excluded, and patterns read later take precedence.
+type Excludes struct {
+	patterns []excludePattern
+}
+
+type excludePattern struct {
+	// base is the directory the pattern is relative to, such as the directory of the .gitignore file it was read from
+	base     string
+	pattern  string
+	negated  bool
+	dirOnly  bool
+	anchored bool
+}
+
+// NewExcludes returns Excludes for patterns relative to the base directory, such as those given on the command line
+func NewExcludes(base string, patterns ...string) *Excludes {
+	excludes := &Excludes{}
+	excludes.Add(base, patterns...)
+	return excludes
+}
+
+// Add adds patterns relative to the base directory, which take precedence over the patterns added before
+func (e *Excludes) Add(base string, patterns ...string) {
+	for _, line := range patterns {
+		if pattern, ok := parseExcludePattern(base, line); ok {
+			e.patterns = append(e.patterns, pattern)
+		}
+	}
+}
+
+// AddFile adds the patterns of an exclude file, such as a .gitignore, relative to the directory holding it.
+// A missing file adds nothing.
+func (e *Excludes) AddFile(filePath string) error {
+	contents, err := os.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	e.Add(path.Dir(filePath), lines...)
+	return scanner.Err()
+}
+
+// Excluded reports whether the file or directory at filePath is excluded. Nil Excludes exclude nothing.
+func (e *Excludes) Excluded(filePath string, isDir bool) bool {
+	if e == nil {
+		return false
+	}
+	filePath = path.Clean(filePath)
+	for i := len(e.patterns) - 1; i >= 0; i-- {
+		if e.patterns[i].matches(filePath, isDir) {
+			return !e.patterns[i].negated
+		}
+	}
+	return false
+}
+
+// ExcludedFile reports whether a file, or any of the directories holding it, is excluded. It is for files that are
+// listed rather than walked, such as those of an archive, where excluded directories are not skipped on the way.
+func (e *Excludes) ExcludedFile(filePath string) bool {
+	filePath = path.Clean(filePath)
+	for dir := path.Dir(filePath); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if e.Excluded(dir, true) {
+			return true
+		}
+	}
+	return e.Excluded(filePath, false)
+}
+
+// parseExcludePattern reads a line of an exclude file. Blank lines and comments hold no pattern, and a backslash
+// keeps a leading # or ! from being read as a comment or a negation.
+func parseExcludePattern(base string, line string) (excludePattern, bool) {
+	line = strings.TrimRight(strings.TrimSuffix(line, "\r"), " ")
+	if line == "" || strings.HasPrefix(line, "#") {
+		return excludePattern{}, false
+	}
+	pattern := excludePattern{base: path.Clean(base)}
+	if strings.HasPrefix(line, "!") {
+		pattern.negated, line = true, line[1:]
+	} else if strings.HasPrefix(line, `\#`) || strings.HasPrefix(line, `\!`) {
+		line = line[1:]
+	}
+	if strings.HasSuffix(line, "/") {
+		pattern.dirOnly, line = true, strings.TrimSuffix(line, "/")
+	}
+	// a pattern holding a slash anywhere but at its end is relative to its base, and otherwise matches at any depth
+	pattern.anchored = strings.Contains(line, "/")
+	pattern.pattern = strings.TrimPrefix(line, "/")
+	return pattern, pattern.pattern != ""
+}
+
+func (p excludePattern) matches(filePath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+	relativePath, ok := relativeTo(p.base, filePath)
+	if !ok {
+		return false
+	}
+	if !p.anchored {
+		relativePath = path.Base(relativePath)
+	}
+	matched, _ := doublestar.Match(p.pattern, relativePath)
+	return matched
+}
+
+// relativeTo returns the path of filePath within the base directory, if it is inside of it
+func relativeTo(base string, filePath string) (string, bool) {
+	if base == "." {
+		return filePath, !strings.HasPrefix(filePath, "../") && filePath != ".."
+	}
+	relativePath, found := strings.CutPrefix(filePath, base+"/")
+	return relativePath, found
+}
@@ -0,0 +1,64 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludesMatchPatternsAsGitDoes(t *testing.T) {
+	excludes := NewExcludes(".",
+		"# a comment",
+		"",
+		"*.log",
+		"!keep.log",
+		"build/",
+		"/root-only.txt",
+		"docs/*.md",
+		"**/generated/**",
+		`\#hash-file`,
+	)
+
+	excluded := map[string]bool{
+		"debug.log":                 true,
+		"some-dir/debug.log":        true,
+		"some-dir/keep.log":         false,
+		"root-only.txt":             true,
+		"some-dir/root-only.txt":    false,
+		"docs/readme.md":            true,
+		"docs/nested/readme.md":     false,
+		"src/generated/file.go":     true,
+		"#hash-file":                true,
+		"some-dir/file-to-scan.txt": false,
+	}
+	for filePath, expected := range excluded {
+		assert.Equal(t, expected, excludes.Excluded(filePath, false), "Unexpected exclusion of %s", filePath)
+	}
+	assert.True(t, excludes.Excluded("some-dir/build", true), "Expected build directories to be excluded")
+	assert.False(t, excludes.Excluded("some-dir/build", false), "Expected files named build not to be excluded")
+}
+
+func TestExcludesReadFromAFileApplyWithinItsDirectory(t *testing.T) {
+	directory := filepath.ToSlash(t.TempDir())
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, IgnoreFileName), []byte("/secrets.txt\n*.tmp\n"), 0600))
+	excludes := &Excludes{}
+
+	assert.NoError(t, excludes.AddFile(directory+"/"+IgnoreFileName))
+	assert.NoError(t, excludes.AddFile(directory+"/missing/"+IgnoreFileName))
+
+	assert.True(t, excludes.Excluded(directory+"/secrets.txt", false))
+	assert.False(t, excludes.Excluded(directory+"/nested/secrets.txt", false))
+	assert.True(t, excludes.Excluded(directory+"/nested/file.tmp", false))
+	assert.False(t, excludes.Excluded("elsewhere/file.tmp", false), "Expected patterns not to apply outside of the directory of the file")
+}
+
+func TestExcludedFilesIncludeThoseInExcludedDirectories(t *testing.T) {
+	excludes := NewExcludes(".", "node_modules/", "/usr/share")
+
+	assert.True(t, excludes.ExcludedFile("app/node_modules/left-pad/index.js"))
+	assert.True(t, excludes.ExcludedFile("usr/share/doc/README"))
+	assert.False(t, excludes.ExcludedFile("usr/local/share/doc/README"))
+	assert.False(t, excludes.ExcludedFile("app/index.js"))
+}
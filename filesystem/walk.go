@@ -0,0 +1,59 @@
+package filesystem
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"talisman/gitrepo"
+	"talisman/utility"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Walk reads the files in the directory tree at root, without git. Files excluded by the .gitignore files in the tree,
+// or by the given excludes, are left out, and so are the .git directories of repositories within it. Files are given
+// by their path from the current directory, starting with root.
+func Walk(root string, excludes *Excludes) ([]gitrepo.Addition, error) {
+	ignored := &Excludes{}
+	var additions []gitrepo.Addition
+	err := filepath.WalkDir(root, func(osPath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		filePath := filepath.ToSlash(osPath)
+		if filePath != filepath.ToSlash(root) && isExcluded(filePath, entry.IsDir(), ignored, excludes) {
+			log.Debugf("skipping excluded %s", filePath)
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			// the patterns of a .gitignore apply within its directory, which is walked before anything else
+			return ignored.AddFile(path.Join(filePath, IgnoreFileName))
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		data, err := utility.SafeReadFile(osPath)
+		if err != nil {
+			log.Warnf("Error reading file: %s. Skipping", filePath)
+			return nil
+		}
+		additions = append(additions, gitrepo.NewAddition(filePath, data))
+		return nil
+	})
+	return additions, err
+}
+
+func isExcluded(filePath string, isDir bool, excludes ...*Excludes) bool {
+	if isDir && path.Base(filePath) == ".git" {
+		return true
+	}
+	for _, exclude := range excludes {
+		if exclude.Excluded(filePath, isDir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,73 @@
+package filesystem
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"talisman/gitrepo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWalkReadsTheFilesThatAreNotExcluded(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		".gitignore":                "*.log\nnode_modules/\n",
+		"app.yml":                   "password: hunter2",
+		"debug.log":                 "excluded by .gitignore",
+		"node_modules/lib/index.js": "excluded directory",
+		"dist/.gitignore":           "!important.log\n*.map\n",
+		"dist/bundle.js":            "bundle",
+		"dist/bundle.js.map":        "excluded by the nested .gitignore",
+		"dist/important.log":        "included again by the nested .gitignore",
+		"dist/vendor.min.js":        "excluded on the command line",
+		".git/config":               "never read",
+	})
+
+	additions, err := Walk(root, NewExcludes(root, "*.min.js"))
+
+	assert.NoError(t, err)
+	root = filepath.ToSlash(root)
+	assert.ElementsMatch(t, []gitrepo.FilePath{
+		gitrepo.FilePath(root + "/.gitignore"),
+		gitrepo.FilePath(root + "/app.yml"),
+		gitrepo.FilePath(root + "/dist/.gitignore"),
+		gitrepo.FilePath(root + "/dist/bundle.js"),
+		gitrepo.FilePath(root + "/dist/important.log"),
+	}, pathsOf(additions))
+	for _, addition := range additions {
+		if addition.Name == "app.yml" {
+			assert.Equal(t, "password: hunter2", string(addition.Data))
+		}
+	}
+}
+
+func TestWalkGivesPathsFromTheCurrentDirectory(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"some-dir/file.txt": "contents"})
+	wd, _ := os.Getwd()
+	os.Chdir(root)
+	defer os.Chdir(wd)
+
+	additions, err := Walk("some-dir", nil)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []gitrepo.FilePath{"some-dir/file.txt"}, pathsOf(additions))
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	for name, contents := range files {
+		filePath := filepath.Join(root, filepath.FromSlash(name))
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filePath), 0700))
+		assert.NoError(t, os.WriteFile(filePath, []byte(contents), 0600))
+	}
+}
+
+func pathsOf(additions []gitrepo.Addition) []gitrepo.FilePath {
+	var paths []gitrepo.FilePath
+	for _, addition := range additions {
+		paths = append(paths, addition.Path)
+	}
+	return paths
+}
@@ -30,9 +30,14 @@ func DoInTempGitRepo(gitOperation GitOperation) {
 
 // Init creates a GitTesting based in a temporary directory
 func Init() *GitTesting {
+	return initAt(tempPath())
+}
+
+// tempPath returns a new path in the temporary directory, which is not created
+func tempPath() string {
 	fs := afero.NewMemMapFs()
 	path, _ := afero.TempDir(fs, afero.GetTempDir(fs, "talisman-test"), "")
-	return initAt(path)
+	return path
 }
 
 // initAt creates a GitTesting based at the specified path
@@ -41,13 +46,18 @@ func initAt(gitRoot string) *GitTesting {
 	testingRepo := &GitTesting{gitRoot}
 	output := testingRepo.execCommand("git", "init", ".")
 	logrus.Debugf("Git init result %v", string(output))
-	testingRepo.execCommand("git", "config", "user.email", "talisman-test-user@example.com")
-	testingRepo.execCommand("git", "config", "user.name", "Talisman Test User")
-	testingRepo.execCommand("git", "config", "commit.gpgsign", "false")
-	testingRepo.removeHooks()
+	testingRepo.configure()
 	return testingRepo
 }
 
+// configure sets the author of commits and removes hooks that could interfere with tests
+func (git *GitTesting) configure() {
+	git.execCommand("git", "config", "user.email", "talisman-test-user@example.com")
+	git.execCommand("git", "config", "user.name", "Talisman Test User")
+	git.execCommand("git", "config", "commit.gpgsign", "false")
+	git.removeHooks()
+}
+
 // Clean removes the directory containing the git repository represented by a GitTesting
 func (git *GitTesting) Clean() {
 	os.RemoveAll(git.root)
@@ -61,6 +71,40 @@ func (git *GitTesting) SetupBaselineFiles(filenames ...string) {
 	git.AddAndcommit("*", "initial commit")
 }
 
+// DoInClone clones the repository into a temporary directory with the given options of git clone, such as
+// "--depth=1" or "--filter=blob:none", and executes the provided GitOperation in the clone
+func (git *GitTesting) DoInClone(gitOperation GitOperation, cloneOptions ...string) {
+	git.execCommand("git", "config", "uploadpack.allowFilter", "true")
+	clone := &GitTesting{tempPath()}
+	defer clone.Clean()
+	arguments := append(append([]string{"clone", "--quiet"}, cloneOptions...), "file://"+git.root, clone.root)
+	git.execCommand("git", arguments...)
+	clone.configure()
+	gitOperation(clone)
+}
+
+// DoInWorktree adds a linked worktree of the repository, checked out at a detached HEAD in a temporary directory, and
+// executes the provided GitOperation in it
+func (git *GitTesting) DoInWorktree(gitOperation GitOperation) {
+	worktree := &GitTesting{tempPath()}
+	git.execCommand("git", "worktree", "add", "--quiet", "--detach", worktree.root)
+	defer func() {
+		worktree.Clean()
+		git.execCommand("git", "worktree", "prune")
+	}()
+	gitOperation(worktree)
+}
+
+// AddSubmodule adds the repository of submodule as a submodule at the given path, checked out along with its own
+// submodules, and returns a GitTesting for the submodule checked out there. The submodule is staged but not committed.
+func (git *GitTesting) AddSubmodule(submodule *GitTesting, path string) *GitTesting {
+	git.execCommand("git", "-c", "protocol.file.allow=always", "submodule", "--quiet", "add", "file://"+submodule.root, path)
+	git.execCommand("git", "-c", "protocol.file.allow=always", "submodule", "--quiet", "update", "--init", "--recursive", "--", path)
+	checkedOut := &GitTesting{filepath.Join(git.root, path)}
+	checkedOut.configure()
+	return checkedOut
+}
+
 func (git *GitTesting) EarliestCommit() string {
 	return git.execCommand("git", "rev-list", "--max-parents=0", "HEAD")
 }
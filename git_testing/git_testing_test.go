@@ -115,3 +115,44 @@ func dieOnError(err error) {
 		panic(err)
 	}
 }
+
+func TestDoInCloneClonesTheRepoWithTheGivenOptions(t *testing.T) {
+	DoInTempGitRepo(func(repo *GitTesting) {
+		repo.SetupBaselineFiles("a.txt")
+		repo.AppendFileContent("a.txt", "more contents")
+		repo.AddAndcommit("a.txt", "change a.txt")
+
+		repo.DoInClone(func(clone *GitTesting) {
+			assert.NotEqual(t, repo.root, clone.root)
+			assert.Equal(t, repo.LatestCommit(), clone.LatestCommit())
+			assert.Equal(t, clone.LatestCommit(), clone.EarliestCommit(), "Expected only the latest commit to be cloned")
+		}, "--depth=1")
+	})
+}
+
+func TestDoInWorktreeChecksOutTheRepoInALinkedWorktree(t *testing.T) {
+	DoInTempGitRepo(func(repo *GitTesting) {
+		repo.SetupBaselineFiles("a.txt")
+
+		repo.DoInWorktree(func(worktree *GitTesting) {
+			assert.NotEqual(t, repo.root, worktree.root)
+			assert.Equal(t, repo.LatestCommit(), worktree.LatestCommit())
+			assert.Equal(t, repo.FileContents("a.txt"), worktree.FileContents("a.txt"))
+		})
+	})
+}
+
+func TestAddSubmoduleChecksOutTheSubmoduleAtThePath(t *testing.T) {
+	DoInTempGitRepo(func(library *GitTesting) {
+		library.SetupBaselineFiles("library.txt")
+		DoInTempGitRepo(func(repo *GitTesting) {
+			repo.SetupBaselineFiles("a.txt")
+
+			submodule := repo.AddSubmodule(library, "vendor/library")
+
+			assert.Equal(t, path.Join(repo.root, "vendor", "library"), submodule.root)
+			assert.Equal(t, library.LatestCommit(), submodule.LatestCommit())
+			assert.True(t, exists(path.Join(repo.root, ".gitmodules")))
+		})
+	})
+}
@@ -0,0 +1,147 @@
+package gitrepo
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Attribution identifies the commit that introduced a finding, who made it and when
+type Attribution struct {
+	Commit string `json:"commit"`
+	Author string `json:"author"`
+	Email  string `json:"email"`
+	// Date is the author date of the commit, in RFC 3339 format
+	Date string `json:"date"`
+	// Branches are the local and remote-tracking branches that contain the commit
+	Branches []string `json:"branches,omitempty"`
+}
+
+// Time returns the author date of the commit, or the zero time if it is unknown
+func (a Attribution) Time() time.Time {
+	date, _ := time.Parse(time.RFC3339, a.Date)
+	return date
+}
+
+// BlamedLine is a line of a file along with the commit that last changed it
+type BlamedLine struct {
+	Attribution
+	Content string
+	// Boundary is set for lines that were not changed by any commit in the range that was blamed
+	Boundary bool
+}
+
+// CommitAttributions returns the author and date of each of the commits, by commit hash
+func (repo GitRepo) CommitAttributions(commits []string) map[string]Attribution {
+	attributions := make(map[string]Attribution, len(commits))
+	if len(commits) == 0 {
+		return attributions
+	}
+	command := repo.makeRepoCommand("git", "log", "--no-walk=unsorted", "--stdin", "--format=%H%x00%an%x00%ae%x00%aI")
+	command.Stdin = strings.NewReader(strings.Join(commits, "\n") + "\n")
+	out, err := command.Output()
+	if err != nil {
+		log.Errorf("unable to read authors of commits: %v", err)
+		return attributions
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) == 4 {
+			attributions[fields[0]] = Attribution{Commit: fields[0], Author: fields[1], Email: fields[2], Date: normalizedDate(fields[3])}
+		}
+	}
+	return attributions
+}
+
+// BranchesContaining returns the local and remote-tracking branches that contain the commit
+func (repo GitRepo) BranchesContaining(commit string) []string {
+	out, err := repo.makeRepoCommand("git", "branch", "--all", "--contains", commit, "--format=%(refname:short)").Output()
+	if err != nil {
+		log.Warnf("unable to list branches containing %s: %v", commit, err)
+		return nil
+	}
+	var branches []string
+	for _, branch := range strings.Split(string(out), "\n") {
+		if branch = strings.TrimSpace(branch); branch != "" {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
+
+// Blame returns each line of the file at newCommit along with the commit that last changed it.
+// When oldCommit is given, only commits after it are blamed, and lines that none of them changed are marked as boundary.
+func (repo GitRepo) Blame(oldCommit string, newCommit string, filePath string) ([]BlamedLine, error) {
+	revision := newCommit
+	if oldCommit != "" {
+		revision = oldCommit + ".." + newCommit
+	}
+	out, err := repo.makeRepoCommand("git", "blame", "--line-porcelain", revision, "--", filePath).Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to blame %s: %v", filePath, err)
+	}
+	return parseBlame(out), nil
+}
+
+// parseBlame reads the output of git blame --line-porcelain, in which every line of the file is preceded by a header
+// naming its commit and by the details of that commit
+func parseBlame(porcelain []byte) []BlamedLine {
+	var lines []BlamedLine
+	var current BlamedLine
+	var authorTime int64
+	var authorZone string
+	scanner := bufio.NewScanner(bytes.NewReader(porcelain))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if content, isContent := strings.CutPrefix(line, "\t"); isContent {
+			current.Content = content
+			current.Date = blameDate(authorTime, authorZone)
+			lines = append(lines, current)
+			current = BlamedLine{}
+			continue
+		}
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "author":
+			current.Author = value
+		case "author-mail":
+			current.Email = strings.TrimSuffix(strings.TrimPrefix(value, "<"), ">")
+		case "author-time":
+			authorTime, _ = strconv.ParseInt(value, 10, 64)
+		case "author-tz":
+			authorZone = value
+		case "boundary":
+			current.Boundary = true
+		default:
+			if current.Commit == "" && (len(key) == 40 || len(key) == 64) {
+				current.Commit = key
+			}
+		}
+	}
+	return lines
+}
+
+// normalizedDate formats a date printed by git in strict ISO 8601 format the same way as dates read from git blame
+func normalizedDate(date string) string {
+	parsed, err := time.Parse(time.RFC3339, date)
+	if err != nil {
+		return date
+	}
+	return parsed.Format(time.RFC3339)
+}
+
+// blameDate formats a timestamp and a time zone such as +0530, as printed by git blame, in RFC 3339 format
+func blameDate(timestamp int64, zone string) string {
+	date := time.Unix(timestamp, 0).UTC()
+	if offset, err := strconv.Atoi(zone); err == nil {
+		seconds := (offset/100)*3600 + (offset%100)*60
+		date = date.In(time.FixedZone(zone, seconds))
+	}
+	return date.Format(time.RFC3339)
+}
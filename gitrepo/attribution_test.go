@@ -0,0 +1,68 @@
+package gitrepo
+
+import (
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const blamePorcelain = `cd1c1b4c5b7e3a1f0f4d3f7f0a7e1e9b3c2d1a0f 1 1 1
+author Alice
+author-mail <alice@example.com>
+author-time 1700000000
+author-tz +0530
+committer Alice
+summary Add config
+boundary
+filename config.yml
+	user: admin
+0a1b2c3d4e5f60718293a4b5c6d7e8f901234567 2 2 1
+author Bob
+author-mail <bob@example.com>
+author-time 1700003600
+author-tz -0100
+summary Add password
+previous cd1c1b4c5b7e3a1f0f4d3f7f0a7e1e9b3c2d1a0f config.yml
+filename config.yml
+	password: hunter2
+`
+
+func TestParseBlameReadsTheCommitAndAuthorOfEachLine(t *testing.T) {
+	lines := parseBlame([]byte(blamePorcelain))
+
+	assert.Equal(t, []BlamedLine{
+		{
+			Attribution: Attribution{Commit: "cd1c1b4c5b7e3a1f0f4d3f7f0a7e1e9b3c2d1a0f", Author: "Alice", Email: "alice@example.com", Date: "2023-11-15T03:43:20+05:30"},
+			Content:     "user: admin",
+			Boundary:    true,
+		},
+		{
+			Attribution: Attribution{Commit: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567", Author: "Bob", Email: "bob@example.com", Date: "2023-11-14T22:13:20-01:00"},
+			Content:     "password: hunter2",
+		},
+	}, lines)
+}
+
+func TestCommitAttributionsAndBlameNameTheAuthorOfACommit(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("d.txt", "first line\n")
+		git.AddAndcommit("d.txt", "Add d.txt")
+		baseline := git.LatestCommit()
+		git.AppendFileContent("d.txt", "secret line\n")
+		git.AddAndcommit("d.txt", "Add secret line")
+		repo := RepoLocatedAt(git.Root())
+
+		attribution := repo.CommitAttributions([]string{git.LatestCommit()})[git.LatestCommit()]
+		lines, err := repo.Blame(baseline, git.LatestCommit(), "d.txt")
+
+		assert.Equal(t, "Talisman Test User", attribution.Author)
+		assert.Equal(t, "talisman-test-user@example.com", attribution.Email)
+		assert.False(t, attribution.Time().IsZero())
+		assert.NoError(t, err)
+		assert.True(t, lines[0].Boundary)
+		assert.Equal(t, BlamedLine{Attribution: attribution, Content: "secret line"}, lines[len(lines)-1])
+		assert.Equal(t, []string{"master"}, repo.BranchesContaining(git.LatestCommit()))
+	})
+}
@@ -0,0 +1,78 @@
+package gitrepo
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ShallowCommits returns the commits of a shallow clone whose parents were not fetched, which is where the history it
+// holds stops. A repository that is not shallow has none.
+func (repo GitRepo) ShallowCommits() ([]string, error) {
+	shallowPath, err := repo.executeRepoCommand("git", "rev-parse", "--git-path", "shallow")
+	if err != nil {
+		return nil, err
+	}
+	path := strings.TrimSpace(string(shallowPath))
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(repo.root, path)
+	}
+	contents, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(contents)), nil
+}
+
+// ShallowBoundaryOf returns the commits of a shallow clone where the history of the given commit stops, oldest first
+func (repo GitRepo) ShallowBoundaryOf(commit string) ([]string, error) {
+	shallowCommits, err := repo.ShallowCommits()
+	if err != nil || len(shallowCommits) == 0 {
+		return nil, err
+	}
+	shallow := make(map[string]bool, len(shallowCommits))
+	for _, shallowCommit := range shallowCommits {
+		shallow[shallowCommit] = true
+	}
+	history, err := repo.executeRepoCommand("git", "rev-list", "--topo-order", "--reverse", commit)
+	if err != nil {
+		return nil, err
+	}
+	var boundary []string
+	for _, historyCommit := range strings.Fields(string(history)) {
+		if shallow[historyCommit] {
+			boundary = append(boundary, historyCommit)
+		}
+	}
+	return boundary, nil
+}
+
+// IsPartial reports whether the repository is a partial clone, such as a blobless clone, which fetches the objects it
+// left out from its promisor remote when they are read
+func (repo GitRepo) IsPartial() bool {
+	if out, err := repo.executeRepoCommand("git", "config", "--get", "extensions.partialClone"); err == nil && len(strings.TrimSpace(string(out))) > 0 {
+		return true
+	}
+	out, err := repo.executeRepoCommand("git", "config", "--bool", "--get-regexp", `^remote\..*\.promisor$`)
+	return err == nil && strings.Contains(string(out), " true")
+}
+
+// MissingObjects returns the objects reachable from the revisions that a partial clone left out, without fetching them.
+// The revisions are given as to git rev-list, such as "--all" or "main", "--not", "origin/main".
+func (repo GitRepo) MissingObjects(revisions ...string) (map[string]bool, error) {
+	out, err := repo.executeRepoCommand("git", append([]string{"rev-list", "--objects", "--missing=print"}, revisions...)...)
+	if err != nil {
+		return nil, err
+	}
+	missing := map[string]bool{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if hash, found := strings.CutPrefix(line, "?"); found {
+			missing[hash] = true
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,66 @@
+package gitrepo
+
+import (
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShallowBoundaryOfListsTheCommitsWhereTheHistoryOfAShallowCloneStops(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.AppendFileContent("a.txt", "more contents")
+		git.AddAndcommit("a.txt", "change a.txt")
+		git.AppendFileContent("c.txt", "more contents")
+		git.AddAndcommit("c.txt", "change c.txt")
+		assert.Empty(t, shallowBoundaryOf(t, RepoLocatedAt(git.Root()), "HEAD"))
+
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			repo := RepoLocatedAt(clone.Root())
+			boundary := clone.EarliestCommit()
+
+			assert.Equal(t, []string{boundary}, shallowBoundaryOf(t, repo, "HEAD"))
+			shallowCommits, err := repo.ShallowCommits()
+			assert.NoError(t, err)
+			assert.Equal(t, []string{boundary}, shallowCommits)
+			assert.NotEqual(t, git.EarliestCommit(), boundary)
+		}, "--depth=2")
+	})
+}
+
+func shallowBoundaryOf(t *testing.T, repo GitRepo, commit string) []string {
+	boundary, err := repo.ShallowBoundaryOf(commit)
+	assert.NoError(t, err)
+	return boundary
+}
+
+func TestAvailableAdditionsWithinRangeLeavesOutFilesAPartialCloneHasNotFetched(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		before := git.LatestCommit()
+		git.AppendFileContent("a.txt", "more contents")
+		git.CreateFileWithContents("d.txt", "new file")
+		git.AddAndcommit("*", "change a.txt and add d.txt")
+		changed := git.LatestCommit()
+		git.AppendFileContent("a.txt", "even more contents")
+		git.AddAndcommit("a.txt", "change a.txt again")
+		assert.False(t, RepoLocatedAt(git.Root()).IsPartial())
+
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			repo := RepoLocatedAt(clone.Root())
+			assert.True(t, repo.IsPartial())
+
+			additions, skipped, err := repo.AvailableAdditionsWithinRange(before, changed)
+
+			assert.NoError(t, err)
+			assert.Equal(t, []FilePath{"a.txt"}, skipped)
+			if assert.Len(t, additions, 1) {
+				assert.Equal(t, FilePath("d.txt"), additions[0].Path)
+				assert.Equal(t, "new file", string(additions[0].Data))
+			}
+			missing, err := repo.MissingObjects("--all")
+			assert.NoError(t, err)
+			assert.Len(t, missing, 2, "Expected the versions of a.txt before the latest not to be fetched")
+		}, "--filter=blob:none")
+	})
+}
@@ -0,0 +1,142 @@
+package gitrepo
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const submoduleMode = "160000"
+
+// diffEntry is a file changed by a diff, as listed by git diff --raw -z along with its section of the patch
+type diffEntry struct {
+	oldMode string
+	newMode string
+	// status is the letter git gives the change, such as M, A, R or C, without the similarity score of renames and copies
+	status  string
+	oldPath string
+	path    string
+	binary  bool
+	added   []byte
+}
+
+func (entry diffEntry) isSubmodule() bool {
+	return entry.newMode == submoduleMode || entry.oldMode == submoduleMode
+}
+
+// parseRawPatch reads the output of git diff --raw -z --patch: a NUL-terminated record for each file, holding its
+// modes, hashes and status followed by its paths, an extra NUL, and then the patch of each file in the same order.
+// Paths are read from the records, as they are neither quoted nor ambiguous there, unlike in the patch headers.
+func parseRawPatch(output []byte) ([]diffEntry, error) {
+	var entries []diffEntry
+	rest := output
+	for len(rest) > 0 && rest[0] == ':' {
+		var header, path string
+		var err error
+		if header, rest, err = cutNul(rest); err != nil {
+			return nil, err
+		}
+		fields := strings.Fields(strings.TrimPrefix(header, ":"))
+		if len(fields) != 5 {
+			return nil, fmt.Errorf("unexpected raw diff record %q", header)
+		}
+		entry := diffEntry{oldMode: fields[0], newMode: fields[1], status: fields[4][:1]}
+		if path, rest, err = cutNul(rest); err != nil {
+			return nil, err
+		}
+		entry.path = path
+		if entry.status == "R" || entry.status == "C" {
+			entry.oldPath = path
+			if entry.path, rest, err = cutNul(rest); err != nil {
+				return nil, err
+			}
+		}
+		entries = append(entries, entry)
+	}
+	sections := patchSections(bytes.TrimLeft(rest, "\x00"))
+	if len(sections) != len(entries) {
+		return nil, fmt.Errorf("diff lists %d files but has patches for %d", len(entries), len(sections))
+	}
+	for i, section := range sections {
+		if err := entries[i].readPatch(section); err != nil {
+			return nil, fmt.Errorf("unable to read the patch of %s: %v", entries[i].path, err)
+		}
+	}
+	return entries, nil
+}
+
+func cutNul(data []byte) (string, []byte, error) {
+	field, rest, found := bytes.Cut(data, []byte{0})
+	if !found {
+		return "", nil, fmt.Errorf("unterminated raw diff record %q", field)
+	}
+	return string(field), rest, nil
+}
+
+// patchSections splits a patch into the lines of each file. Every line of a hunk starts with a space, +, - or \,
+// so only the header of a file can start with "diff --git ".
+func patchSections(patch []byte) [][]string {
+	var sections [][]string
+	for _, line := range strings.Split(string(patch), "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			sections = append(sections, []string{})
+			continue
+		}
+		if len(sections) > 0 {
+			sections[len(sections)-1] = append(sections[len(sections)-1], line)
+		}
+	}
+	return sections
+}
+
+// readPatch reads the hunks of the patch of the entry, collecting the lines they add
+func (entry *diffEntry) readPatch(lines []string) error {
+	inHunks := false
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "@@ "):
+			if err := checkHunkHeader(line); err != nil {
+				return err
+			}
+			inHunks = true
+		case !inHunks:
+			// the extended header lines, such as those giving modes, renames and the names of the files
+			if strings.HasPrefix(line, "Binary files ") || line == "GIT binary patch" {
+				entry.binary = true
+			}
+		case strings.HasPrefix(line, "+"):
+			entry.added = append(entry.added, line[1:]...)
+			entry.added = append(entry.added, '\n')
+		}
+	}
+	return nil
+}
+
+// checkHunkHeader checks a hunk header such as "@@ -1,6 +1,7 @@ func main() {", in which a range without a count
+// covers a single line
+func checkHunkHeader(header string) error {
+	fields := strings.Fields(header)
+	if len(fields) < 4 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") || fields[3] != "@@" {
+		return fmt.Errorf("invalid hunk header %q", header)
+	}
+	for _, hunkRange := range fields[1:3] {
+		if err := checkHunkRange(hunkRange[1:]); err != nil {
+			return fmt.Errorf("invalid hunk header %q: %v", header, err)
+		}
+	}
+	return nil
+}
+
+func checkHunkRange(hunkRange string) error {
+	startText, linesText, hasLines := strings.Cut(hunkRange, ",")
+	if _, err := strconv.Atoi(startText); err != nil {
+		return err
+	}
+	if hasLines {
+		if _, err := strconv.Atoi(linesText); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package gitrepo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const rawPatch = ":100644 100644 bdc955b 8835708 M\x00bin.dat\x00" +
+	":100644 100755 587be6b 587be6b M\x00mode.sh\x00" +
+	":100644 100644 b566061 f893115 R064\x00old name.txt\x00new name é.txt\x00" +
+	":000000 100644 0000000 4b779b9 A\x00we b/x.txt\x00" +
+	":160000 160000 1111111 2222222 M\x00sub\x00" +
+	"\x00" +
+	`diff --git a/bin.dat b/bin.dat
+index bdc955b..8835708 100644
+Binary files a/bin.dat and b/bin.dat differ
+diff --git a/mode.sh b/mode.sh
+old mode 100644
+new mode 100755
+diff --git a/old name.txt "b/new name \303\251.txt"
+similarity index 64%
+rename from old name.txt
+rename to "new name \303\251.txt"
+index b566061..f893115 100644
+--- a/old name.txt
++++ "b/new name \303\251.txt"
+@@ -1,6 +1,7 @@
+ one
+ two
+-three
++THREE
+ four
+ five
+ six
++seven
+diff --git a/we b/x.txt b/we b/x.txt
+new file mode 100644
+index 0000000..4b779b9
+--- /dev/null
++++ b/we b/x.txt
+@@ -0,0 +1,2 @@
++a
++diff --git a/ b/c
+\ No newline at end of file
+diff --git a/sub b/sub
+index 1111111..2222222 160000
+--- a/sub
++++ b/sub
+@@ -1 +1 @@
+-Subproject commit 1111111
++Subproject commit 2222222
+`
+
+func TestParseRawPatchReadsEachFileOfTheDiff(t *testing.T) {
+	entries, err := parseRawPatch([]byte(rawPatch))
+	if !assert.NoError(t, err) || !assert.Len(t, entries, 5) {
+		return
+	}
+
+	assert.Equal(t, "bin.dat", entries[0].path)
+	assert.True(t, entries[0].binary)
+	assert.Empty(t, entries[0].added)
+
+	assert.Equal(t, "mode.sh", entries[1].path)
+	assert.Equal(t, "100755", entries[1].newMode)
+	assert.Empty(t, entries[1].added)
+
+	assert.Equal(t, "new name é.txt", entries[2].path)
+	assert.Equal(t, "old name.txt", entries[2].oldPath)
+	assert.Equal(t, "R", entries[2].status)
+	assert.Equal(t, "THREE\nseven\n", string(entries[2].added))
+
+	assert.Equal(t, "we b/x.txt", entries[3].path)
+	assert.Equal(t, "a\ndiff --git a/ b/c\n", string(entries[3].added))
+
+	assert.True(t, entries[4].isSubmodule())
+	assert.False(t, entries[2].isSubmodule())
+}
+
+func TestParseRawPatchRejectsDiffsItCannotMatchToFiles(t *testing.T) {
+	_, err := parseRawPatch([]byte(":100644 100644 bdc955b 8835708 M\x00a.txt\x00\x00"))
+	assert.Error(t, err)
+	_, err = parseRawPatch([]byte(":100644 100644 bdc955b 8835708 M\x00a.txt"))
+	assert.Error(t, err)
+	_, err = parseRawPatch([]byte(":100644 100644 bdc955b 8835708 M\x00a.txt\x00\x00diff --git a/a.txt b/a.txt\n@@ -a +1 @@\n+x\n"))
+	assert.Error(t, err)
+
+	entries, err := parseRawPatch(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestStagedDiffOfRenamesQuotedPathsBinariesModesAndSubmodules(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo := RepoLocatedAt(git.Root())
+		git.CreateFileWithContents("script.sh", "#!/bin/sh")
+		git.AddAndcommit("script.sh", "add script")
+		git.OverwriteFileContent("a.txt", strings.Repeat("unchanged line\n", 5))
+		git.AddAndcommit("a.txt", "rewrite a.txt")
+
+		repo.executeRepoCommand("git", "mv", "a.txt", "renamed \"ünïcode\".txt")
+		git.AppendFileContent("renamed \"ünïcode\".txt", "added line\n")
+		git.Add("renamed \"ünïcode\".txt")
+		assert.NoError(t, os.WriteFile(filepath.Join(git.Root(), "image.bin"), []byte{0, 1, 2, 0, 3}, 0644))
+		git.Add("image.bin")
+		assert.NoError(t, os.Chmod(filepath.Join(git.Root(), "script.sh"), 0755))
+		git.Add("script.sh")
+		repo.executeRepoCommand("git", "update-index", "--add", "--cacheinfo", "160000,"+git.LatestCommit()+",submodule")
+
+		additions := map[FilePath]Addition{}
+		for _, addition := range stagedDiffOf(t, repo) {
+			additions[addition.Path] = addition
+		}
+		assert.Len(t, additions, 3)
+		assert.NotContains(t, additions, FilePath("submodule"))
+
+		renamed := additions["renamed \"ünïcode\".txt"]
+		assert.Equal(t, FilePath("a.txt"), renamed.RenamedFrom)
+		assert.Equal(t, "added line\n", string(renamed.Data))
+
+		assert.Equal(t, []byte{0, 1, 2, 0, 3}, additions["image.bin"].Data)
+		assert.Contains(t, additions, FilePath("script.sh"))
+		assert.Empty(t, additions["script.sh"].Data)
+	})
+}
+
+func TestStagedDiffIgnoresExternalDiffAndPrefixSettings(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		for _, setting := range [][]string{{"diff.noprefix", "true"}, {"diff.external", "false"}, {"core.quotePath", "false"}} {
+			command := exec.Command("git", "config", setting[0], setting[1])
+			command.Dir = git.Root()
+			assert.NoError(t, command.Run())
+		}
+		git.CreateFileWithContents("new.txt", "created contents")
+		git.Add("new.txt")
+		additions := stagedDiffOf(t, RepoLocatedAt(git.Root()))
+		if assert.Len(t, additions, 1) {
+			assert.Equal(t, "created contents\n", string(additions[0].Data))
+		}
+	})
+}
@@ -0,0 +1,124 @@
+package gitrepo
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"talisman/gitrepo/gitobject"
+)
+
+var (
+	// ErrNotARepository is returned when git is run outside of a git repository
+	ErrNotARepository = gitobject.ErrNotARepository
+	// ErrNoCommits is returned when a command needs a commit in a repository that has none yet
+	ErrNoCommits = errors.New("the repository has no commits yet")
+	// ErrShallowHistory is returned when a commit or object is missing from a shallow clone, whose history is cut short
+	ErrShallowHistory = errors.New("the history of this shallow clone is incomplete")
+	// ErrMissingObject is returned when a commit or object cannot be found in the repository
+	ErrMissingObject = gitobject.ErrObjectNotFound
+)
+
+// missingObjectMessages are the messages git prints when a revision or an object it was asked about does not exist
+var missingObjectMessages = []string{
+	"bad object",
+	"bad revision",
+	"bad default revision",
+	"unknown revision",
+	"invalid revision range",
+	"not a valid object name",
+	"ambiguous argument",
+	"does not have any commits yet",
+	"unable to read",
+	"could not read",
+	"missing blob",
+	"missing tree",
+	"missing commit",
+}
+
+// GitError is a git command that failed, along with the situation it failed in when that is a common one
+type GitError struct {
+	Args   []string
+	Stderr string
+	// Kind is ErrNotARepository, ErrNoCommits, ErrShallowHistory or ErrMissingObject, or nil if the failure is of
+	// another kind
+	Kind error
+	Err  error
+}
+
+func (e *GitError) Error() string {
+	message := strings.TrimSpace(e.Stderr)
+	if message == "" {
+		message = e.Err.Error()
+	}
+	if e.Kind != nil {
+		return fmt.Sprintf("git %s: %v: %s", strings.Join(e.Args, " "), e.Kind, message)
+	}
+	return fmt.Sprintf("git %s: %s", strings.Join(e.Args, " "), message)
+}
+
+// Unwrap lets errors.Is match both the kind of failure and the error running git returned
+func (e *GitError) Unwrap() []error {
+	if e.Kind == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// CommandError returns the error of a git command run in the repository with the given arguments. Errors of commands
+// run with Output carry what git printed on standard error, which tells which of the common failures this is.
+func (repo GitRepo) CommandError(args []string, err error) error {
+	if err == nil {
+		return nil
+	}
+	gitError := &GitError{Args: args, Err: err}
+	var exitError *exec.ExitError
+	if errors.As(err, &exitError) {
+		gitError.Stderr = string(exitError.Stderr)
+	}
+	gitError.Kind = repo.failureKind(gitError.Stderr)
+	return gitError
+}
+
+// ObjectError returns the error of reading an object or file of the repository, typed like the errors of git commands
+func (repo GitRepo) ObjectError(err error) error {
+	if err == nil || errors.Is(err, ErrShallowHistory) || !errors.Is(err, ErrMissingObject) {
+		return err
+	}
+	if repo.IsShallow() {
+		return fmt.Errorf("%w: %w", ErrShallowHistory, err)
+	}
+	return err
+}
+
+func (repo GitRepo) failureKind(stderr string) error {
+	message := strings.ToLower(stderr)
+	if strings.Contains(message, "not a git repository") {
+		return ErrNotARepository
+	}
+	for _, missingObject := range missingObjectMessages {
+		if !strings.Contains(message, missingObject) {
+			continue
+		}
+		switch {
+		case !repo.HasCommits():
+			return ErrNoCommits
+		case repo.IsShallow():
+			return ErrShallowHistory
+		default:
+			return ErrMissingObject
+		}
+	}
+	return nil
+}
+
+// HasCommits reports whether HEAD points to a commit, which it does not in a repository where nothing is committed yet
+func (repo GitRepo) HasCommits() bool {
+	return repo.makeRepoCommand("git", "rev-parse", "--verify", "--quiet", "HEAD^{commit}").Run() == nil
+}
+
+// IsShallow reports whether the repository is a shallow clone, whose history stops at some commits
+func (repo GitRepo) IsShallow() bool {
+	out, err := repo.makeRepoCommand("git", "rev-parse", "--is-shallow-repository").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
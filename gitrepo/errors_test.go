@@ -0,0 +1,81 @@
+package gitrepo
+
+import (
+	"errors"
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const absentCommit = "0123456789abcdef0123456789abcdef01234567"
+
+func TestCommandsOutsideOfARepositoryReturnErrNotARepository(t *testing.T) {
+	repo := RepoLocatedAt(t.TempDir())
+
+	_, err := repo.CommitMessages()
+
+	assert.ErrorIs(t, err, ErrNotARepository)
+	var gitError *GitError
+	if assert.True(t, errors.As(err, &gitError)) {
+		assert.Contains(t, gitError.Stderr, "not a git repository")
+	}
+}
+
+func TestCommandsInARepositoryWithoutCommitsReturnErrNoCommits(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		repo := RepoLocatedAt(git.Root())
+
+		_, err := repo.AdditionsWithinRange("HEAD~1", "HEAD")
+		assert.ErrorIs(t, err, ErrNoCommits)
+
+		tracked, err := repo.TrackedFilesAsAdditions()
+		assert.NoError(t, err, "Expected a repository without commits to have no tracked files")
+		assert.Empty(t, tracked)
+	})
+}
+
+func TestCommandsOnCommitsMissingFromAShallowCloneReturnErrShallowHistory(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.AppendFileContent("a.txt", "more contents")
+		git.AddAndcommit("a.txt", "change a.txt")
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			repo := RepoLocatedAt(clone.Root())
+
+			_, err := repo.AdditionsWithinRange(git.EarliestCommit(), git.LatestCommit())
+
+			assert.ErrorIs(t, err, ErrShallowHistory)
+			assert.True(t, repo.IsShallow())
+		}, "--depth=1")
+	})
+}
+
+func TestCommandsOnCommitsThatDoNotExistReturnErrMissingObject(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo := RepoLocatedAt(git.Root())
+
+		_, err := repo.AdditionsWithinRange(absentCommit, "HEAD")
+		assert.ErrorIs(t, err, ErrMissingObject)
+		_, err = repo.CommitMessagesWithinRange(absentCommit, "HEAD")
+		assert.ErrorIs(t, err, ErrMissingObject)
+		assert.False(t, repo.IsShallow())
+	})
+}
+
+func TestBatchReaderReturnsErrMissingObjectForObjectsThatDoNotExist(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		reader := NewBatchGitObjectHashReader(git.Root())
+		if !assert.NoError(t, reader.Start()) {
+			return
+		}
+		defer reader.Shutdown()
+
+		_, err := reader.Read(absentCommit)
+		assert.ErrorIs(t, err, ErrMissingObject)
+
+		contents, err := reader.Read(git.LatestCommit())
+		assert.NoError(t, err, "Expected the reader to keep reading after a missing object")
+		assert.Contains(t, string(contents), "tree ")
+	})
+}
@@ -2,10 +2,12 @@ package gitrepo
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"os/exec"
 	"strconv"
+	"strings"
 
 	"github.com/sirupsen/logrus"
 )
@@ -23,17 +25,33 @@ type BatchGitObjectReader struct {
 	inputWriter  *bufio.Writer
 	outputReader *bufio.Reader
 	read         ReadFunc
+	// err is the error setting up the git process, returned instead of starting it
+	err     error
+	started bool
 }
 
 func (bgor *BatchGitObjectReader) Start() error {
-	return bgor.cmd.Start()
+	if bgor.err != nil {
+		return bgor.err
+	}
+	if err := bgor.cmd.Start(); err != nil {
+		return fmt.Errorf("error starting batch git file reader subprocess: %w", err)
+	}
+	bgor.started = true
+	return nil
 }
 
 func (bgor *BatchGitObjectReader) Shutdown() error {
+	if !bgor.started {
+		return nil
+	}
 	return bgor.cmd.Process.Kill()
 }
 
 func (bgor *BatchGitObjectReader) Read(expr string) ([]byte, error) {
+	if !bgor.started {
+		return nil, errors.New("batch git file reader subprocess has not been started")
+	}
 	return bgor.read(expr)
 }
 
@@ -42,11 +60,11 @@ func newBatchGitObjectReader(root string) *BatchGitObjectReader {
 	cmd := repo.makeRepoCommand("git", "cat-file", "--batch=%(objectsize)")
 	inputPipe, err := cmd.StdinPipe()
 	if err != nil {
-		logrus.Fatalf("error creating stdin pipe for batch git file reader subprocess: %v", err)
+		return &BatchGitObjectReader{repo: repo, cmd: cmd, err: fmt.Errorf("error creating stdin pipe for batch git file reader subprocess: %w", err)}
 	}
 	outputPipe, err := cmd.StdoutPipe()
 	if err != nil {
-		logrus.Fatalf("error creating stdout pipe for batch git file reader subprocess: %v", err)
+		return &BatchGitObjectReader{repo: repo, cmd: cmd, err: fmt.Errorf("error creating stdout pipe for batch git file reader subprocess: %w", err)}
 	}
 	batchReader := BatchGitObjectReader{
 		repo:         repo,
@@ -58,18 +76,29 @@ func newBatchGitObjectReader(root string) *BatchGitObjectReader {
 }
 
 func NewBatchGitHeadPathReader(root string) BatchReader {
+	if nativeReads {
+		return newNativeBatchReader(root, func(reader ObjectReader, path string) ([]byte, error) {
+			return reader.ReadPath(GIT_HEAD_PREFIX, path)
+		})
+	}
 	bgor := newBatchGitObjectReader(root)
 	bgor.read = bgor.makePathReader(GIT_HEAD_PREFIX)
 	return bgor
 }
 
 func NewBatchGitStagedPathReader(root string) BatchReader {
+	if nativeReads {
+		return newNativeBatchReader(root, ObjectReader.ReadStaged)
+	}
 	bgor := newBatchGitObjectReader(root)
 	bgor.read = bgor.makePathReader(GIT_STAGED_PREFIX)
 	return bgor
 }
 
 func NewBatchGitObjectHashReader(root string) BatchReader {
+	if nativeReads {
+		return newNativeBatchReader(root, ObjectReader.ReadBlob)
+	}
 	bgor := newBatchGitObjectReader(root)
 	bgor.read = bgor.makeObjectHashReader()
 	return bgor
@@ -117,7 +146,12 @@ func (bgor *BatchGitObjectReader) doCatFile(gitExpressionChan chan ([]byte), res
 			resultsChan <- gitCatFileReadResult{[]byte{}, err}
 			continue
 		}
-		filesize, err := strconv.Atoi(string(filesizeBytes[:len(filesizeBytes)-1]))
+		sizeLine := string(filesizeBytes[:len(filesizeBytes)-1])
+		if strings.HasSuffix(sizeLine, " missing") || strings.HasSuffix(sizeLine, " ambiguous") {
+			resultsChan <- gitCatFileReadResult{[]byte{}, bgor.repo.ObjectError(fmt.Errorf("%w: %s", ErrMissingObject, sizeLine))}
+			continue
+		}
+		filesize, err := strconv.Atoi(sizeLine)
 		if err != nil {
 			logrus.Errorf("error parsing filesize: %v", err)
 			resultsChan <- gitCatFileReadResult{[]byte{}, err}
@@ -0,0 +1,258 @@
+package gitobject
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func init() {
+	git_testing.Logger = logrus.WithField("Environment", "Debug")
+	git_testing.Logger.Debug("GitObject test started")
+}
+
+func gitOutput(t *testing.T, root string, args ...string) string {
+	command := exec.Command("git", args...)
+	command.Dir = root
+	out, err := command.Output()
+	assert.NoError(t, err, "git %v", args)
+	return string(out)
+}
+
+func commitVersions(git *git_testing.GitTesting, fileName string, versions int) {
+	var lines []string
+	for i := 0; i < 200; i++ {
+		lines = append(lines, fmt.Sprintf("line %d of a file that changes a little in every commit", i))
+	}
+	for version := 0; version < versions; version++ {
+		lines[version%len(lines)] = fmt.Sprintf("line changed in version %d", version)
+		git.CreateFileWithContents(fileName, strings.Join(lines, "\n"))
+		git.AddAndcommit(fileName, fmt.Sprintf("version %d", version))
+	}
+}
+
+func TestReadingLooseObjectsMatchesGit(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt", filepath.Join("nested", "b.txt"))
+		repo, err := Find(git.Root())
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer repo.Close()
+
+		contents, err := repo.ReadPath("HEAD", "nested/b.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, gitOutput(t, git.Root(), "cat-file", "-p", "HEAD:nested/b.txt"), string(contents))
+
+		objectType, commit, err := repo.ReadObject(git.LatestCommit())
+		assert.NoError(t, err)
+		assert.Equal(t, CommitObject, objectType)
+		assert.Equal(t, gitOutput(t, git.Root(), "cat-file", "commit", git.LatestCommit()), string(commit))
+	})
+}
+
+func TestReadingPackedObjectsWithDeltasMatchesGit(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		commitVersions(git, "versions.txt", 30)
+		gitOutput(t, git.Root(), "repack", "-adf", "--depth=50")
+		loose, _ := filepath.Glob(filepath.Join(git.Root(), ".git", "objects", "??", "*"))
+		assert.Empty(t, loose, "objects should all have been packed")
+		packIndexes, _ := filepath.Glob(filepath.Join(git.Root(), ".git", "objects", "pack", "*.idx"))
+		if !assert.Len(t, packIndexes, 1) {
+			return
+		}
+		verifyPack := gitOutput(t, git.Root(), "verify-pack", "-v", packIndexes[0])
+		assert.Contains(t, verifyPack, "chain length", "pack should hold deltas")
+
+		repo, err := Find(git.Root())
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer repo.Close()
+		for _, line := range strings.Fields(gitOutput(t, git.Root(), "cat-file", "--batch-all-objects", "--batch-check=%(objectname)")) {
+			objectType, contents, err := repo.ReadObject(line)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, strings.TrimSpace(gitOutput(t, git.Root(), "cat-file", "-t", line)), objectType.String())
+			assert.Equal(t, gitOutput(t, git.Root(), "cat-file", objectType.String(), line), string(contents), "object %s", line)
+		}
+	})
+}
+
+func TestReadingObjectsPackedAfterOpening(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		repo, err := Find(git.Root())
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer repo.Close()
+		commitVersions(git, "versions.txt", 3)
+		gitOutput(t, git.Root(), "gc", "--quiet")
+
+		contents, err := repo.ReadPath("HEAD", "versions.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, gitOutput(t, git.Root(), "cat-file", "-p", "HEAD:versions.txt"), string(contents))
+	})
+}
+
+func TestResolvingLooseAndPackedRefs(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		gitOutput(t, git.Root(), "tag", "-a", "v1.0", "-m", "release")
+		gitOutput(t, git.Root(), "pack-refs", "--all")
+		git.AppendFileContent("a.txt", "more")
+		git.AddAndcommit("a.txt", "after packing refs")
+		repo, err := Find(git.Root())
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer repo.Close()
+
+		for _, name := range []string{"HEAD", "master", "refs/heads/master", "v1.0", "tags/v1.0"} {
+			hash, err := repo.ResolveRef(name)
+			assert.NoError(t, err, name)
+			assert.Equal(t, strings.TrimSpace(gitOutput(t, git.Root(), "rev-parse", name)), hash, name)
+		}
+		refs, err := repo.Refs()
+		assert.NoError(t, err)
+		assert.Equal(t, git.LatestCommit(), refs["refs/heads/master"])
+		assert.Contains(t, refs, "refs/tags/v1.0")
+
+		contents, err := repo.ReadPath("v1.0", "a.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, gitOutput(t, git.Root(), "cat-file", "-p", "v1.0:a.txt"), string(contents))
+	})
+}
+
+func TestReadingTheIndex(t *testing.T) {
+	for _, version := range []string{"2", "3", "4"} {
+		t.Run("version "+version, func(t *testing.T) {
+			git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+				git.SetupBaselineFiles("a.txt", filepath.Join("some", "deeply", "nested", "file.txt"), filepath.Join("some", "deeply", "other.txt"))
+				git.CreateFileWithContents("staged file.txt", "staged contents")
+				git.Add("staged file.txt")
+				gitOutput(t, git.Root(), "update-index", "--index-version", version)
+				if version == "3" {
+					gitOutput(t, git.Root(), "update-index", "--skip-worktree", "a.txt")
+				}
+				repo, err := Find(git.Root())
+				if !assert.NoError(t, err) {
+					return
+				}
+				defer repo.Close()
+
+				entries, err := repo.Index()
+				assert.NoError(t, err)
+				var listed []string
+				for _, entry := range entries {
+					listed = append(listed, fmt.Sprintf("%o %s %d\t%s", entry.Mode, entry.Hash, entry.Stage, entry.Path))
+				}
+				assert.Equal(t, strings.Split(strings.TrimSpace(gitOutput(t, git.Root(), "ls-files", "-s")), "\n"), listed)
+
+				contents, err := repo.ReadStaged("staged file.txt")
+				assert.NoError(t, err)
+				assert.Equal(t, "staged contents", string(contents))
+			})
+		})
+	}
+}
+
+func TestReadingAWorktree(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		worktree := filepath.Join(git.Root(), "..", filepath.Base(git.Root())+"-worktree")
+		gitOutput(t, git.Root(), "worktree", "add", "-b", "feature", worktree)
+		defer os.RemoveAll(worktree)
+		repo, err := Find(worktree)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer repo.Close()
+
+		hash, err := repo.ResolveRef("HEAD")
+		assert.NoError(t, err)
+		assert.Equal(t, git.LatestCommit(), hash)
+		contents, err := repo.ReadStaged("a.txt")
+		assert.NoError(t, err)
+		assert.Equal(t, git.FileContents("a.txt"), contents)
+	})
+}
+
+func TestReadErrors(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		repo, err := Find(git.Root())
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer repo.Close()
+
+		_, _, err = repo.ReadObject(strings.Repeat("0", 40))
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+		var objectErr *ObjectError
+		assert.ErrorAs(t, err, &objectErr)
+		_, err = repo.ReadPath("HEAD", "missing.txt")
+		assert.ErrorIs(t, err, ErrPathNotFound)
+		_, err = repo.ReadStaged("missing.txt")
+		assert.ErrorIs(t, err, ErrPathNotFound)
+		_, err = repo.ReadPath("no-such-branch", "a.txt")
+		assert.ErrorIs(t, err, ErrRefNotFound)
+		_, err = repo.ReadBlob(git.LatestCommit())
+		assert.Error(t, err)
+	})
+
+	_, err := Find(t.TempDir())
+	assert.ErrorIs(t, err, ErrNotARepository)
+}
+
+func TestApplyDeltaRejectsCorruptDeltas(t *testing.T) {
+	base := []byte("hello world")
+	// base size 11, target size 5, copy 5 bytes from offset 6
+	target, err := applyDelta(base, []byte{11, 5, 0x80 | 0x01 | 0x10, 6, 5})
+	assert.NoError(t, err)
+	assert.Equal(t, "world", string(target))
+
+	_, err = applyDelta(base, []byte{12, 5, 0x80 | 0x01 | 0x10, 6, 5})
+	assert.Error(t, err, "base size mismatch")
+	_, err = applyDelta(base, []byte{11, 5, 0x80 | 0x01 | 0x10, 8, 5})
+	assert.Error(t, err, "copy beyond the base")
+	_, err = applyDelta(base, []byte{11, 5, 3, 'a'})
+	assert.Error(t, err, "truncated insert")
+	_, err = applyDelta(base, []byte{11, 5, 0})
+	assert.Error(t, err, "reserved instruction")
+	_, err = applyDelta(base, []byte{11, 3, 0x80 | 0x01 | 0x10, 6, 5})
+	assert.Error(t, err, "building more than the target size")
+	_, err = applyDelta(base, []byte{11, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f, 0x80 | 0x01 | 0x10, 6, 5})
+	assert.EqualError(t, err, "delta of 3 bytes cannot build 72057594037927935 bytes")
+}
+
+func TestReadingEntriesRejectsSizesThePackCannotHold(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pack-corrupt.pack")
+	// a blob claiming a size of 2^32 - 1 bytes, followed by the compressed form of an empty one
+	entry := []byte{0x80 | 0x30 | 0x0f, 0xff, 0xff, 0xff, 0x7f, 0x78, 0x9c, 0x03, 0x00, 0x00, 0x00, 0x00, 0x01}
+	if !assert.NoError(t, os.WriteFile(path, append([]byte("PACK\x00\x00\x00\x02\x00\x00\x00\x01"), entry...), 0644)) {
+		return
+	}
+	file, err := os.Open(path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer file.Close()
+	pack := &packfile{path: path, file: file, size: int64(12 + len(entry))}
+
+	_, _, _, _, err = pack.readEntry(12)
+	assert.EqualError(t, err, fmt.Sprintf("corrupt pack %s at offset 12: object of 4294967295 bytes cannot be held in the 13 bytes left in the pack", path))
+	_, _, _, _, err = pack.readEntry(uint64(pack.size))
+	assert.Error(t, err, "offset beyond the end of the pack")
+}
@@ -0,0 +1,136 @@
+package gitobject
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	indexExtendedFlag = 0x4000
+	// indexEntryFixedSize is the size of the times, device, inode, mode, ids and size at the start of each entry
+	indexEntryFixedSize = 40
+)
+
+// IndexEntry is a path staged in the index
+type IndexEntry struct {
+	Path string
+	Hash string
+	Mode uint32
+	// Stage is 0 for a resolved path, or 1, 2 and 3 for the base, ours and theirs versions of a conflicted path
+	Stage int
+}
+
+// Index returns the entries of the index, in the order git keeps them, which is by path and then by stage
+func (r *Repository) Index() ([]IndexEntry, error) {
+	index, err := os.ReadFile(filepath.Join(r.gitDir, "index"))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseIndex(index, r.hashSize)
+	if err != nil {
+		return nil, fmt.Errorf("corrupt index: %v", err)
+	}
+	return entries, nil
+}
+
+// IndexEntry returns the resolved entry of the index for path
+func (r *Repository) IndexEntry(path string) (IndexEntry, error) {
+	entries, err := r.Index()
+	if err != nil {
+		return IndexEntry{}, err
+	}
+	for _, entry := range entries {
+		if entry.Path == path && entry.Stage == 0 {
+			return entry, nil
+		}
+	}
+	return IndexEntry{}, fmt.Errorf("%w: %s is not staged", ErrPathNotFound, path)
+}
+
+// parseIndex reads the entries of an index of version 2, 3 or 4, ignoring the extensions that follow them.
+// Versions 2 and 3 pad each entry with NULs to a multiple of 8 bytes; version 4 instead stores each path as the
+// number of bytes to drop from the end of the previous path and the bytes to append to what remains.
+func parseIndex(index []byte, hashSize int) ([]IndexEntry, error) {
+	if len(index) < 12 || string(index[:4]) != "DIRC" {
+		return nil, errors.New("invalid header")
+	}
+	version := binary.BigEndian.Uint32(index[4:8])
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("unsupported version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(index[8:12]))
+	entries := make([]IndexEntry, 0, count)
+	position := 12
+	previousPath := ""
+	for i := 0; i < count; i++ {
+		start := position
+		if len(index) < position+indexEntryFixedSize+hashSize+2 {
+			return nil, errors.New("truncated entry")
+		}
+		mode := binary.BigEndian.Uint32(index[position+24:])
+		position += indexEntryFixedSize
+		hash := hex.EncodeToString(index[position : position+hashSize])
+		position += hashSize
+		flags := binary.BigEndian.Uint16(index[position:])
+		position += 2
+		if version >= 3 && flags&indexExtendedFlag != 0 {
+			position += 2
+		}
+		var path string
+		if version == 4 {
+			drop, read, err := indexVarint(index[min(position, len(index)):])
+			if err != nil {
+				return nil, err
+			}
+			position += read
+			if drop > uint64(len(previousPath)) {
+				return nil, fmt.Errorf("entry drops %d bytes of a %d byte path", drop, len(previousPath))
+			}
+			suffixEnd := bytes.IndexByte(index[min(position, len(index)):], 0)
+			if suffixEnd < 0 {
+				return nil, errors.New("unterminated path")
+			}
+			path = previousPath[:len(previousPath)-int(drop)] + string(index[position:position+suffixEnd])
+			position += suffixEnd + 1
+		} else {
+			nameEnd := bytes.IndexByte(index[min(position, len(index)):], 0)
+			if nameEnd < 0 {
+				return nil, errors.New("unterminated path")
+			}
+			path = string(index[position : position+nameEnd])
+			position += nameEnd + 1
+			// the NUL ending the path is part of the padding, which brings the entry to a multiple of 8 bytes
+			position = start + (position-start+7)/8*8
+		}
+		previousPath = path
+		entries = append(entries, IndexEntry{Path: path, Hash: hash, Mode: mode, Stage: int(flags>>12) & 0x3})
+	}
+	return entries, nil
+}
+
+// indexVarint reads a number in the encoding of version 4 indexes, in which each continuation also adds one
+func indexVarint(data []byte) (uint64, int, error) {
+	if len(data) == 0 {
+		return 0, 0, errors.New("truncated path prefix length")
+	}
+	c := data[0]
+	value := uint64(c & 0x7f)
+	read := 1
+	for c&0x80 != 0 {
+		if read >= len(data) || read > 9 {
+			return 0, 0, errors.New("truncated path prefix length")
+		}
+		c = data[read]
+		read++
+		value = ((value + 1) << 7) | uint64(c&0x7f)
+	}
+	return value, read, nil
+}
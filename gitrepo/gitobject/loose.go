@@ -0,0 +1,55 @@
+package gitobject
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// readLooseObject reads an object stored in a file of its own, compressed along with a "<type> <size>\0" header
+func readLooseObject(objectDir string, hash string) (ObjectType, []byte, error) {
+	file, err := os.Open(filepath.Join(objectDir, hash[:2], hash[2:]))
+	if os.IsNotExist(err) {
+		return 0, nil, ErrObjectNotFound
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	defer file.Close()
+	decompressor, err := zlib.NewReader(file)
+	if err != nil {
+		return 0, nil, fmt.Errorf("corrupt loose object: %v", err)
+	}
+	defer decompressor.Close()
+	data, err := io.ReadAll(decompressor)
+	if err != nil {
+		return 0, nil, fmt.Errorf("corrupt loose object: %v", err)
+	}
+	headerEnd := bytes.IndexByte(data, 0)
+	if headerEnd < 0 {
+		return 0, nil, fmt.Errorf("corrupt loose object: no header")
+	}
+	typeName, sizeText, _ := bytes.Cut(data[:headerEnd], []byte(" "))
+	objectType, err := parseObjectType(string(typeName))
+	if err != nil {
+		return 0, nil, err
+	}
+	contents := data[headerEnd+1:]
+	if size, err := strconv.Atoi(string(sizeText)); err != nil || size != len(contents) {
+		return 0, nil, fmt.Errorf("corrupt loose object: header gives size %q for %d bytes", sizeText, len(contents))
+	}
+	return objectType, contents, nil
+}
+
+func decodeHash(hash string) ([]byte, error) {
+	decoded, err := hex.DecodeString(hash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid object hash: %v", err)
+	}
+	return decoded, nil
+}
@@ -0,0 +1,173 @@
+package gitobject
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// maxTagDepth bounds the chains of tags pointing to tags that are peeled
+const maxTagDepth = 16
+
+// TreeEntry is a file, directory or submodule in a tree
+type TreeEntry struct {
+	Name string
+	Mode uint32
+	Hash string
+}
+
+// IsTree tells whether the entry is a directory
+func (e TreeEntry) IsTree() bool {
+	return e.Mode&0170000 == 0040000
+}
+
+// IsSubmodule tells whether the entry is a submodule, whose hash names a commit of another repository
+func (e TreeEntry) IsSubmodule() bool {
+	return e.Mode&0170000 == 0160000
+}
+
+// ReadBlob returns the contents of the blob with the given hash
+func (r *Repository) ReadBlob(hash string) ([]byte, error) {
+	return r.readTyped(hash, BlobObject)
+}
+
+// ReadTree returns the entries of the tree with the given hash
+func (r *Repository) ReadTree(hash string) ([]TreeEntry, error) {
+	contents, err := r.readTyped(hash, TreeObject)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := parseTree(contents, r.hashSize)
+	if err != nil {
+		return nil, &ObjectError{hash, err}
+	}
+	return entries, nil
+}
+
+// ReadPath returns the contents of the file at path in the given revision, which is a hash or a ref naming a commit,
+// a tag or a tree
+func (r *Repository) ReadPath(revision string, path string) ([]byte, error) {
+	hash, err := r.ResolveRef(revision)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := r.peelToTree(hash)
+	if err != nil {
+		return nil, err
+	}
+	components := strings.Split(strings.Trim(path, "/"), "/")
+	for i, component := range components {
+		entries, err := r.ReadTree(tree)
+		if err != nil {
+			return nil, err
+		}
+		entry, found := findEntry(entries, component)
+		if !found {
+			return nil, fmt.Errorf("%w: %s in %s", ErrPathNotFound, path, revision)
+		}
+		if i == len(components)-1 {
+			if entry.IsTree() || entry.IsSubmodule() {
+				return nil, fmt.Errorf("%w: %s in %s is not a file", ErrPathNotFound, path, revision)
+			}
+			return r.ReadBlob(entry.Hash)
+		}
+		if !entry.IsTree() {
+			return nil, fmt.Errorf("%w: %s in %s", ErrPathNotFound, path, revision)
+		}
+		tree = entry.Hash
+	}
+	return nil, fmt.Errorf("%w: %s in %s", ErrPathNotFound, path, revision)
+}
+
+// ReadStaged returns the contents of the file staged at path
+func (r *Repository) ReadStaged(path string) ([]byte, error) {
+	entry, err := r.IndexEntry(path)
+	if err != nil {
+		return nil, err
+	}
+	return r.ReadBlob(entry.Hash)
+}
+
+func (r *Repository) readTyped(hash string, expected ObjectType) ([]byte, error) {
+	objectType, contents, err := r.ReadObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	if objectType != expected {
+		return nil, &ObjectError{hash, fmt.Errorf("expected a %s, found a %s", expected, objectType)}
+	}
+	return contents, nil
+}
+
+// peelToTree follows tags to the object they point to and commits to their tree, until reaching a tree
+func (r *Repository) peelToTree(hash string) (string, error) {
+	for depth := 0; depth < maxTagDepth; depth++ {
+		objectType, contents, err := r.ReadObject(hash)
+		if err != nil {
+			return "", err
+		}
+		switch objectType {
+		case TreeObject:
+			return hash, nil
+		case CommitObject:
+			return headerValue(contents, "tree", hash)
+		case TagObject:
+			if hash, err = headerValue(contents, "object", hash); err != nil {
+				return "", err
+			}
+		default:
+			return "", &ObjectError{hash, fmt.Errorf("a %s has no tree", objectType)}
+		}
+	}
+	return "", &ObjectError{hash, errors.New("tags nested too deeply")}
+}
+
+// headerValue returns the value of a header of a commit or a tag, which come before the first empty line
+func headerValue(contents []byte, name string, hash string) (string, error) {
+	for _, line := range strings.Split(string(contents), "\n") {
+		if line == "" {
+			break
+		}
+		if value, found := strings.CutPrefix(line, name+" "); found {
+			return value, nil
+		}
+	}
+	return "", &ObjectError{hash, fmt.Errorf("no %s header", name)}
+}
+
+// parseTree reads the entries of a tree, each of which is "<octal mode> <name>\0" followed by the hash in binary
+func parseTree(contents []byte, hashSize int) ([]TreeEntry, error) {
+	var entries []TreeEntry
+	for len(contents) > 0 {
+		space := bytes.IndexByte(contents, ' ')
+		nul := bytes.IndexByte(contents, 0)
+		if space < 0 || nul < space || len(contents) < nul+1+hashSize {
+			return nil, errors.New("corrupt tree entry")
+		}
+		var mode uint32
+		for _, digit := range contents[:space] {
+			if digit < '0' || digit > '7' {
+				return nil, fmt.Errorf("corrupt tree entry mode %q", contents[:space])
+			}
+			mode = mode<<3 | uint32(digit-'0')
+		}
+		entries = append(entries, TreeEntry{
+			Name: string(contents[space+1 : nul]),
+			Mode: mode,
+			Hash: hex.EncodeToString(contents[nul+1 : nul+1+hashSize]),
+		})
+		contents = contents[nul+1+hashSize:]
+	}
+	return entries, nil
+}
+
+func findEntry(entries []TreeEntry, name string) (TreeEntry, bool) {
+	for _, entry := range entries {
+		if entry.Name == name {
+			return entry, true
+		}
+	}
+	return TreeEntry{}, false
+}
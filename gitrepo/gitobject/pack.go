@@ -0,0 +1,392 @@
+package gitobject
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	packIndexV2Magic = "\377tOc"
+	// maxDeltaDepth bounds the chains of deltas followed, so that a corrupt pack cannot loop forever
+	maxDeltaDepth = 10000
+	// maxCachedBases is the number of delta bases kept per pack, as the same bases are read for consecutive versions of a file
+	maxCachedBases = 256
+	// maxInflation is the most that deflate can expand data by, which bounds the size of an object in what is left of a pack
+	maxInflation = 1032
+	// maxCopyPerDeltaByte bounds the bytes a delta builds per byte of its instructions, as a copy of up to 0xff0000
+	// bytes takes two bytes and a copy of up to 0xffffff bytes takes four
+	maxCopyPerDeltaByte = 0x800000
+)
+
+// packfile is a pack of objects along with the index of their offsets in it
+type packfile struct {
+	path     string
+	file     *os.File
+	size     int64
+	hashSize int
+	// fanout holds, for each first byte of a hash, the number of objects whose hashes start with a byte up to it
+	fanout  [256]uint32
+	hashes  []byte
+	offsets []uint64
+
+	mutex sync.Mutex
+	bases map[uint64]cachedObject
+}
+
+type cachedObject struct {
+	objectType ObjectType
+	contents   []byte
+}
+
+func openPack(indexPath string, hashSize int) (*packfile, error) {
+	index, err := os.ReadFile(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	pack := &packfile{path: strings.TrimSuffix(indexPath, ".idx") + ".pack", hashSize: hashSize, bases: map[uint64]cachedObject{}}
+	if bytes.HasPrefix(index, []byte(packIndexV2Magic)) {
+		err = pack.readIndexV2(index)
+	} else {
+		err = pack.readIndexV1(index)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("corrupt pack index %s: %v", indexPath, err)
+	}
+	pack.file, err = os.Open(pack.path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := pack.file.Stat()
+	if err != nil {
+		pack.file.Close()
+		return nil, err
+	}
+	pack.size = info.Size()
+	header := make([]byte, 12)
+	if _, err := pack.file.ReadAt(header, 0); err != nil || string(header[:4]) != "PACK" {
+		pack.file.Close()
+		return nil, fmt.Errorf("corrupt pack %s: invalid header", pack.path)
+	}
+	if version := binary.BigEndian.Uint32(header[4:8]); version != 2 && version != 3 {
+		pack.file.Close()
+		return nil, fmt.Errorf("unsupported pack version %d in %s", version, pack.path)
+	}
+	return pack, nil
+}
+
+func (p *packfile) readFanout(fanout []byte) (int, error) {
+	if len(fanout) < 256*4 {
+		return 0, errors.New("truncated fanout table")
+	}
+	for i := range p.fanout {
+		p.fanout[i] = binary.BigEndian.Uint32(fanout[i*4:])
+		if i > 0 && p.fanout[i] < p.fanout[i-1] {
+			return 0, errors.New("unsorted fanout table")
+		}
+	}
+	return int(p.fanout[255]), nil
+}
+
+// readIndexV1 reads an index made of the fanout table followed by a 4 byte offset and a hash for each object
+func (p *packfile) readIndexV1(index []byte) error {
+	count, err := p.readFanout(index)
+	if err != nil {
+		return err
+	}
+	entries := index[256*4:]
+	entrySize := 4 + p.hashSize
+	if len(entries) < count*entrySize {
+		return errors.New("truncated object table")
+	}
+	p.hashes = make([]byte, 0, count*p.hashSize)
+	p.offsets = make([]uint64, count)
+	for i := 0; i < count; i++ {
+		entry := entries[i*entrySize:]
+		p.offsets[i] = uint64(binary.BigEndian.Uint32(entry))
+		p.hashes = append(p.hashes, entry[4:entrySize]...)
+	}
+	return nil
+}
+
+// readIndexV2 reads an index made of a header, the fanout table, the hashes, their checksums, their 4 byte offsets and
+// the 8 byte offsets that do not fit in 31 bits
+func (p *packfile) readIndexV2(index []byte) error {
+	if len(index) < 8 {
+		return errors.New("truncated header")
+	}
+	if version := binary.BigEndian.Uint32(index[4:8]); version != 2 {
+		return fmt.Errorf("unsupported index version %d", version)
+	}
+	count, err := p.readFanout(index[8:])
+	if err != nil {
+		return err
+	}
+	hashesStart := 8 + 256*4
+	offsetsStart := hashesStart + count*p.hashSize + count*4
+	largeOffsetsStart := offsetsStart + count*4
+	if len(index) < largeOffsetsStart {
+		return errors.New("truncated object table")
+	}
+	p.hashes = index[hashesStart : hashesStart+count*p.hashSize]
+	p.offsets = make([]uint64, count)
+	for i := 0; i < count; i++ {
+		offset := binary.BigEndian.Uint32(index[offsetsStart+i*4:])
+		if offset&0x80000000 == 0 {
+			p.offsets[i] = uint64(offset)
+			continue
+		}
+		large := largeOffsetsStart + int(offset&0x7fffffff)*8
+		if len(index) < large+8 {
+			return errors.New("truncated large offset table")
+		}
+		p.offsets[i] = binary.BigEndian.Uint64(index[large:])
+	}
+	return nil
+}
+
+// find returns the offset in the pack of the object with the given hash
+func (p *packfile) find(hash []byte) (uint64, bool) {
+	low := 0
+	if hash[0] > 0 {
+		low = int(p.fanout[hash[0]-1])
+	}
+	high := int(p.fanout[hash[0]])
+	for low < high {
+		middle := (low + high) / 2
+		switch bytes.Compare(p.hashes[middle*p.hashSize:(middle+1)*p.hashSize], hash) {
+		case 0:
+			return p.offsets[middle], true
+		case -1:
+			low = middle + 1
+		default:
+			high = middle
+		}
+	}
+	return 0, false
+}
+
+func (p *packfile) close() error {
+	if p.file == nil {
+		return nil
+	}
+	return p.file.Close()
+}
+
+// readAt reads the object at offset, applying its chain of deltas when it is stored as a delta.
+// Bases given by hash rather than by offset may be in another pack or loose, so they are read through repo.
+func (p *packfile) readAt(repo *Repository, offset uint64) (ObjectType, []byte, error) {
+	var deltas [][]byte
+	var objectType ObjectType
+	var contents []byte
+	for depth := 0; ; depth++ {
+		if depth > maxDeltaDepth {
+			return 0, nil, fmt.Errorf("corrupt pack %s: delta chain longer than %d", p.path, maxDeltaDepth)
+		}
+		if cached, ok := p.cachedBase(offset); ok {
+			objectType, contents = cached.objectType, cached.contents
+			break
+		}
+		entryType, baseOffset, baseHash, data, err := p.readEntry(offset)
+		if err != nil {
+			return 0, nil, err
+		}
+		if entryType != offsetDelta && entryType != refDelta {
+			objectType, contents = entryType, data
+			p.cacheBase(offset, objectType, contents)
+			break
+		}
+		deltas = append(deltas, data)
+		if entryType == offsetDelta {
+			offset = baseOffset
+			continue
+		}
+		objectType, contents, err = repo.ReadObject(baseHash)
+		if err != nil {
+			return 0, nil, fmt.Errorf("reading delta base: %w", err)
+		}
+		break
+	}
+	for i := len(deltas) - 1; i >= 0; i-- {
+		var err error
+		contents, err = applyDelta(contents, deltas[i])
+		if err != nil {
+			return 0, nil, fmt.Errorf("corrupt pack %s: %v", p.path, err)
+		}
+	}
+	return objectType, contents, nil
+}
+
+func (p *packfile) cachedBase(offset uint64) (cachedObject, bool) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	cached, ok := p.bases[offset]
+	return cached, ok
+}
+
+func (p *packfile) cacheBase(offset uint64, objectType ObjectType, contents []byte) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if len(p.bases) >= maxCachedBases {
+		p.bases = map[uint64]cachedObject{}
+	}
+	p.bases[offset] = cachedObject{objectType, contents}
+}
+
+// readEntry reads the entry at offset, returning its type and decompressed data along with, for deltas, the offset
+// or the hash of their base
+func (p *packfile) readEntry(offset uint64) (ObjectType, uint64, string, []byte, error) {
+	corrupt := func(err error) (ObjectType, uint64, string, []byte, error) {
+		return 0, 0, "", nil, fmt.Errorf("corrupt pack %s at offset %d: %v", p.path, offset, err)
+	}
+	if offset >= uint64(p.size) {
+		return corrupt(fmt.Errorf("offset beyond the end of a pack of %d bytes", p.size))
+	}
+	remaining := uint64(p.size) - offset
+	reader := bufio.NewReader(io.NewSectionReader(p.file, int64(offset), int64(remaining)))
+	c, err := reader.ReadByte()
+	if err != nil {
+		return corrupt(err)
+	}
+	entryType := ObjectType((c >> 4) & 0x7)
+	size := uint64(c & 0x0f)
+	for shift := 4; c&0x80 != 0; shift += 7 {
+		if c, err = reader.ReadByte(); err != nil {
+			return corrupt(err)
+		}
+		size |= uint64(c&0x7f) << shift
+	}
+	var baseOffset uint64
+	var baseHash string
+	switch entryType {
+	case CommitObject, TreeObject, BlobObject, TagObject:
+	case offsetDelta:
+		// the distance back to the base, in a big-endian encoding where each continuation also adds one
+		if c, err = reader.ReadByte(); err != nil {
+			return corrupt(err)
+		}
+		distance := uint64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = reader.ReadByte(); err != nil {
+				return corrupt(err)
+			}
+			distance = ((distance + 1) << 7) | uint64(c&0x7f)
+		}
+		if distance == 0 || distance > offset {
+			return corrupt(fmt.Errorf("delta base offset %d out of range", distance))
+		}
+		baseOffset = offset - distance
+	case refDelta:
+		hash := make([]byte, p.hashSize)
+		if _, err := io.ReadFull(reader, hash); err != nil {
+			return corrupt(err)
+		}
+		baseHash = hex.EncodeToString(hash)
+	default:
+		return corrupt(fmt.Errorf("unknown object type %d", entryType))
+	}
+	if size > remaining*maxInflation {
+		return corrupt(fmt.Errorf("object of %d bytes cannot be held in the %d bytes left in the pack", size, remaining))
+	}
+	decompressor, err := zlib.NewReader(reader)
+	if err != nil {
+		return corrupt(err)
+	}
+	defer decompressor.Close()
+	data := make([]byte, size)
+	if _, err := io.ReadFull(decompressor, data); err != nil {
+		return corrupt(err)
+	}
+	return entryType, baseOffset, baseHash, data, nil
+}
+
+// applyDelta builds an object from its base and a delta, which gives the sizes of both followed by instructions
+// to either copy a range of the base or insert new data
+func applyDelta(base []byte, delta []byte) ([]byte, error) {
+	baseSize, delta, err := deltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+	if baseSize != uint64(len(base)) {
+		return nil, fmt.Errorf("delta expects a base of %d bytes, got %d", baseSize, len(base))
+	}
+	targetSize, delta, err := deltaSize(delta)
+	if err != nil {
+		return nil, err
+	}
+	if targetSize > uint64(len(delta))*maxCopyPerDeltaByte {
+		return nil, fmt.Errorf("delta of %d bytes cannot build %d bytes", len(delta), targetSize)
+	}
+	// the target is grown as it is built, so that a delta giving a size it does not build cannot exhaust memory
+	target := make([]byte, 0, min(targetSize, uint64(len(base)+len(delta))))
+	for len(delta) > 0 {
+		instruction := delta[0]
+		delta = delta[1:]
+		switch {
+		case instruction&0x80 != 0:
+			var offset, size uint64
+			for i := 0; i < 7; i++ {
+				if instruction&(1<<i) == 0 {
+					continue
+				}
+				if len(delta) == 0 {
+					return nil, errors.New("truncated delta copy instruction")
+				}
+				if i < 4 {
+					offset |= uint64(delta[0]) << (8 * i)
+				} else {
+					size |= uint64(delta[0]) << (8 * (i - 4))
+				}
+				delta = delta[1:]
+			}
+			if size == 0 {
+				size = 0x10000
+			}
+			if offset+size > uint64(len(base)) {
+				return nil, fmt.Errorf("delta copies %d bytes at %d beyond a base of %d bytes", size, offset, len(base))
+			}
+			if uint64(len(target))+size > targetSize {
+				return nil, fmt.Errorf("delta builds more than %d bytes", targetSize)
+			}
+			target = append(target, base[offset:offset+size]...)
+		case instruction != 0:
+			if int(instruction) > len(delta) {
+				return nil, errors.New("truncated delta insert instruction")
+			}
+			if uint64(len(target))+uint64(instruction) > targetSize {
+				return nil, fmt.Errorf("delta builds more than %d bytes", targetSize)
+			}
+			target = append(target, delta[:instruction]...)
+			delta = delta[instruction:]
+		default:
+			return nil, errors.New("invalid delta instruction 0")
+		}
+	}
+	if uint64(len(target)) != targetSize {
+		return nil, fmt.Errorf("delta built %d bytes instead of %d", len(target), targetSize)
+	}
+	return target, nil
+}
+
+func deltaSize(delta []byte) (uint64, []byte, error) {
+	var size uint64
+	for shift := 0; ; shift += 7 {
+		if len(delta) == 0 || shift > 63 {
+			return 0, nil, errors.New("truncated delta header")
+		}
+		c := delta[0]
+		delta = delta[1:]
+		size |= uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return size, delta, nil
+		}
+	}
+}
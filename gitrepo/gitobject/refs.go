@@ -0,0 +1,139 @@
+package gitobject
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// maxSymbolicRefDepth bounds the chains of symbolic refs followed, as git does
+const maxSymbolicRefDepth = 5
+
+// ResolveRef returns the hash of the object a full hash or a ref names, trying the same candidates as git rev-parse:
+// the name as given, then under refs/, refs/tags/, refs/heads/, refs/remotes/ and as refs/remotes/<name>/HEAD
+func (r *Repository) ResolveRef(name string) (string, error) {
+	if r.isHash(name) {
+		return name, nil
+	}
+	candidates := []string{name, "refs/" + name, "refs/tags/" + name, "refs/heads/" + name, "refs/remotes/" + name, "refs/remotes/" + name + "/HEAD"}
+	for _, candidate := range candidates {
+		hash, found, err := r.resolveSymbolic(candidate, 0)
+		if err != nil {
+			return "", err
+		}
+		if found {
+			return hash, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrRefNotFound, name)
+}
+
+// Refs returns the hash of every ref under refs/, by its full name
+func (r *Repository) Refs() (map[string]string, error) {
+	refs, err := r.packedRefs()
+	if err != nil {
+		return nil, err
+	}
+	refsDir := filepath.Join(r.commonDir, "refs")
+	err = filepath.WalkDir(refsDir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		relative, err := filepath.Rel(r.commonDir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(relative)
+		hash, found, err := r.resolveSymbolic(name, 0)
+		if err != nil {
+			return err
+		}
+		if found {
+			refs[name] = hash
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	return refs, nil
+}
+
+// resolveSymbolic resolves a ref by its full name, following symbolic refs such as HEAD
+func (r *Repository) resolveSymbolic(name string, depth int) (string, bool, error) {
+	if depth > maxSymbolicRefDepth {
+		return "", false, fmt.Errorf("%w: %s is a symbolic ref nested too deeply", ErrRefNotFound, name)
+	}
+	contents, found, err := r.readLooseRef(name)
+	if err != nil {
+		return "", false, err
+	}
+	if !found {
+		packed, err := r.packedRefs()
+		if err != nil {
+			return "", false, err
+		}
+		hash, found := packed[name]
+		return hash, found, nil
+	}
+	if target, isSymbolic := strings.CutPrefix(contents, "ref:"); isSymbolic {
+		return r.resolveSymbolic(strings.TrimSpace(target), depth+1)
+	}
+	if !r.isHash(contents) {
+		return "", false, fmt.Errorf("%w: %s holds %q", ErrRefNotFound, name, contents)
+	}
+	return contents, true, nil
+}
+
+// readLooseRef reads a ref from its own file. Refs such as HEAD belong to a worktree, and others are shared by all.
+func (r *Repository) readLooseRef(name string) (string, bool, error) {
+	if strings.Contains(name, "..") {
+		return "", false, nil
+	}
+	directory := r.commonDir
+	if !strings.HasPrefix(name, "refs/") || strings.HasPrefix(name, "refs/bisect/") {
+		directory = r.gitDir
+	}
+	contents, err := os.ReadFile(filepath.Join(directory, filepath.FromSlash(name)))
+	if os.IsNotExist(err) || isDirectoryError(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimSpace(string(contents)), true, nil
+}
+
+// packedRefs reads the refs that git gc and git pack-refs moved into the packed-refs file, skipping the peeled
+// hashes of annotated tags given on the lines starting with ^
+func (r *Repository) packedRefs() (map[string]string, error) {
+	refs := map[string]string{}
+	file, err := os.Open(filepath.Join(r.commonDir, "packed-refs"))
+	if os.IsNotExist(err) {
+		return refs, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "^") {
+			continue
+		}
+		hash, name, found := strings.Cut(line, " ")
+		if found && r.isHash(hash) {
+			refs[name] = hash
+		}
+	}
+	return refs, scanner.Err()
+}
+
+func isDirectoryError(err error) bool {
+	return errors.Is(err, syscall.EISDIR)
+}
@@ -0,0 +1,304 @@
+// Package gitobject reads git repositories directly from disk, without running git: loose objects, packfiles,
+// the index and refs.
+package gitobject
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ObjectType is the type of a git object
+type ObjectType int
+
+const (
+	CommitObject ObjectType = 1
+	TreeObject   ObjectType = 2
+	BlobObject   ObjectType = 3
+	TagObject    ObjectType = 4
+
+	// offsetDelta and refDelta are only found in packfiles, as the types of objects stored as changes to another object
+	offsetDelta ObjectType = 6
+	refDelta    ObjectType = 7
+)
+
+func (t ObjectType) String() string {
+	switch t {
+	case CommitObject:
+		return "commit"
+	case TreeObject:
+		return "tree"
+	case BlobObject:
+		return "blob"
+	case TagObject:
+		return "tag"
+	}
+	return fmt.Sprintf("unknown(%d)", int(t))
+}
+
+func parseObjectType(name string) (ObjectType, error) {
+	for _, objectType := range []ObjectType{CommitObject, TreeObject, BlobObject, TagObject} {
+		if objectType.String() == name {
+			return objectType, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown object type %q", name)
+}
+
+var (
+	// ErrNotARepository is returned when no git repository can be found
+	ErrNotARepository = errors.New("not a git repository")
+	// ErrObjectNotFound is returned when an object is in none of the object directories of a repository
+	ErrObjectNotFound = errors.New("object not found")
+	// ErrRefNotFound is returned when a ref or revision cannot be resolved
+	ErrRefNotFound = errors.New("ref not found")
+	// ErrPathNotFound is returned when a path is not in a tree or in the index
+	ErrPathNotFound = errors.New("path not found")
+)
+
+// ObjectError is an error reading a particular object
+type ObjectError struct {
+	Hash string
+	Err  error
+}
+
+func (e *ObjectError) Error() string {
+	return fmt.Sprintf("git object %s: %v", e.Hash, e.Err)
+}
+
+func (e *ObjectError) Unwrap() error {
+	return e.Err
+}
+
+// Repository reads the objects, refs and index of a git repository
+type Repository struct {
+	// gitDir holds HEAD and the index, which belong to a worktree
+	gitDir string
+	// commonDir holds the objects and refs shared by every worktree
+	commonDir  string
+	hashSize   int
+	objectDirs []string
+
+	mutex sync.Mutex
+	packs []*packfile
+	// packsSeen are the index files of the packs opened so far, by path
+	packsSeen map[string]bool
+}
+
+// Find opens the repository that path is in, looking for a .git directory, or a .git file pointing to one as in
+// worktrees and submodules, in path and in each of its parents. A path that is itself a git directory is opened as is.
+func Find(path string) (*Repository, error) {
+	directory, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if isGitDir(directory) {
+		return Open(directory)
+	}
+	for {
+		dotGit := filepath.Join(directory, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			if info.IsDir() {
+				return Open(dotGit)
+			}
+			gitDir, err := readGitFile(dotGit)
+			if err != nil {
+				return nil, err
+			}
+			return Open(gitDir)
+		}
+		parent := filepath.Dir(directory)
+		if parent == directory {
+			return nil, fmt.Errorf("%w: %s", ErrNotARepository, path)
+		}
+		directory = parent
+	}
+}
+
+// Open opens the repository whose git directory is gitDir
+func Open(gitDir string) (*Repository, error) {
+	if !isGitDir(gitDir) {
+		return nil, fmt.Errorf("%w: %s", ErrNotARepository, gitDir)
+	}
+	repo := &Repository{gitDir: gitDir, commonDir: gitDir, hashSize: 20, packsSeen: map[string]bool{}}
+	if commonDir, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		repo.commonDir = relativeTo(gitDir, strings.TrimSpace(string(commonDir)))
+	}
+	objectFormat, err := repo.configValue("extensions", "objectformat")
+	if err != nil {
+		return nil, err
+	}
+	switch objectFormat {
+	case "", "sha1":
+	case "sha256":
+		repo.hashSize = 32
+	default:
+		return nil, fmt.Errorf("unsupported object format %q", objectFormat)
+	}
+	repo.objectDirs = objectDirectories(filepath.Join(repo.commonDir, "objects"))
+	if err := repo.loadPacks(); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// Close closes the packfiles of the repository
+func (r *Repository) Close() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	var errs []error
+	for _, pack := range r.packs {
+		errs = append(errs, pack.close())
+	}
+	r.packs = nil
+	r.packsSeen = map[string]bool{}
+	return errors.Join(errs...)
+}
+
+// ReadObject returns the type and contents of the object with the given hash
+func (r *Repository) ReadObject(hash string) (ObjectType, []byte, error) {
+	if !r.isHash(hash) {
+		return 0, nil, &ObjectError{hash, fmt.Errorf("not a full object hash")}
+	}
+	objectType, contents, err := r.readObject(hash)
+	if errors.Is(err, ErrObjectNotFound) {
+		// the object may be in a pack written since the packs were loaded, such as after git gc
+		if loadErr := r.loadPacks(); loadErr != nil {
+			return 0, nil, &ObjectError{hash, loadErr}
+		}
+		objectType, contents, err = r.readObject(hash)
+	}
+	if err != nil {
+		return 0, nil, &ObjectError{hash, err}
+	}
+	return objectType, contents, nil
+}
+
+func (r *Repository) readObject(hash string) (ObjectType, []byte, error) {
+	for _, objectDir := range r.objectDirs {
+		objectType, contents, err := readLooseObject(objectDir, hash)
+		if !errors.Is(err, ErrObjectNotFound) {
+			return objectType, contents, err
+		}
+	}
+	hashBytes, err := decodeHash(hash)
+	if err != nil {
+		return 0, nil, err
+	}
+	r.mutex.Lock()
+	packs := r.packs
+	r.mutex.Unlock()
+	for _, pack := range packs {
+		if offset, found := pack.find(hashBytes); found {
+			return pack.readAt(r, offset)
+		}
+	}
+	return 0, nil, ErrObjectNotFound
+}
+
+// loadPacks opens the packs in every object directory that have not been opened yet
+func (r *Repository) loadPacks() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for _, objectDir := range r.objectDirs {
+		indexes, _ := filepath.Glob(filepath.Join(objectDir, "pack", "*.idx"))
+		for _, index := range indexes {
+			if r.packsSeen[index] {
+				continue
+			}
+			pack, err := openPack(index, r.hashSize)
+			if err != nil {
+				return err
+			}
+			r.packsSeen[index] = true
+			r.packs = append(r.packs, pack)
+		}
+	}
+	return nil
+}
+
+func (r *Repository) isHash(hash string) bool {
+	if len(hash) != r.hashSize*2 {
+		return false
+	}
+	for _, c := range hash {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// configValue reads a value from the config file of the repository, as a lower case string. Only the simple
+// "key = value" syntax of the sections without subsections is understood, which is enough for the settings read here.
+func (r *Repository) configValue(section string, key string) (string, error) {
+	file, err := os.Open(filepath.Join(r.commonDir, "config"))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	currentSection := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			currentSection = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if found && currentSection == section && strings.ToLower(strings.TrimSpace(name)) == key {
+			return strings.ToLower(strings.TrimSpace(value)), nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// objectDirectories returns the object directory along with the alternate object directories it borrows from
+func objectDirectories(objectDir string) []string {
+	directories := []string{objectDir}
+	alternates, err := os.ReadFile(filepath.Join(objectDir, "info", "alternates"))
+	if err != nil {
+		return directories
+	}
+	for _, alternate := range strings.Split(string(alternates), "\n") {
+		alternate = strings.TrimSpace(alternate)
+		if alternate != "" && !strings.HasPrefix(alternate, "#") {
+			directories = append(directories, relativeTo(objectDir, alternate))
+		}
+	}
+	return directories
+}
+
+func isGitDir(directory string) bool {
+	_, headErr := os.Stat(filepath.Join(directory, "HEAD"))
+	_, objectsErr := os.Stat(filepath.Join(directory, "objects"))
+	_, commonDirErr := os.Stat(filepath.Join(directory, "commondir"))
+	return headErr == nil && (objectsErr == nil || commonDirErr == nil)
+}
+
+// readGitFile reads the location of the git directory from a .git file, as in "gitdir: ../.git/worktrees/feature"
+func readGitFile(path string) (string, error) {
+	contents, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	gitDir, found := strings.CutPrefix(strings.TrimSpace(string(contents)), "gitdir:")
+	if !found {
+		return "", fmt.Errorf("%w: %s does not point to a git directory", ErrNotARepository, path)
+	}
+	return relativeTo(filepath.Dir(path), strings.TrimSpace(gitDir)), nil
+}
+
+func relativeTo(directory string, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(directory, path)
+}
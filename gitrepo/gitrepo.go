@@ -6,7 +6,6 @@ import (
 	"os/exec"
 	"path"
 	"path/filepath"
-	"regexp"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
@@ -24,6 +23,8 @@ type Addition struct {
 	Name    FileName
 	Commits []string
 	Data    []byte
+	// RenamedFrom is the path the file had before it was renamed, if git detected a rename
+	RenamedFrom FilePath
 }
 
 // GitRepo represents a Git repository located at the absolute path represented by root
@@ -42,59 +43,33 @@ func (repo GitRepo) Root() string {
 	return repo.root
 }
 
-// GetDiffForStagedFiles gets all the staged files and collects the diff section in each file
-func (repo GitRepo) GetDiffForStagedFiles() []Addition {
-	stagedContent := repo.executeRepoCommand("git", "diff", "--staged", "--src-prefix=a/", "--dst-prefix=b/")
-	content := strings.TrimSpace(string(stagedContent))
-	lines := strings.Split(content, "\n")
-	result := make([]Addition, 0)
-
-	if len(lines) < 1 {
-		return result
+// GetDiffForStagedFiles gets all the staged files along with the lines added to each of them.
+// Binary files are read whole, as their diffs hold no lines, and submodules are left out, as they hold no files of this repository.
+func (repo GitRepo) GetDiffForStagedFiles() ([]Addition, error) {
+	stagedDiff, err := repo.executeRepoCommand("git", "diff", "--staged", "--raw", "-z", "--patch", "-M", "-C", "--diff-filter=ACMR",
+		"--no-abbrev", "--no-color", "--no-ext-diff", "--no-textconv", "--submodule=short", "--src-prefix=a/", "--dst-prefix=b/")
+	if err != nil {
+		return nil, err
 	}
-
-	// Standard git diff header pattern
-	// ref: https://git-scm.com/docs/diff-format#_generating_patches_with_p
-
-	lineNumberOfFirstHeader := 0
-	var additionFilename string
-	for ; lineNumberOfFirstHeader < len(lines); lineNumberOfFirstHeader++ {
-		match, stagedFilename := MatchGitDiffLine(lines[lineNumberOfFirstHeader])
-		if match {
-			additionFilename = stagedFilename
-			break
-		}
+	entries, err := parseRawPatch(stagedDiff)
+	if err != nil {
+		log.Errorf("unable to parse the staged diff, checking whole staged files instead: %v", err)
+		return repo.StagedAdditions()
 	}
 
-	additionContentBuffer := &strings.Builder{}
-	for i := lineNumberOfFirstHeader + 1; i < len(lines); i++ {
-		match, stagedFilename := MatchGitDiffLine(lines[i])
-		if match {
-			// It is a new diff header
-			// which means we have reached the next file's header
-
-			// capture content written to buffer so far as addition content
-			stagedChanges := repo.extractAdditions(additionContentBuffer.String())
-			if stagedChanges != nil {
-				addition := NewAddition(additionFilename, stagedChanges)
-				result = append(
-					result, addition,
-				)
-			}
-
-			// get next file name and reset buffer for next iteration
-			additionFilename = stagedFilename
-			additionContentBuffer.Reset()
-		} else {
-			additionContentBuffer.WriteString(lines[i])
-			additionContentBuffer.WriteRune('\n')
+	result := make([]Addition, 0, len(entries))
+	for _, entry := range entries {
+		if entry.isSubmodule() {
+			log.Debugf("skipping staged submodule %s", entry.path)
+			continue
+		}
+		addition := NewAddition(entry.path, entry.added)
+		if entry.binary {
+			addition.Data = repo.readAdditionData(entry.path, GIT_STAGED_PREFIX)
+		}
+		if entry.status == "R" {
+			addition.RenamedFrom = FilePath(entry.oldPath)
 		}
-	}
-
-	// Save last file's diff content
-	stagedChanges := repo.extractAdditions(additionContentBuffer.String())
-	if stagedChanges != nil {
-		addition := NewAddition(additionFilename, stagedChanges)
 		result = append(result, addition)
 	}
 
@@ -102,54 +77,107 @@ func (repo GitRepo) GetDiffForStagedFiles() []Addition {
 		"additions": result,
 	}).Debug("Generating staged additions.")
 
-	return result
-}
-
-func MatchGitDiffLine(gitDiffString string) (bool, string) {
-	if strings.Contains(gitDiffString, "diff --git") {
-		fileNameLength := (len(gitDiffString) - len("diff --git a/ b/")) / 2
-		regexPattern := fmt.Sprintf("^diff --git a/(.{%v}) b/(.{%v})$", fileNameLength, fileNameLength)
-		headerRegex := regexp.MustCompile(regexPattern)
-
-		if headerRegex.MatchString(gitDiffString) {
-			matches := headerRegex.FindStringSubmatch(gitDiffString)
-			if matches[1] == matches[2] {
-				return true, matches[1]
-			}
-		}
-	}
-	return false, ""
+	return result, nil
 }
 
 // StagedAdditions returns the files staged for commit in a GitRepo
-func (repo GitRepo) StagedAdditions() []Addition {
-	files := repo.stagedFiles()
-	result := make([]Addition, len(files))
-	for i, file := range files {
-		data, _ := repo.readRepoFile(file, GIT_STAGED_PREFIX)
-		result[i] = NewAddition(file, data)
+func (repo GitRepo) StagedAdditions() ([]Addition, error) {
+	changes, err := repo.stagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Addition, len(changes))
+	for i, change := range changes {
+		result[i] = NewAddition(change.path, repo.readAdditionData(change.path, GIT_STAGED_PREFIX))
+		result[i].RenamedFrom = FilePath(change.renamedFrom)
 	}
 
 	log.WithFields(log.Fields{
 		"additions": result,
 	}).Info("Generating staged additions.")
-	return result
+	return result, nil
+}
+
+// StagedRenames returns the paths of files renamed in the index, keyed by their new path
+func (repo GitRepo) StagedRenames() (map[FilePath]FilePath, error) {
+	changes, err := repo.stagedFiles()
+	if err != nil {
+		return nil, err
+	}
+	renames := make(map[FilePath]FilePath)
+	for _, change := range changes {
+		if change.renamedFrom != "" {
+			renames[FilePath(change.path)] = FilePath(change.renamedFrom)
+		}
+	}
+	return renames, nil
 }
 
 // AdditionsWithinRange returns the outgoing additions and modifications in a GitRepo that are in the given commit range. This does not include files that were deleted.
-func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) []Addition {
-	files := repo.outgoingNonDeletedFiles(oldCommit, newCommit)
-	result := make([]Addition, len(files))
-	for i, file := range files {
-		data, _ := repo.readRepoFile(file, GIT_HEAD_PREFIX)
-		result[i] = NewAddition(file, data)
+// Submodules are left out, as their files are not part of this repository.
+// Files whose contents a partial clone left out are not fetched, and are left out with a warning.
+func (repo GitRepo) AdditionsWithinRange(oldCommit string, newCommit string) ([]Addition, error) {
+	additions, skipped, err := repo.AvailableAdditionsWithinRange(oldCommit, newCommit)
+	if len(skipped) > 0 {
+		log.Warnf("the contents of %v were not fetched by this partial clone, so they are not checked", skipped)
+	}
+	return additions, err
+}
+
+// AvailableAdditionsWithinRange returns the additions in the given commit range like AdditionsWithinRange, along with
+// the paths of the files it left out because a partial clone has not fetched their contents.
+// Renames are not detected when files are left out, as git would fetch the missing contents to compare them.
+func (repo GitRepo) AvailableAdditionsWithinRange(oldCommit string, newCommit string) ([]Addition, []FilePath, error) {
+	missingBlobs, err := repo.missingBlobsWithinRange(oldCommit, newCommit)
+	if err != nil {
+		return nil, nil, err
+	}
+	changes, err := repo.outgoingNonDeletedFiles(oldCommit, newCommit, len(missingBlobs) == 0)
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make([]Addition, 0, len(changes))
+	var skipped []FilePath
+	for _, change := range changes {
+		if missingBlobs[change.path] {
+			skipped = append(skipped, FilePath(change.path))
+			continue
+		}
+		addition := NewAddition(change.path, repo.readAdditionData(change.path, GIT_HEAD_PREFIX))
+		addition.RenamedFrom = FilePath(change.renamedFrom)
+		result = append(result, addition)
 	}
 	log.WithFields(log.Fields{
 		"oldCommit": oldCommit,
 		"newCommit": newCommit,
 		"additions": result,
+		"skipped":   skipped,
 	}).Info("Generating all additions in range.")
-	return result
+	return result, skipped, nil
+}
+
+// missingBlobsWithinRange returns the paths in newCommit whose contents were left out by a partial clone, among the
+// files changed since oldCommit. Other repositories have none.
+func (repo GitRepo) missingBlobsWithinRange(oldCommit string, newCommit string) (map[string]bool, error) {
+	if !repo.IsPartial() {
+		return nil, nil
+	}
+	missing, err := repo.MissingObjects(newCommit, "--not", oldCommit)
+	if err != nil || len(missing) == 0 {
+		return nil, err
+	}
+	tree, err := repo.executeRepoCommand("git", "ls-tree", "-r", "-z", "--full-tree", newCommit)
+	if err != nil {
+		return nil, err
+	}
+	missingPaths := map[string]bool{}
+	for _, entry := range strings.Split(string(tree), "\x00") {
+		header, path, found := strings.Cut(entry, "\t")
+		if fields := strings.Fields(header); found && len(fields) == 3 && missing[fields[2]] {
+			missingPaths[path] = true
+		}
+	}
+	return missingPaths, nil
 }
 
 // NewAddition returns a new Addition for a file with supplied name and contents
@@ -214,6 +242,14 @@ func (a Addition) Matches(pattern string) bool {
 	return result
 }
 
+// RenamedFromMatches reports whether the path the addition was renamed from matches the given pattern, in the same way as Matches
+func (a Addition) RenamedFromMatches(pattern string) bool {
+	if a.RenamedFrom == "" {
+		return false
+	}
+	return NewAddition(string(a.RenamedFrom), nil).Matches(pattern)
+}
+
 // NameMatches reports whether the basename of the Addition matches the given pattern
 func (a Addition) NameMatches(pattern string) bool {
 	result, _ := path.Match(pattern, string(a.Name))
@@ -221,111 +257,101 @@ func (a Addition) NameMatches(pattern string) bool {
 }
 
 // TrackedFilesAsAdditions returns all of the tracked files in a GitRepo as Additions
-func (repo GitRepo) TrackedFilesAsAdditions() []Addition {
-	trackedFilePaths := repo.trackedFilePaths()
+func (repo GitRepo) TrackedFilesAsAdditions() ([]Addition, error) {
+	trackedFilePaths, err := repo.trackedFilePaths()
+	if err != nil {
+		return nil, err
+	}
 	var additions []Addition
 	for _, path := range trackedFilePaths {
 		additions = append(additions, NewAddition(path, make([]byte, 0)))
 	}
-	return additions
+	return additions, nil
 }
 
-func (repo GitRepo) trackedFilePaths() []string {
-	branchName := repo.currentBranch()
-	if len(branchName) == 0 {
-		return make([]string, 0)
+// trackedFilePaths lists the files committed at HEAD, which there are none of in a repository with no commits yet
+func (repo GitRepo) trackedFilePaths() ([]string, error) {
+	if !repo.HasCommits() {
+		return make([]string, 0), nil
+	}
+	byteArray, err := repo.executeRepoCommand("git", "ls-tree", "HEAD", "--name-only", "-r")
+	if err != nil {
+		return nil, err
 	}
-	byteArray := repo.executeRepoCommand("git", "ls-tree", branchName, "--name-only", "-r")
 	trackedFilePaths := strings.Split(string(byteArray), "\n")
-	return trackedFilePaths
+	return trackedFilePaths, nil
 }
 
-func (repo GitRepo) stagedFiles() []string {
-	stagedFiles := strings.Split(repo.fetchStagedChanges(), "\n")
-	var result []string
-	for _, c := range stagedFiles {
-		if len(c) != 0 {
-			changeTypeAndFile := strings.Split(c, "\t")
-			if len(changeTypeAndFile) > 0 {
-				file := changeTypeAndFile[1]
-				result = append(result, file)
-			}
-		}
-	}
-	return result
+func (repo GitRepo) stagedFiles() ([]fileChange, error) {
+	stagedChanges, err := repo.fetchStagedChanges()
+	return parseNameStatus(stagedChanges), err
 }
 
-func (repo GitRepo) currentBranch() string {
-	if !repo.hasBranch() {
-		return ""
-	}
-	byteArray := repo.executeRepoCommand("git", "rev-parse", "--abbrev-ref", "HEAD")
-	branchName := strings.TrimSpace(string(byteArray))
-	return branchName
+// fileChange is a file reported by git diff --name-status, along with the path it was renamed from, if any
+type fileChange struct {
+	path        string
+	renamedFrom string
 }
 
-func (repo GitRepo) hasBranch() bool {
-	byteArray := repo.executeRepoCommand("git", "branch")
-	return len(string(byteArray)) != 0
+func (repo GitRepo) outgoingNonDeletedFiles(oldCommit, newCommit string, detectRenames bool) ([]fileChange, error) {
+	outgoingDiff, err := repo.fetchRawOutgoingDiff(oldCommit, newCommit, detectRenames)
+	return parseNameStatus(outgoingDiff), err
 }
 
-func (repo GitRepo) outgoingNonDeletedFiles(oldCommit, newCommit string) []string {
-	allChanges := strings.Split(repo.fetchRawOutgoingDiff(oldCommit, newCommit), "\n")
-	var result []string
-	for _, c := range allChanges {
-		if len(c) != 0 {
-			result = append(result, c)
+// parseNameStatus reads the output of git diff --name-status -z: a NUL-terminated status for each file, followed by
+// its path, or by its previous path and its path when it was renamed. Paths are read verbatim rather than quoted.
+func parseNameStatus(nameStatus []byte) []fileChange {
+	var result []fileChange
+	records := strings.Split(string(nameStatus), "\x00")
+	for i := 0; i+1 < len(records); i++ {
+		status := records[i]
+		switch {
+		case status == "":
+		case strings.HasPrefix(status, "R"):
+			if i+2 < len(records) {
+				result = append(result, fileChange{path: records[i+2], renamedFrom: records[i+1]})
+			}
+			i += 2
+		default:
+			result = append(result, fileChange{path: records[i+1]})
+			i++
 		}
 	}
 	return result
 }
 
-func (repo *GitRepo) fetchStagedChanges() string {
-	return string(repo.executeRepoCommand("git", "diff", "--cached", "--name-status", "--diff-filter=ACM"))
-}
-
-// extractAdditions will accept git diff --staged {file} output and filters the command output
-// to get only the modified sections of the file
-func (repo *GitRepo) extractAdditions(diffContent string) []byte {
-	var result []byte
-	changes := strings.Split(diffContent, "\n")
-	for _, c := range changes {
-		if !strings.HasPrefix(c, "+++") && !strings.HasPrefix(c, "---") && strings.HasPrefix(c, "+") {
-
-			result = append(result, strings.TrimPrefix(c, "+")...)
-			result = append(result, "\n"...)
-		}
-	}
-	return result
+func (repo *GitRepo) fetchStagedChanges() ([]byte, error) {
+	return repo.executeRepoCommand("git", "diff", "--cached", "-M", "--name-status", "-z", "--diff-filter=ACMR")
 }
 
-func (repo GitRepo) fetchRawOutgoingDiff(oldCommit string, newCommit string) string {
+func (repo GitRepo) fetchRawOutgoingDiff(oldCommit string, newCommit string, detectRenames bool) ([]byte, error) {
 	gitRange := oldCommit + ".." + newCommit
-	return string(repo.executeRepoCommand("git", "diff", gitRange, "--name-only", "--diff-filter=ACM"))
+	renames := "-M"
+	if !detectRenames {
+		renames = "--no-renames"
+	}
+	return repo.executeRepoCommand("git", "diff", gitRange, renames, "--name-status", "-z", "--diff-filter=ACMR", "--ignore-submodules=all")
 }
 
-func (repo GitRepo) executeRepoCommand(commandName string, args ...string) []byte {
+// executeRepoCommand runs a git command in the repository and returns its output, or a *GitError if it fails
+func (repo GitRepo) executeRepoCommand(commandName string, args ...string) ([]byte, error) {
 	log.WithFields(log.Fields{
 		"command": commandName,
 		"args":    args,
 	}).Debug("Building repo command")
-	co, err := repo.rawExecuteRepoCommand(commandName, args...)
+	co, err := repo.makeRepoCommand(commandName, args...).Output()
 	logEntry := log.WithFields(log.Fields{
 		"dir":     repo.root,
 		"command": fmt.Sprintf("%s %s", commandName, strings.Join(args, " ")),
 		"output":  string(co),
 		"error":   err,
 	})
-	if err == nil {
-		logEntry.Debug("Git command executed successfully")
-	} else {
-		logEntry.Fatal("Git command execution failed")
+	if err != nil {
+		logEntry.Debug("Git command execution failed")
+		return nil, repo.CommandError(args, err)
 	}
-	return co
-}
-
-func (repo GitRepo) rawExecuteRepoCommand(commandName string, args ...string) ([]byte, error) {
-	return repo.makeRepoCommand(commandName, args...).CombinedOutput()
+	logEntry.Debug("Git command executed successfully")
+	return co, nil
 }
 
 func (repo GitRepo) makeRepoCommand(commandName string, args ...string) *exec.Cmd {
@@ -337,6 +363,20 @@ func (repo GitRepo) makeRepoCommand(commandName string, args ...string) *exec.Cm
 func (repo GitRepo) readRepoFile(fileName, prefix string) ([]byte, error) {
 	path := filepath.Join(repo.root, fileName)
 	log.Debugf("reading file %s", path)
+	if nativeReads {
+		data, err := readNatively(repo.root, fileName, prefix)
+		return data, repo.ObjectError(err)
+	}
 	fileExpression := fmt.Sprintf("%s:%s", prefix, fileName)
-	return repo.rawExecuteRepoCommand("git", "cat-file", "-p", fileExpression)
+	return repo.executeRepoCommand("git", "cat-file", "-p", fileExpression)
+}
+
+// readAdditionData reads the contents of a changed file. Files that cannot be read, such as submodules, which have no
+// contents in this repository, are checked without contents.
+func (repo GitRepo) readAdditionData(fileName, prefix string) []byte {
+	data, err := repo.readRepoFile(fileName, prefix)
+	if err != nil {
+		log.Warnf("unable to read the contents of %s: %v", fileName, err)
+	}
+	return data
 }
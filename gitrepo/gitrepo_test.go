@@ -19,8 +19,26 @@ func init() {
 	git_testing.Logger.Debug("GitRepo test started")
 }
 
-func (repo GitRepo) additionsInLastCommit() []Addition {
-	return repo.AdditionsWithinRange("HEAD~1", "HEAD")
+func (repo GitRepo) additionsInLastCommit(t *testing.T) []Addition {
+	return additionsWithinRange(t, repo, "HEAD~1", "HEAD")
+}
+
+func additionsWithinRange(t *testing.T, repo GitRepo, oldCommit string, newCommit string) []Addition {
+	additions, err := repo.AdditionsWithinRange(oldCommit, newCommit)
+	assert.NoError(t, err)
+	return additions
+}
+
+func stagedAdditionsOf(t *testing.T, repo GitRepo) []Addition {
+	additions, err := repo.StagedAdditions()
+	assert.NoError(t, err)
+	return additions
+}
+
+func stagedDiffOf(t *testing.T, repo GitRepo) []Addition {
+	additions, err := repo.GetDiffForStagedFiles()
+	assert.NoError(t, err)
+	return additions
 }
 
 func TestNewRepoGetsCreatedWithAbsolutePath(t *testing.T) {
@@ -31,7 +49,7 @@ func TestNewRepoGetsCreatedWithAbsolutePath(t *testing.T) {
 
 func TestNoAdditionsBetweenSameRef(t *testing.T) {
 	doInRepoWithCommit(func(git *git_testing.GitTesting) {
-		assert.Len(t, RepoLocatedAt(git.Root()).AdditionsWithinRange("HEAD", "HEAD"), 0,
+		assert.Len(t, additionsWithinRange(t, RepoLocatedAt(git.Root()), "HEAD", "HEAD"), 0,
 			"There should be no additions between a ref and itself.")
 	})
 }
@@ -43,7 +61,7 @@ func TestGetDiffForStagedFiles(t *testing.T) {
 		git.Add("a.txt")
 		git.Add("new.txt")
 		repo := RepoLocatedAt(git.Root())
-		additions := repo.GetDiffForStagedFiles()
+		additions := stagedDiffOf(t, repo)
 
 		if assert.Len(t, additions, 2) {
 			modifiedAddition := additions[0]
@@ -81,7 +99,7 @@ func TestGetDiffForStagedFilesWithSpacesInPath(t *testing.T) {
 		git.AppendFileContent("folder b/c.txt", "New content.\n", "Spanning multiple lines, even.")
 		git.Add("folder b/c.txt")
 		repo := RepoLocatedAt(git.Root())
-		additions := repo.GetDiffForStagedFiles()
+		additions := stagedDiffOf(t, repo)
 
 		if assert.Len(t, additions, 1) {
 			modifiedAddition := additions[0]
@@ -109,7 +127,7 @@ func TestAdditionsReturnsEditsAndAdds(t *testing.T) {
 		git.CreateFileWithContents("new.txt", "created contents")
 		git.AddAndcommit("*", "added to lorem-ipsum content with my own stuff!")
 
-		additions := RepoLocatedAt(git.Root()).additionsInLastCommit()
+		additions := RepoLocatedAt(git.Root()).additionsInLastCommit(t)
 		assert.Len(t, additions, 2)
 		assert.True(t, strings.HasSuffix(string(additions[0].Data), "New content.\nSpanning multiple lines, even."))
 	})
@@ -120,7 +138,7 @@ func TestNewlyAddedFilesAreCountedAsChanges(t *testing.T) {
 		git.CreateFileWithContents("h", "Hello")
 		git.CreateFileWithContents("foo/bar/w", ", World!")
 		git.AddAndcommit("*", "added hello world")
-		assert.Len(t, RepoLocatedAt(git.Root()).additionsInLastCommit(), 2)
+		assert.Len(t, RepoLocatedAt(git.Root()).additionsInLastCommit(t), 2)
 	})
 }
 
@@ -129,8 +147,8 @@ func TestOutgoingContentOfNewlyAddedFilesIsAvailableInChanges(t *testing.T) {
 		git.CreateFileWithContents("foo/bar/w", "new contents")
 		git.AddAndcommit("*", "added new files")
 		repo := RepoLocatedAt(git.Root())
-		assert.Len(t, repo.additionsInLastCommit(), 1)
-		assert.True(t, strings.HasSuffix(string(repo.AdditionsWithinRange("HEAD~1", "HEAD")[0].Data), "new contents"))
+		assert.Len(t, repo.additionsInLastCommit(t), 1)
+		assert.True(t, strings.HasSuffix(string(additionsWithinRange(t, repo, "HEAD~1", "HEAD")[0].Data), "new contents"))
 	})
 }
 
@@ -139,8 +157,8 @@ func TestOutgoingContentOfModifiedFilesIsAvailableInChanges(t *testing.T) {
 		git.AppendFileContent("a.txt", "New content.\n", "Spanning multiple lines, even.")
 		git.AddAndcommit("a.txt", "added to lorem-ipsum content with my own stuff!")
 		repo := RepoLocatedAt(git.Root())
-		assert.Len(t, repo.additionsInLastCommit(), 1)
-		assert.True(t, strings.HasSuffix(string(repo.additionsInLastCommit()[0].Data), "New content.\nSpanning multiple lines, even."))
+		assert.Len(t, repo.additionsInLastCommit(t), 1)
+		assert.True(t, strings.HasSuffix(string(repo.additionsInLastCommit(t)[0].Data), "New content.\nSpanning multiple lines, even."))
 	})
 }
 
@@ -153,8 +171,8 @@ func TestMultipleOutgoingChangesToTheSameFileAreAvailableInAdditions(t *testing.
 		git.AddAndcommit("a.txt", "added some more new content")
 
 		repo := RepoLocatedAt(git.Root())
-		assert.Len(t, repo.additionsInLastCommit(), 1)
-		assert.True(t, strings.HasSuffix(string(repo.AdditionsWithinRange("HEAD~2", "HEAD")[0].Data), "New content.\nMore new content.\n"))
+		assert.Len(t, repo.additionsInLastCommit(t), 1)
+		assert.True(t, strings.HasSuffix(string(additionsWithinRange(t, repo, "HEAD~2", "HEAD")[0].Data), "New content.\nMore new content.\n"))
 	})
 }
 
@@ -162,7 +180,7 @@ func TestContentOfDeletedFilesIsNotAvailableInChanges(t *testing.T) {
 	doInRepoWithCommit(func(git *git_testing.GitTesting) {
 		git.RemoveFile("a.txt")
 		git.AddAndcommit("a.txt", "Deleted this file. After all, it only had lorem-ipsum content.")
-		assert.Equal(t, 0, len(RepoLocatedAt(git.Root()).additionsInLastCommit()),
+		assert.Equal(t, 0, len(RepoLocatedAt(git.Root()).additionsInLastCommit(t)),
 			"There should be no additions because there is only an outgoing deletion")
 	})
 }
@@ -172,8 +190,8 @@ func TestDiffContainingBinaryFileChangesDoesNotBlowUp(t *testing.T) {
 		repo := RepoLocatedAt(git.Root())
 		exec.Command("cp", "./pixel.jpg", repo.root).Run()
 		git.AddAndcommit("pixel.jpg", "Testing binary diff.")
-		assert.Len(t, repo.additionsInLastCommit(), 1)
-		assert.Equal(t, "pixel.jpg", string(repo.additionsInLastCommit()[0].Name))
+		assert.Len(t, repo.additionsInLastCommit(t), 1)
+		assert.Equal(t, "pixel.jpg", string(repo.additionsInLastCommit(t)[0].Name))
 	})
 }
 
@@ -185,7 +203,7 @@ func TestStagedAdditionsIncludeStagedFiles(t *testing.T) {
 		git.AppendFileContent("a.txt", "More new content\n")
 		git.AppendFileContent("alice/bob/b.txt", "New content to b\n")
 
-		stagedAdditions := RepoLocatedAt(git.Root()).StagedAdditions()
+		stagedAdditions := stagedAdditionsOf(t, RepoLocatedAt(git.Root()))
 		assert.Len(t, stagedAdditions, 1)
 		assert.Equal(t, "a.txt", string(stagedAdditions[0].Name))
 		assert.Equal(t, "New content.\n", string(stagedAdditions[0].Data))
@@ -197,7 +215,7 @@ func TestStagedAdditionsIncludeStagedNewFiles(t *testing.T) {
 		git.CreateFileWithContents("new.txt", "New content.\n")
 		git.Add("new.txt")
 
-		stagedAdditions := RepoLocatedAt(git.Root()).StagedAdditions()
+		stagedAdditions := stagedAdditionsOf(t, RepoLocatedAt(git.Root()))
 		assert.Len(t, stagedAdditions, 1)
 		assert.Equal(t, "new.txt", string(stagedAdditions[0].Name))
 		assert.Equal(t, "New content.\n", string(stagedAdditions[0].Data))
@@ -209,7 +227,7 @@ func TestStagedAdditionsShouldNotIncludeDeletedFiles(t *testing.T) {
 		git.RemoveFile("a.txt")
 		git.Add(".")
 
-		stagedAdditions := RepoLocatedAt(git.Root()).StagedAdditions()
+		stagedAdditions := stagedAdditionsOf(t, RepoLocatedAt(git.Root()))
 		assert.Len(t, stagedAdditions, 0)
 	})
 }
@@ -267,3 +285,112 @@ func doInRepoWithCommit(gitOperation git_testing.GitOperation) {
 		gitOperation(git)
 	})
 }
+
+func TestRenamedFilesAreAvailableInChangesWithTheirPreviousPath(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo := RepoLocatedAt(git.Root())
+		repo.executeRepoCommand("git", "mv", "a.txt", "renamed.txt")
+		git.Commit("renamed.txt", "Renamed a.txt")
+		additions := repo.additionsInLastCommit(t)
+		if assert.Len(t, additions, 1) {
+			assert.Equal(t, FilePath("renamed.txt"), additions[0].Path)
+			assert.Equal(t, FilePath("a.txt"), additions[0].RenamedFrom)
+		}
+	})
+}
+
+func TestStagedAdditionsIncludeThePreviousPathOfRenamedFiles(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo := RepoLocatedAt(git.Root())
+		repo.executeRepoCommand("git", "mv", "a.txt", "renamed.txt")
+
+		stagedAdditions := stagedAdditionsOf(t, repo)
+		if assert.Len(t, stagedAdditions, 1) {
+			assert.Equal(t, FilePath("renamed.txt"), stagedAdditions[0].Path)
+			assert.Equal(t, FilePath("a.txt"), stagedAdditions[0].RenamedFrom)
+		}
+	})
+}
+
+func TestRenamedFromMatchesUsesThePreviousPath(t *testing.T) {
+	addition := Addition{Path: "new/cert.pem", Name: "cert.pem", RenamedFrom: "old/cert.pem"}
+	assert.True(t, addition.RenamedFromMatches("old/cert.pem"))
+	assert.True(t, addition.RenamedFromMatches("old/"))
+	assert.False(t, addition.RenamedFromMatches("new/cert.pem"))
+	assert.False(t, NewAddition("new/cert.pem", nil).RenamedFromMatches("new/cert.pem"))
+}
+
+func TestNativeReaderReadsTheSameAdditionsAsGit(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt", filepath.Join("alice", "bob", "b.txt"))
+		git.AppendFileContent("a.txt", "\nmore contents")
+		git.AddAndcommit("a.txt", "more contents")
+		git.CreateFileWithContents(filepath.Join("alice", "bob", "b.txt"), "staged contents")
+		git.Add(filepath.Join("alice", "bob", "b.txt"))
+		repo := RepoLocatedAt(git.Root())
+		outgoing := repo.additionsInLastCommit(t)
+		staged := stagedAdditionsOf(t, repo)
+
+		UseNativeReader(true)
+		defer UseNativeReader(false)
+		assert.Equal(t, outgoing, repo.additionsInLastCommit(t))
+		assert.Equal(t, staged, stagedAdditionsOf(t, repo))
+
+		reader := NewBatchGitStagedPathReader(git.Root())
+		assert.NoError(t, reader.Start())
+		contents, err := reader.Read(filepath.Join("alice", "bob", "b.txt"))
+		assert.NoError(t, err)
+		assert.Equal(t, "staged contents", string(contents))
+		_, err = reader.Read("missing.txt")
+		assert.Error(t, err)
+		assert.NoError(t, reader.Shutdown())
+	})
+}
+
+func TestBatchReaderReturnsAnErrorWhenReadBeforeStarting(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("a.txt")
+		for _, native := range []bool{false, true} {
+			UseNativeReader(native)
+			_, err := NewBatchGitHeadPathReader(git.Root()).Read("a.txt")
+			assert.Error(t, err)
+		}
+		UseNativeReader(false)
+	})
+}
+
+func TestParseNameStatusReadsPathsVerbatim(t *testing.T) {
+	changes := parseNameStatus([]byte("M\x00folder b/c.txt\x00A\x00naïve.txt\x00R087\x00old\tname.txt\x00new \"name\".txt\x00"))
+
+	assert.Equal(t, []fileChange{
+		{path: "folder b/c.txt"},
+		{path: "naïve.txt"},
+		{path: "new \"name\".txt", renamedFrom: "old\tname.txt"},
+	}, changes)
+}
+
+func TestStagedAdditionsIncludeFilesWithUnicodeInTheirPath(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("naïve.txt", "created contents")
+		git.Add("naïve.txt")
+		repo := RepoLocatedAt(git.Root())
+
+		stagedAdditions := stagedAdditionsOf(t, repo)
+		if assert.Len(t, stagedAdditions, 1) {
+			assert.Equal(t, FilePath("naïve.txt"), stagedAdditions[0].Path)
+			assert.Equal(t, "created contents", string(stagedAdditions[0].Data))
+		}
+	})
+}
+
+func TestOutgoingAdditionsIncludeFilesWithUnicodeInTheirPath(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.CreateFileWithContents("naïve.txt", "created contents")
+		git.AddAndcommit("naïve.txt", "added a file with unicode in its path")
+
+		additions := RepoLocatedAt(git.Root()).additionsInLastCommit(t)
+		if assert.Len(t, additions, 1) {
+			assert.Equal(t, FilePath("naïve.txt"), additions[0].Path)
+		}
+	})
+}
@@ -0,0 +1,36 @@
+package gitrepo
+
+import (
+	"strings"
+)
+
+// Locate returns the repository whose working tree holds the given path, with its root at the top level of that
+// working tree as git itself finds it. A path inside a subdirectory, a linked worktree or a submodule is resolved to
+// the top level of its own working tree, whose git directory may live elsewhere.
+func Locate(path string) (GitRepo, error) {
+	out, err := RepoLocatedAt(path).executeRepoCommand("git", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return GitRepo{}, err
+	}
+	return RepoLocatedAt(strings.TrimSpace(string(out))), nil
+}
+
+// GitDir returns the absolute path of the git directory of the working tree, which for a linked worktree or a
+// submodule is not the .git inside of it
+func (repo GitRepo) GitDir() (string, error) {
+	out, err := repo.executeRepoCommand("git", "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// CommonDir returns the absolute path of the git directory shared by all worktrees of the repository, which holds
+// its objects and refs
+func (repo GitRepo) CommonDir() (string, error) {
+	out, err := repo.executeRepoCommand("git", "rev-parse", "--path-format=absolute", "--git-common-dir")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,102 @@
+package gitrepo
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	// CommitMessage is the kind of a commit message
+	CommitMessage = "commit"
+	// TagAnnotation is the kind of the message of an annotated tag
+	TagAnnotation = "tag"
+
+	messageSuffix   = ":message"
+	recordSeparator = "\x1e"
+	fieldSeparator  = "\x00"
+)
+
+// Message is the message of a commit or of an annotated tag
+type Message struct {
+	Kind string
+	// ID is the commit hash or tag name the message belongs to. It is empty for a commit that is yet to be made.
+	ID   string
+	Text string
+}
+
+// Path returns the pseudo path findings in the message are reported against, such as commit:<sha>:message
+func (m Message) Path() FilePath {
+	if m.ID == "" {
+		return FilePath(m.Kind + messageSuffix)
+	}
+	return FilePath(fmt.Sprintf("%s:%s%s", m.Kind, m.ID, messageSuffix))
+}
+
+// Addition returns the message as an addition, so that it can be tested like file content.
+// Its name is the whole pseudo path, so that .talismanrc entries such as commit:* match every commit message.
+func (m Message) Addition() Addition {
+	var commits []string
+	if m.Kind == CommitMessage && m.ID != "" {
+		commits = []string{m.ID}
+	}
+	return Addition{
+		Path:    m.Path(),
+		Name:    FileName(m.Path()),
+		Commits: commits,
+		Data:    []byte(m.Text),
+	}
+}
+
+// IsMessagePath reports whether a path is the pseudo path of a commit message or tag annotation rather than a file
+func IsMessagePath(filePath string) bool {
+	return strings.HasSuffix(filePath, messageSuffix) &&
+		(strings.HasPrefix(filePath, CommitMessage+":") || strings.HasPrefix(filePath, TagAnnotation+":"))
+}
+
+// CommitMessages returns the messages of the commits selected by the arguments, given as to git log
+func (repo GitRepo) CommitMessages(revisions ...string) ([]Message, error) {
+	arguments := append([]string{"log", "--format=%H%x00%B%x1e"}, revisions...)
+	out, err := repo.executeRepoCommand("git", append(arguments, "--")...)
+	if err != nil {
+		return nil, err
+	}
+	var messages []Message
+	for _, record := range strings.Split(string(out), recordSeparator) {
+		sha, text, found := strings.Cut(strings.TrimLeft(record, "\n"), fieldSeparator)
+		if found {
+			messages = append(messages, Message{Kind: CommitMessage, ID: sha, Text: text})
+		}
+	}
+	return messages, nil
+}
+
+// CommitMessagesWithinRange returns the messages of the commits between oldCommit and newCommit.
+// When oldCommit is empty, the messages of all commits leading to newCommit that are on no remote are returned.
+func (repo GitRepo) CommitMessagesWithinRange(oldCommit string, newCommit string) ([]Message, error) {
+	if oldCommit == "" {
+		return repo.CommitMessages(newCommit, "--not", "--remotes")
+	}
+	return repo.CommitMessages(oldCommit + ".." + newCommit)
+}
+
+// TagAnnotations returns the messages of the annotated tags among the refs given, or of all annotated tags if none
+// are given. Signatures of signed tags are left out.
+func (repo GitRepo) TagAnnotations(refs ...string) ([]Message, error) {
+	format := strings.Join([]string{"%(objecttype)", "%(refname:short)", "%(contents:subject)\n\n%(contents:body)"}, "%00") + "%1e"
+	if len(refs) == 0 {
+		refs = []string{"refs/tags"}
+	}
+	arguments := append([]string{"for-each-ref", "--format=" + format}, refs...)
+	out, err := repo.executeRepoCommand("git", arguments...)
+	if err != nil {
+		return nil, err
+	}
+	var messages []Message
+	for _, record := range strings.Split(string(out), recordSeparator) {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), fieldSeparator, 3)
+		if len(fields) == 3 && fields[0] == "tag" {
+			messages = append(messages, Message{Kind: TagAnnotation, ID: fields[1], Text: strings.TrimSpace(fields[2])})
+		}
+	}
+	return messages, nil
+}
@@ -0,0 +1,70 @@
+package gitrepo
+
+import (
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessagePaths(t *testing.T) {
+	assert.Equal(t, FilePath("commit:abc123:message"), Message{Kind: CommitMessage, ID: "abc123"}.Path())
+	assert.Equal(t, FilePath("tag:v1.0:message"), Message{Kind: TagAnnotation, ID: "v1.0"}.Path())
+	assert.Equal(t, FilePath("commit:message"), Message{Kind: CommitMessage}.Path())
+
+	assert.True(t, IsMessagePath("commit:abc123:message"))
+	assert.True(t, IsMessagePath("tag:v1.0:message"))
+	assert.False(t, IsMessagePath("commit:message.txt"))
+	assert.False(t, IsMessagePath("docs/commit:message"))
+}
+
+func TestMessageAdditionIsAttributedToItsCommit(t *testing.T) {
+	addition := Message{Kind: CommitMessage, ID: "abc123", Text: "fix"}.Addition()
+
+	assert.Equal(t, FilePath("commit:abc123:message"), addition.Path)
+	assert.Equal(t, []string{"abc123"}, addition.Commits)
+	assert.Equal(t, []byte("fix"), addition.Data)
+	assert.Empty(t, Message{Kind: TagAnnotation, ID: "v1.0"}.Addition().Commits)
+}
+
+func TestMessageAdditionsMatchWildcardPatternsOnTheirPseudoPath(t *testing.T) {
+	addition := Message{Kind: CommitMessage, ID: "abc123"}.Addition()
+
+	assert.True(t, addition.Matches("commit:*"))
+	assert.True(t, addition.Matches("*:message"))
+	assert.True(t, addition.Matches("commit:abc123:message"))
+	assert.False(t, addition.Matches("tag:*"))
+}
+
+func TestCommitMessagesWithinRange(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		baseline := git.LatestCommit()
+		git.CreateFileWithContents("d.txt", "d")
+		git.AddAndcommit("d.txt", "First line\n\nSecond paragraph")
+		repo := RepoLocatedAt(git.Root())
+
+		messages, err := repo.CommitMessagesWithinRange(baseline, git.LatestCommit())
+		assert.NoError(t, err)
+		assert.Equal(t, []Message{{Kind: CommitMessage, ID: git.LatestCommit(), Text: "First line\n\nSecond paragraph\n"}}, messages)
+
+		outgoing, err := repo.CommitMessagesWithinRange("", git.LatestCommit())
+		assert.NoError(t, err)
+		assert.Len(t, outgoing, 3, "Expected every commit to be outgoing as there is no remote")
+	})
+}
+
+func TestTagAnnotationsLeaveOutLightweightTags(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo := RepoLocatedAt(git.Root())
+		repo.executeRepoCommand("git", "tag", "-a", "v1.0", "-m", "Release notes")
+		repo.executeRepoCommand("git", "tag", "lightweight")
+
+		annotations, err := repo.TagAnnotations()
+		assert.NoError(t, err)
+		assert.Equal(t, []Message{{Kind: TagAnnotation, ID: "v1.0", Text: "Release notes"}}, annotations)
+		annotations, err = repo.TagAnnotations("refs/tags/lightweight")
+		assert.NoError(t, err)
+		assert.Empty(t, annotations)
+	})
+}
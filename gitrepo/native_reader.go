@@ -0,0 +1,93 @@
+package gitrepo
+
+import (
+	"errors"
+	"sync"
+	"talisman/gitrepo/gitobject"
+)
+
+// ObjectReader reads files and blobs of a repository
+type ObjectReader interface {
+	// ReadPath returns the contents of the file at path in revision
+	ReadPath(revision string, path string) ([]byte, error)
+	// ReadStaged returns the contents of the file staged at path
+	ReadStaged(path string) ([]byte, error)
+	// ReadBlob returns the contents of the blob with the given hash
+	ReadBlob(hash string) ([]byte, error)
+}
+
+var (
+	nativeReads bool
+	// nativeRepositories are the repositories opened for native reads, by root, kept open for the rest of the run
+	nativeRepositories sync.Map
+)
+
+// UseNativeReader switches reading files and blobs between running git and reading the repository directly
+func UseNativeReader(enabled bool) {
+	nativeReads = enabled
+}
+
+// NativeReaderInUse tells whether files and blobs are read directly from the repository
+func NativeReaderInUse() bool {
+	return nativeReads
+}
+
+// NativeObjectReader returns a reader of the objects of the repository at root that does not run git
+func NativeObjectReader(root string) (ObjectReader, error) {
+	if repository, ok := nativeRepositories.Load(root); ok {
+		return repository.(*gitobject.Repository), nil
+	}
+	repository, err := gitobject.Find(root)
+	if err != nil {
+		return nil, err
+	}
+	if existing, loaded := nativeRepositories.LoadOrStore(root, repository); loaded {
+		repository.Close()
+		return existing.(*gitobject.Repository), nil
+	}
+	return repository, nil
+}
+
+// readNatively reads a file the way git cat-file reads "<prefix>:<fileName>", where an empty prefix names the index
+func readNatively(root string, fileName string, prefix string) ([]byte, error) {
+	reader, err := NativeObjectReader(root)
+	if err != nil {
+		return nil, err
+	}
+	if prefix == GIT_STAGED_PREFIX {
+		return reader.ReadStaged(fileName)
+	}
+	return reader.ReadPath(prefix, fileName)
+}
+
+// nativeBatchReader is a BatchReader that reads the repository directly instead of through git cat-file
+type nativeBatchReader struct {
+	root   string
+	reader ObjectReader
+	read   func(reader ObjectReader, expression string) ([]byte, error)
+}
+
+func newNativeBatchReader(root string, read func(reader ObjectReader, expression string) ([]byte, error)) BatchReader {
+	return &nativeBatchReader{root: root, read: read}
+}
+
+func (nbr *nativeBatchReader) Start() error {
+	reader, err := NativeObjectReader(nbr.root)
+	if err != nil {
+		return err
+	}
+	nbr.reader = reader
+	return nil
+}
+
+func (nbr *nativeBatchReader) Read(expression string) ([]byte, error) {
+	if nbr.reader == nil {
+		return nil, errors.New("native git reader has not been started")
+	}
+	return nbr.read(nbr.reader, expression)
+}
+
+// Shutdown leaves the repository open, as other readers of the same repository share it
+func (nbr *nativeBatchReader) Shutdown() error {
+	return nil
+}
@@ -0,0 +1,82 @@
+package gitrepo
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// SubmoduleUpdate is a change to the commit that a submodule of the repository points at
+type SubmoduleUpdate struct {
+	// Path is where the submodule is within the working tree of the repository
+	Path string
+	// OldCommit is the commit the submodule pointed at before, which is empty for a submodule that was added
+	OldCommit string
+	NewCommit string
+}
+
+// Submodule returns the repository of the submodule at the given path within the working tree
+func (repo GitRepo) Submodule(submodulePath string) GitRepo {
+	return GitRepo{filepath.Join(repo.root, submodulePath)}
+}
+
+// Submodules returns the paths of the submodules checked out in the working tree, along with those of the submodules
+// checked out within them. Submodules that were never initialized hold no files, and are left out.
+func (repo GitRepo) Submodules() ([]string, error) {
+	out, err := repo.executeRepoCommand("git", "ls-files", "--stage", "-z")
+	if err != nil {
+		return nil, err
+	}
+	var submodules []string
+	for _, entry := range strings.Split(string(out), "\x00") {
+		header, submodulePath, found := strings.Cut(entry, "\t")
+		if fields := strings.Fields(header); !found || len(fields) != 3 || fields[0] != submoduleMode {
+			continue
+		}
+		submodule := repo.Submodule(submodulePath)
+		if !submodule.isCheckedOut() {
+			continue
+		}
+		nested, err := submodule.Submodules()
+		if err != nil {
+			return nil, err
+		}
+		submodules = append(submodules, submodulePath)
+		for _, nestedPath := range nested {
+			submodules = append(submodules, path.Join(submodulePath, nestedPath))
+		}
+	}
+	return submodules, nil
+}
+
+// isCheckedOut reports whether a submodule has been checked out, in which case it has a .git file pointing at its
+// git directory, or a .git directory of its own
+func (repo GitRepo) isCheckedOut() bool {
+	_, err := os.Stat(filepath.Join(repo.root, ".git"))
+	return err == nil
+}
+
+// SubmoduleUpdatesWithinRange returns the submodules that were added or made to point at another commit in the given
+// commit range. Submodules that were removed are left out, as they add nothing.
+func (repo GitRepo) SubmoduleUpdatesWithinRange(oldCommit string, newCommit string) ([]SubmoduleUpdate, error) {
+	out, err := repo.executeRepoCommand("git", "diff", oldCommit+".."+newCommit, "--raw", "-z", "--no-abbrev",
+		"--no-renames", "--diff-filter=AMT")
+	if err != nil {
+		return nil, err
+	}
+	var updates []SubmoduleUpdate
+	records := strings.Split(string(out), "\x00")
+	for i := 0; i+1 < len(records); i += 2 {
+		fields := strings.Fields(strings.TrimPrefix(records[i], ":"))
+		if len(fields) != 5 || fields[1] != submoduleMode {
+			continue
+		}
+		update := SubmoduleUpdate{Path: records[i+1], NewCommit: fields[3]}
+		if fields[0] == submoduleMode {
+			update.OldCommit = fields[2]
+		}
+		updates = append(updates, update)
+	}
+	return updates, nil
+}
@@ -0,0 +1,113 @@
+package gitrepo
+
+import (
+	"path/filepath"
+	"testing"
+
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocateFindsTheTopLevelOfTheWorkingTree(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		repo, err := Locate(filepath.Join(git.Root(), "alice", "bob"))
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, git.Root(), repo.Root())
+
+		_, err = Locate(t.TempDir())
+		assert.ErrorIs(t, err, ErrNotARepository)
+	})
+}
+
+func TestLocateFindsLinkedWorktreesAndTheGitDirectoryTheyShare(t *testing.T) {
+	doInRepoWithCommit(func(git *git_testing.GitTesting) {
+		git.DoInWorktree(func(worktree *git_testing.GitTesting) {
+			repo, err := Locate(filepath.Join(worktree.Root(), "alice"))
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, worktree.Root(), repo.Root())
+
+			gitDir, err := repo.GitDir()
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join(git.Root(), ".git", "worktrees", filepath.Base(worktree.Root())), gitDir)
+			commonDir, err := repo.CommonDir()
+			assert.NoError(t, err)
+			assert.Equal(t, filepath.Join(git.Root(), ".git"), commonDir)
+		})
+	})
+}
+
+func TestSubmodulesListsCheckedOutSubmodulesAlongWithNestedOnes(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(nested *git_testing.GitTesting) {
+		nested.CreateFileWithContents("nested.txt", "nested")
+		nested.AddAndcommit("nested.txt", "add nested.txt")
+		git_testing.DoInTempGitRepo(func(library *git_testing.GitTesting) {
+			library.CreateFileWithContents("library.txt", "library")
+			library.AddAndcommit("library.txt", "add library.txt")
+			library.AddSubmodule(nested, "nested")
+			library.Commit(".", "add nested submodule")
+			doInRepoWithCommit(func(git *git_testing.GitTesting) {
+				git.AddSubmodule(library, filepath.Join("vendor", "library"))
+				git.Commit(".", "add library submodule")
+				git.Add(".")
+
+				submodules, err := RepoLocatedAt(git.Root()).Submodules()
+
+				assert.NoError(t, err)
+				assert.Equal(t, []string{"vendor/library", "vendor/library/nested"}, submodules)
+			})
+		})
+	})
+}
+
+func TestSubmodulesLeavesOutSubmodulesThatAreNotCheckedOut(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(library *git_testing.GitTesting) {
+		library.CreateFileWithContents("library.txt", "library")
+		library.AddAndcommit("library.txt", "add library.txt")
+		doInRepoWithCommit(func(git *git_testing.GitTesting) {
+			git.AddSubmodule(library, "library")
+			git.Commit(".", "add library submodule")
+
+			git.DoInClone(func(clone *git_testing.GitTesting) {
+				submodules, err := RepoLocatedAt(clone.Root()).Submodules()
+				assert.NoError(t, err)
+				assert.Empty(t, submodules)
+			})
+		})
+	})
+}
+
+func TestSubmoduleUpdatesWithinRangeListsTheCommitsSubmodulesWereMadeToPointAt(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(library *git_testing.GitTesting) {
+		library.CreateFileWithContents("library.txt", "library")
+		library.AddAndcommit("library.txt", "add library.txt")
+		added := library.LatestCommit()
+		doInRepoWithCommit(func(git *git_testing.GitTesting) {
+			before := git.LatestCommit()
+			checkedOut := git.AddSubmodule(library, "library")
+			git.Commit(".", "add library submodule")
+			withSubmodule := git.LatestCommit()
+			checkedOut.AppendFileContent("library.txt", "more")
+			checkedOut.AddAndcommit("library.txt", "change library.txt")
+			git.AddAndcommit("library", "update library submodule")
+			repo := RepoLocatedAt(git.Root())
+
+			updates, err := repo.SubmoduleUpdatesWithinRange(before, withSubmodule)
+			assert.NoError(t, err)
+			assert.Equal(t, []SubmoduleUpdate{{Path: "library", NewCommit: added}}, updates)
+
+			updates, err = repo.SubmoduleUpdatesWithinRange(withSubmodule, "HEAD")
+			assert.NoError(t, err)
+			assert.Equal(t, []SubmoduleUpdate{{Path: "library", OldCommit: added, NewCommit: checkedOut.LatestCommit()}}, updates)
+
+			additions, err := repo.AdditionsWithinRange(before, "HEAD")
+			assert.NoError(t, err)
+			assert.Len(t, additions, 1, "Expected only .gitmodules to be added, as the files of submodules are not part of the repository")
+			assert.Equal(t, FilePath(".gitmodules"), additions[0].Path)
+		})
+	})
+}
@@ -33,6 +33,20 @@ func (m *MockChecksumCalculator) EXPECT() *MockChecksumCalculatorMockRecorder {
 	return m.recorder
 }
 
+// CalculateChecksumForPattern mocks base method.
+func (m *MockChecksumCalculator) CalculateChecksumForPattern(fileNamePattern string, checksumVersion int) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CalculateChecksumForPattern", fileNamePattern, checksumVersion)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// CalculateChecksumForPattern indicates an expected call of CalculateChecksumForPattern.
+func (mr *MockChecksumCalculatorMockRecorder) CalculateChecksumForPattern(fileNamePattern, checksumVersion interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CalculateChecksumForPattern", reflect.TypeOf((*MockChecksumCalculator)(nil).CalculateChecksumForPattern), fileNamePattern, checksumVersion)
+}
+
 // CalculateCollectiveChecksumForPattern mocks base method.
 func (m *MockChecksumCalculator) CalculateCollectiveChecksumForPattern(fileNamePattern string) string {
 	m.ctrl.T.Helper()
@@ -48,15 +62,15 @@ func (mr *MockChecksumCalculatorMockRecorder) CalculateCollectiveChecksumForPatt
 }
 
 // SuggestTalismanRC mocks base method.
-func (m *MockChecksumCalculator) SuggestTalismanRC(fileNamePatterns []string) string {
+func (m *MockChecksumCalculator) SuggestTalismanRC(fileNamePatterns []string, checksumVersion int) string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SuggestTalismanRC", fileNamePatterns)
+	ret := m.ctrl.Call(m, "SuggestTalismanRC", fileNamePatterns, checksumVersion)
 	ret0, _ := ret[0].(string)
 	return ret0
 }
 
 // SuggestTalismanRC indicates an expected call of SuggestTalismanRC.
-func (mr *MockChecksumCalculatorMockRecorder) SuggestTalismanRC(fileNamePatterns interface{}) *gomock.Call {
+func (mr *MockChecksumCalculatorMockRecorder) SuggestTalismanRC(fileNamePatterns, checksumVersion interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestTalismanRC", reflect.TypeOf((*MockChecksumCalculator)(nil).SuggestTalismanRC), fileNamePatterns)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SuggestTalismanRC", reflect.TypeOf((*MockChecksumCalculator)(nil).SuggestTalismanRC), fileNamePatterns, checksumVersion)
 }
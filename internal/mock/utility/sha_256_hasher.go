@@ -47,6 +47,20 @@ func (mr *MockSHA256HasherMockRecorder) CollectiveSHA256Hash(paths interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CollectiveSHA256Hash", reflect.TypeOf((*MockSHA256Hasher)(nil).CollectiveSHA256Hash), paths)
 }
 
+// ContentSHA256Hash mocks base method.
+func (m *MockSHA256Hasher) ContentSHA256Hash(paths []string) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ContentSHA256Hash", paths)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ContentSHA256Hash indicates an expected call of ContentSHA256Hash.
+func (mr *MockSHA256HasherMockRecorder) ContentSHA256Hash(paths interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ContentSHA256Hash", reflect.TypeOf((*MockSHA256Hasher)(nil).ContentSHA256Hash), paths)
+}
+
 // Shutdown mocks base method.
 func (m *MockSHA256Hasher) Shutdown() error {
 	m.ctrl.T.Helper()
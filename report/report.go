@@ -14,6 +14,7 @@ import (
 )
 
 const jsonFileName string = "report.json"
+const authorsFileName string = "authors.json"
 const htmlReportDir string = "talisman_html_report"
 const jsonReportDir string = "talisman_html_report"
 
@@ -54,9 +55,25 @@ func GenerateReport(r *helpers.DetectionResults, directory string) (string, erro
 	if err != nil {
 		return "", err
 	}
+	err = writeAuthorsFile(r, filepath.Join(filepath.Dir(jsonFilePath), authorsFileName))
+	if err != nil {
+		return "", err
+	}
 	return path, nil
 }
 
+// writeAuthorsFile writes the findings that could be attributed to a commit, grouped by their author
+func writeAuthorsFile(r *helpers.DetectionResults, authorsFilePath string) error {
+	jsonString, err := json.Marshal(r.ByAuthor())
+	if err != nil {
+		return fmt.Errorf("error while rendering authors json: %v", err)
+	}
+	if err = os.WriteFile(authorsFilePath, jsonString, 0644); err != nil {
+		return fmt.Errorf("error while writing authors json to file: %v", err)
+	}
+	return nil
+}
+
 func generateAndWriteToFile(r *helpers.DetectionResults, jsonFilePath string) (path string, err error) {
 	jsonFile, err := os.Create(jsonFilePath)
 	defer func() {
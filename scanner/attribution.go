@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"os"
+	"strings"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+	"talisman/utility"
+)
+
+// AttributeFindings attributes each failure and warning found in the history to the earliest of the commits it was
+// found in, along with the author and date of that commit and the branches that contain it.
+// Findings in files of the given submodules are attributed to commits of the submodule they were found in.
+func AttributeFindings(results *helpers.DetectionResults, submodules ...string) {
+	wd, _ := os.Getwd()
+	superproject := gitrepo.RepoLocatedAt(wd)
+	repositoryOf := func(filePath gitrepo.FilePath) gitrepo.GitRepo {
+		repo, longest := superproject, ""
+		for _, submodule := range submodules {
+			if strings.HasPrefix(string(filePath), submodule+"/") && len(submodule) > len(longest) {
+				repo, longest = superproject.Submodule(submodule), submodule
+			}
+		}
+		return repo
+	}
+
+	commits := map[string][]string{}
+	repos := map[string]gitrepo.GitRepo{}
+	for _, result := range results.Results {
+		repo := repositoryOf(result.Filename)
+		repos[repo.Root()] = repo
+		for _, failure := range result.FailureList {
+			commits[repo.Root()] = append(commits[repo.Root()], failure.Commits...)
+		}
+		for _, warning := range result.WarningList {
+			commits[repo.Root()] = append(commits[repo.Root()], warning.Commits...)
+		}
+	}
+	attributions := map[string]map[string]gitrepo.Attribution{}
+	for root, repoCommits := range commits {
+		if len(repoCommits) > 0 {
+			attributions[root] = repos[root].CommitAttributions(utility.UniqueItems(repoCommits))
+		}
+	}
+	if len(attributions) == 0 {
+		return
+	}
+	branches := map[string][]string{}
+	results.Attribute(func(filePath gitrepo.FilePath, details helpers.Details) *gitrepo.Attribution {
+		repo := repositoryOf(filePath)
+		earliest, found := earliestAttribution(details.Commits, attributions[repo.Root()])
+		if !found {
+			return nil
+		}
+		if _, listed := branches[earliest.Commit]; !listed {
+			branches[earliest.Commit] = repo.BranchesContaining(earliest.Commit)
+		}
+		earliest.Branches = branches[earliest.Commit]
+		return &earliest
+	})
+}
+
+func earliestAttribution(commits []string, attributions map[string]gitrepo.Attribution) (gitrepo.Attribution, bool) {
+	var earliest gitrepo.Attribution
+	found := false
+	for _, commit := range commits {
+		attribution, known := attributions[commit]
+		if known && (!found || attribution.Time().Before(earliest.Time())) {
+			earliest, found = attribution, true
+		}
+	}
+	return earliest, found
+}
@@ -0,0 +1,22 @@
+package scanner
+
+import (
+	"talisman/gitrepo"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarliestAttributionPicksTheOldestKnownCommit(t *testing.T) {
+	attributions := map[string]gitrepo.Attribution{
+		"new": {Commit: "new", Date: "2024-02-01T10:00:00+01:00"},
+		"old": {Commit: "old", Date: "2024-01-01T10:00:00Z"},
+	}
+
+	earliest, found := earliestAttribution([]string{"new", "unknown", "old"}, attributions)
+
+	assert.True(t, found)
+	assert.Equal(t, "old", earliest.Commit)
+	_, found = earliestAttribution([]string{"unknown"}, attributions)
+	assert.False(t, found)
+}
@@ -0,0 +1,85 @@
+package scanner
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"talisman/detector/helpers"
+	"talisman/gitrepo"
+)
+
+// Availability is what part of the history to scan the repository holds. A shallow clone stops at its boundary
+// commits, and a partial clone, such as a blobless clone, leaves out objects that git would fetch when they are read.
+type Availability struct {
+	// Commits is the number of commits in the history to scan that the repository holds
+	Commits int
+	// ShallowBoundary lists the commits of a shallow clone whose parents are missing
+	ShallowBoundary []string
+	// Partial is true for partial clones
+	Partial bool
+
+	missing map[string]bool
+}
+
+// CheckAvailability finds what part of the history is held by the repository, without fetching anything
+func CheckAvailability(history History) (Availability, error) {
+	wd, _ := os.Getwd()
+	repo := gitrepo.RepoLocatedAt(wd)
+	commitCount, err := countCommits(history)
+	if err != nil {
+		return Availability{}, err
+	}
+	boundary, err := repo.ShallowCommits()
+	if err != nil {
+		return Availability{}, err
+	}
+	availability := Availability{Commits: commitCount, ShallowBoundary: boundary, Partial: repo.IsPartial()}
+	if availability.Partial {
+		revisions := history.revListArguments()
+		if history.Deep {
+			revisions = append(revisions, "--reflog")
+		}
+		if availability.missing, err = repo.MissingObjects(revisions...); err != nil {
+			return Availability{}, err
+		}
+	}
+	return availability, nil
+}
+
+func countCommits(history History) (int, error) {
+	arguments := append([]string{"rev-list", "--count"}, history.revListArguments()...)
+	out, err := gitOutput(arguments...)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(out)))
+}
+
+// Available splits the blobs into those the repository holds and those a partial clone left out
+func (a Availability) Available(blobs []Blob) (available []Blob, missing []Blob) {
+	if len(a.missing) == 0 {
+		return blobs, nil
+	}
+	for _, blob := range blobs {
+		if a.missing[blob.Hash] {
+			missing = append(missing, blob)
+		} else {
+			available = append(available, blob)
+		}
+	}
+	return available, missing
+}
+
+// Coverage describes how much of the history a scan that read scannedBlobs and skipped the missing blobs covered
+func (a Availability) Coverage(scannedBlobs int, missing []Blob) *helpers.Coverage {
+	coverage := &helpers.Coverage{
+		Complete:        len(a.ShallowBoundary) == 0 && len(missing) == 0,
+		Commits:         a.Commits,
+		ScannedBlobs:    scannedBlobs,
+		ShallowBoundary: a.ShallowBoundary,
+	}
+	for _, blob := range missing {
+		coverage.SkippedFiles = append(coverage.SkippedFiles, helpers.SkippedFile{Path: blob.Path, Hash: blob.Hash, Commits: blob.Commits})
+	}
+	return coverage
+}
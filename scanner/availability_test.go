@@ -0,0 +1,82 @@
+package scanner
+
+import (
+	"os"
+	"testing"
+
+	"talisman/detector/helpers"
+	"talisman/git_testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvailabilityOfAShallowCloneStopsAtItsBoundary(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("file.txt")
+		git.AppendFileContent("file.txt", "second version")
+		git.AddAndcommit("*", "Change file")
+		git.AppendFileContent("file.txt", "third version")
+		git.AddAndcommit("*", "Change file again")
+
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			wd, _ := os.Getwd()
+			defer os.Chdir(wd)
+			os.Chdir(clone.Root())
+
+			availability, err := CheckAvailability(History{})
+			if !assert.NoError(t, err) {
+				return
+			}
+			blobs, err := ListBlobs(History{})
+			assert.NoError(t, err)
+			available, missing := availability.Available(blobs)
+
+			assert.Equal(t, 2, availability.Commits)
+			assert.Equal(t, []string{clone.EarliestCommit()}, availability.ShallowBoundary)
+			assert.False(t, availability.Partial)
+			assert.Len(t, available, 2)
+			assert.Empty(t, missing)
+			assert.Equal(t, &helpers.Coverage{Commits: 2, ScannedBlobs: 2, ShallowBoundary: []string{clone.EarliestCommit()}},
+				availability.Coverage(2, missing))
+		}, "--depth=2")
+	})
+}
+
+func TestAvailabilityOfAPartialCloneLeavesOutBlobsItHasNotFetched(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		git.SetupBaselineFiles("file.txt")
+		first := git.LatestCommit()
+		git.OverwriteFileContent("file.txt", "second version")
+		git.AddAndcommit("*", "Change file")
+
+		git.DoInClone(func(clone *git_testing.GitTesting) {
+			wd, _ := os.Getwd()
+			defer os.Chdir(wd)
+			os.Chdir(clone.Root())
+
+			availability, err := CheckAvailability(History{})
+			if !assert.NoError(t, err) {
+				return
+			}
+			blobs, err := ListBlobs(History{})
+			assert.NoError(t, err)
+			available, missing := availability.Available(blobs)
+
+			assert.True(t, availability.Partial)
+			if assert.Len(t, available, 1) && assert.Len(t, missing, 1) {
+				assert.Equal(t, []string{clone.LatestCommit()}, available[0].Commits)
+				assert.Equal(t, []string{first}, missing[0].Commits)
+			}
+			coverage := availability.Coverage(len(available), missing)
+			assert.False(t, coverage.Complete)
+			assert.Equal(t, []helpers.SkippedFile{{Path: "file.txt", Hash: missing[0].Hash, Commits: []string{first}}}, coverage.SkippedFiles)
+
+			headOnly, err := CheckAvailability(History{HeadOnly: true})
+			assert.NoError(t, err)
+			assert.Equal(t, 1, headOnly.Commits)
+			available, missing = headOnly.Available(available)
+			assert.Len(t, available, 1)
+			assert.Empty(t, missing)
+		}, "--filter=blob:none")
+	})
+}
@@ -0,0 +1,329 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
+	"talisman/gitrepo"
+	"talisman/talismanrc"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// CacheFileName is the name of the file the scan cache is kept in, inside the cache directory
+	CacheFileName = "scan-cache.json"
+
+	findingFailure = "failure"
+	findingWarning = "warning"
+	findingIgnore  = "ignore"
+)
+
+// Cache remembers which commits earlier history scans have walked and what was found in each blob,
+// so that a later scan only walks new commits and only scans blobs it has not seen before.
+// A cache is only used with the configuration and talisman build it was created with, as identified by its key.
+type Cache struct {
+	Key            string        `json:"key"`
+	ScannedCommits []string      `json:"scanned_commits"`
+	Blobs          []*CachedBlob `json:"blobs"`
+
+	path    string
+	blobs   map[blobDetails]*CachedBlob
+	pending []*CachedBlob
+}
+
+// CachedBlob is a blob along with what scanning it found
+type CachedBlob struct {
+	Blob
+	Scanned  bool            `json:"scanned"`
+	Findings []CachedFinding `json:"findings,omitempty"`
+}
+
+// CachedFinding is a failure, warning or ignore reported for a blob
+type CachedFinding struct {
+	Kind     string `json:"kind"`
+	Category string `json:"category"`
+	Message  string `json:"message,omitempty"`
+	Severity int    `json:"severity,omitempty"`
+}
+
+// DefaultCacheDirectory returns the talisman directory inside the git directory of the current repository. Linked
+// worktrees share the cache kept in the git directory of the main worktree, as they share the history it describes.
+func DefaultCacheDirectory() (string, error) {
+	wd, _ := os.Getwd()
+	commonDir, err := gitrepo.RepoLocatedAt(wd).CommonDir()
+	if err != nil {
+		return "", fmt.Errorf("unable to locate git directory: %v", err)
+	}
+	return filepath.Join(commonDir, "talisman"), nil
+}
+
+// CacheKey identifies everything that decides what a scan finds: the configuration, the severities of the built-in
+// detectors and the talisman build. Results cached under a different key are not reused.
+func CacheKey(tRC *talismanrc.TalismanRC, talismanVersion string) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(talismanVersion))
+	if rc, err := yaml.Marshal(tRC); err == nil {
+		hasher.Write(rc)
+	}
+	if severities, err := json.Marshal(severity.SeverityConfiguration); err == nil {
+		hasher.Write(severities)
+	}
+	hasher.Write([]byte(buildID()))
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// buildID identifies the sources talisman was built from, as recorded by the go toolchain: the version of the module
+// when it was installed as one, or the commit it was built from and whether the working tree had changes
+func buildID() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	id := info.Main.Version + " " + info.Main.Sum
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" || setting.Key == "vcs.modified" {
+			id += " " + setting.Value
+		}
+	}
+	return id
+}
+
+// LoadCache reads the scan cache kept in directory. An empty cache is returned if there is none yet,
+// if it cannot be read, or if it was created under a different key.
+func LoadCache(directory string, key string) *Cache {
+	cache := &Cache{Key: key, path: filepath.Join(directory, CacheFileName)}
+	contents, err := os.ReadFile(cache.path)
+	if err != nil {
+		logrus.Debugf("no scan cache found at %s: %v", cache.path, err)
+		return cache.index()
+	}
+	stored := &Cache{}
+	if err := json.Unmarshal(contents, stored); err != nil {
+		logrus.Warnf("ignoring unreadable scan cache %s: %v", cache.path, err)
+		return cache.index()
+	}
+	if stored.Key != key {
+		logrus.Infof("configuration or talisman version changed since the last scan, discarding scan cache %s", cache.path)
+		return cache.index()
+	}
+	stored.path = cache.path
+	return stored.index()
+}
+
+// Save writes the cache back to the file it was loaded from
+func (c *Cache) Save() error {
+	c.Blobs = c.Blobs[:0]
+	for _, blob := range c.blobs {
+		c.Blobs = append(c.Blobs, blob)
+	}
+	contents, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error encoding scan cache: %v", err)
+	}
+	if err := replaceFile(c.path, contents); err != nil {
+		return fmt.Errorf("error writing scan cache: %v", err)
+	}
+	return nil
+}
+
+// replaceFile writes contents to a temporary file first and then renames it, so that the file at path is never left
+// half written
+func replaceFile(path string, contents []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	temporaryPath := path + ".tmp"
+	if err := os.WriteFile(temporaryPath, contents, 0644); err != nil {
+		return err
+	}
+	return os.Rename(temporaryPath, path)
+}
+
+func (c *Cache) index() *Cache {
+	c.blobs = make(map[blobDetails]*CachedBlob, len(c.Blobs))
+	for _, blob := range c.Blobs {
+		c.blobs[blobDetails{hash: blob.Hash, filePath: blob.Path}] = blob
+	}
+	return c
+}
+
+// update brings the cache in line with the commits currently in the repository, and returns the commits that
+// have not been walked yet. Commits that are no longer reachable are forgotten, along with blobs found only in them.
+func (c *Cache) update(commits []string) []string {
+	present := make(map[string]bool, len(commits))
+	for _, commit := range commits {
+		present[commit] = true
+	}
+	scanned := make(map[string]bool, len(c.ScannedCommits))
+	var stillPresent []string
+	for _, commit := range c.ScannedCommits {
+		if present[commit] {
+			scanned[commit] = true
+			stillPresent = append(stillPresent, commit)
+		}
+	}
+	if len(stillPresent) != len(c.ScannedCommits) {
+		for key, blob := range c.blobs {
+			blob.Commits = keepCommits(blob.Commits, scanned)
+			if len(blob.Commits) == 0 {
+				delete(c.blobs, key)
+			}
+		}
+	}
+	c.ScannedCommits = stillPresent
+
+	var newCommits []string
+	for _, commit := range commits {
+		if !scanned[commit] {
+			newCommits = append(newCommits, commit)
+		}
+	}
+	return newCommits
+}
+
+// add records the blobs found in newly walked commits
+func (c *Cache) add(commits []string, blobsInCommits BlobsInCommits) {
+	for blob, blobCommits := range blobsInCommits.commits {
+		cached, ok := c.blobs[blob]
+		if !ok {
+			cached = &CachedBlob{Blob: Blob{Hash: blob.hash, Path: blob.filePath}}
+			c.blobs[blob] = cached
+		}
+		cached.Commits = append(cached.Commits, blobCommits...)
+	}
+	c.ScannedCommits = append(c.ScannedCommits, commits...)
+}
+
+// unscanned returns the blobs that still need to be scanned, remembering them so that their results can be recorded
+func (c *Cache) unscanned() []*CachedBlob {
+	c.pending = nil
+	for _, blob := range c.blobs {
+		if !blob.Scanned {
+			c.pending = append(c.pending, blob)
+		}
+	}
+	return c.pending
+}
+
+// LeaveUnscanned keeps the blobs from being recorded as scanned, such as blobs that a partial clone left out and that
+// were not read, so that a later scan reads them once they are fetched
+func (c *Cache) LeaveUnscanned(blobs []Blob) {
+	leave := make(map[blobDetails]bool, len(blobs))
+	for _, blob := range blobs {
+		leave[blobDetails{hash: blob.Hash, filePath: blob.Path}] = true
+	}
+	var pending []*CachedBlob
+	for _, blob := range c.pending {
+		if !leave[blobDetails{hash: blob.Hash, filePath: blob.Path}] {
+			pending = append(pending, blob)
+		}
+	}
+	c.pending = pending
+}
+
+// Record stores what the scan found in the blobs that were scanned. A result belongs to a blob when it is
+// reported for the blob's path and for one of the commits the blob is present in.
+func (c *Cache) Record(results *helpers.DetectionResults) {
+	resultsByPath := resultsByPath(results)
+	for _, blob := range c.pending {
+		blob.record(resultsByPath)
+	}
+}
+
+// Replay adds the findings of blobs scanned by earlier runs to the results of this run
+func (c *Cache) Replay(results *helpers.DetectionResults) int {
+	pending := make(map[*CachedBlob]bool, len(c.pending))
+	for _, blob := range c.pending {
+		pending[blob] = true
+	}
+	replayed := 0
+	for _, blob := range c.blobs {
+		if !blob.Scanned || pending[blob] {
+			continue
+		}
+		replayed++
+		blob.replay(results)
+	}
+	return replayed
+}
+
+func resultsByPath(results *helpers.DetectionResults) map[string]helpers.ResultsDetails {
+	byPath := make(map[string]helpers.ResultsDetails, len(results.Results))
+	for _, result := range results.Results {
+		byPath[string(result.Filename)] = result
+	}
+	return byPath
+}
+
+// record stores the findings reported for the blob and marks it as scanned
+func (blob *CachedBlob) record(resultsByPath map[string]helpers.ResultsDetails) {
+	blob.Findings = nil
+	if result, ok := resultsByPath[blob.Path]; ok {
+		blob.Findings = append(blob.Findings, findingsFor(blob, findingFailure, result.FailureList)...)
+		blob.Findings = append(blob.Findings, findingsFor(blob, findingWarning, result.WarningList)...)
+		blob.Findings = append(blob.Findings, findingsFor(blob, findingIgnore, result.IgnoreList)...)
+	}
+	blob.Scanned = true
+}
+
+// replay adds the findings stored for the blob to results
+func (blob *CachedBlob) replay(results *helpers.DetectionResults) {
+	path := gitrepo.FilePath(blob.Path)
+	for _, finding := range blob.Findings {
+		switch finding.Kind {
+		case findingFailure:
+			results.Fail(path, finding.Category, finding.Message, blob.Commits, severity.Severity(finding.Severity))
+		case findingWarning:
+			results.Warn(path, finding.Category, finding.Message, blob.Commits, severity.Severity(finding.Severity))
+		case findingIgnore:
+			results.Ignore(path, finding.Category)
+		}
+	}
+}
+
+func findingsFor(blob *CachedBlob, kind string, details []helpers.Details) []CachedFinding {
+	var findings []CachedFinding
+	blobCommits := make(map[string]bool, len(blob.Commits))
+	for _, commit := range blob.Commits {
+		blobCommits[commit] = true
+	}
+	for _, detail := range details {
+		if len(detail.Commits) > 0 && !sharesCommit(blobCommits, detail.Commits) {
+			continue
+		}
+		findings = append(findings, CachedFinding{
+			Kind:     kind,
+			Category: detail.Category,
+			Message:  detail.Message,
+			Severity: int(detail.Severity),
+		})
+	}
+	return findings
+}
+
+func sharesCommit(blobCommits map[string]bool, commits []string) bool {
+	for _, commit := range commits {
+		if blobCommits[commit] {
+			return true
+		}
+	}
+	return false
+}
+
+func keepCommits(commits []string, keep map[string]bool) []string {
+	var kept []string
+	for _, commit := range commits {
+		if keep[commit] {
+			kept = append(kept, commit)
+		}
+	}
+	return kept
+}
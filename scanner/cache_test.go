@@ -0,0 +1,121 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadCacheReturnsWhatWasSaved(t *testing.T) {
+	directory := t.TempDir()
+	cache := LoadCache(directory, "key")
+	cache.add([]string{"c1"}, blobsInCommitsOf(map[blobDetails][]string{{"h1", "a.txt"}: {"c1"}}))
+	assert.NoError(t, cache.Save())
+
+	loaded := LoadCache(directory, "key")
+
+	assert.Equal(t, []string{"c1"}, loaded.ScannedCommits)
+	assert.Equal(t, []string{"c1"}, loaded.blobs[blobDetails{"h1", "a.txt"}].Commits)
+}
+
+func TestLoadCacheDiscardsCacheCreatedUnderADifferentKey(t *testing.T) {
+	directory := t.TempDir()
+	cache := LoadCache(directory, "old-key")
+	cache.add([]string{"c1"}, blobsInCommitsOf(map[blobDetails][]string{{"h1", "a.txt"}: {"c1"}}))
+	assert.NoError(t, cache.Save())
+
+	loaded := LoadCache(directory, "new-key")
+
+	assert.Equal(t, "new-key", loaded.Key)
+	assert.Empty(t, loaded.ScannedCommits)
+	assert.Empty(t, loaded.blobs)
+}
+
+func TestLoadCacheDiscardsUnreadableCache(t *testing.T) {
+	directory := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(directory, CacheFileName), []byte("{not json"), 0644))
+
+	loaded := LoadCache(directory, "key")
+
+	assert.Empty(t, loaded.blobs)
+}
+
+func TestCacheKeyChangesWithConfiguration(t *testing.T) {
+	defaultKey := CacheKey(&talismanrc.TalismanRC{}, "v1")
+
+	assert.Equal(t, defaultKey, CacheKey(&talismanrc.TalismanRC{}, "v1"))
+	assert.NotEqual(t, defaultKey, CacheKey(&talismanrc.TalismanRC{Threshold: severity.High}, "v1"))
+	assert.NotEqual(t, defaultKey, CacheKey(&talismanrc.TalismanRC{}, "v2"))
+}
+
+func TestCacheUpdateOnlyReturnsCommitsNotWalkedBefore(t *testing.T) {
+	cache := LoadCache(t.TempDir(), "key")
+	cache.add([]string{"c1"}, blobsInCommitsOf(map[blobDetails][]string{{"h1", "a.txt"}: {"c1"}}))
+
+	assert.Equal(t, []string{"c2"}, cache.update([]string{"c2", "c1"}))
+}
+
+func TestCacheUpdateForgetsBlobsOnlyInCommitsThatAreGone(t *testing.T) {
+	cache := LoadCache(t.TempDir(), "key")
+	cache.add([]string{"c1", "c2"}, blobsInCommitsOf(map[blobDetails][]string{
+		{"h1", "a.txt"}: {"c1", "c2"},
+		{"h2", "b.txt"}: {"c2"},
+	}))
+
+	cache.update([]string{"c1"})
+
+	assert.Equal(t, []string{"c1"}, cache.ScannedCommits)
+	assert.Equal(t, []string{"c1"}, cache.blobs[blobDetails{"h1", "a.txt"}].Commits)
+	assert.NotContains(t, cache.blobs, blobDetails{"h2", "b.txt"})
+}
+
+func TestCacheRecordsFindingsForTheBlobTheyWereFoundIn(t *testing.T) {
+	cache := LoadCache(t.TempDir(), "key")
+	cache.add([]string{"c1", "c2"}, blobsInCommitsOf(map[blobDetails][]string{
+		{"secret", "a.txt"}: {"c1"},
+		{"safe", "a.txt"}:   {"c2"},
+	}))
+	assert.Len(t, cache.unscanned(), 2)
+	results := helpers.NewDetectionResults()
+	results.Fail("a.txt", "filecontent", "secret found", []string{"c1"}, severity.High)
+
+	cache.Record(results)
+
+	assert.Empty(t, cache.unscanned())
+	secret := cache.blobs[blobDetails{"secret", "a.txt"}]
+	assert.Equal(t, []CachedFinding{{Kind: findingFailure, Category: "filecontent", Message: "secret found", Severity: int(severity.High)}}, secret.Findings)
+	assert.Empty(t, cache.blobs[blobDetails{"safe", "a.txt"}].Findings)
+}
+
+func TestCacheReplaysFindingsOfBlobsScannedEarlier(t *testing.T) {
+	directory := t.TempDir()
+	cache := LoadCache(directory, "key")
+	cache.add([]string{"c1"}, blobsInCommitsOf(map[blobDetails][]string{{"secret", "a.txt"}: {"c1"}}))
+	cache.unscanned()
+	earlierResults := helpers.NewDetectionResults()
+	earlierResults.Fail("a.txt", "filecontent", "secret found", []string{"c1"}, severity.High)
+	earlierResults.Warn("a.txt", "filesize", "file is large", []string{"c1"}, severity.Low)
+	cache.Record(earlierResults)
+	assert.NoError(t, cache.Save())
+
+	loaded := LoadCache(directory, "key")
+	loaded.add(loaded.update([]string{"c2", "c1"}), blobsInCommitsOf(map[blobDetails][]string{{"secret", "a.txt"}: {"c2"}}))
+	assert.Empty(t, loaded.unscanned())
+	results := helpers.NewDetectionResults()
+	replayed := loaded.Replay(results)
+
+	assert.Equal(t, 1, replayed)
+	assert.True(t, results.HasFailures())
+	assert.Equal(t, []string{"c1", "c2"}, results.GetFailures("a.txt")[0].Commits)
+	assert.Equal(t, severity.High, results.GetFailures("a.txt")[0].Severity)
+	assert.True(t, results.HasWarnings())
+}
+
+func blobsInCommitsOf(commits map[blobDetails][]string) BlobsInCommits {
+	return BlobsInCommits{commits: commits}
+}
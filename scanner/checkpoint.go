@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"talisman/detector/helpers"
+	"talisman/talismanrc"
+
+	"github.com/sirupsen/logrus"
+)
+
+// CheckpointFileName is the name of the file the progress of a history scan is kept in, inside the report directory
+const CheckpointFileName = "talisman_scan_checkpoint.json"
+
+// Checkpoint records the blobs an unfinished history scan has already scanned, along with what was found in them,
+// so that an interrupted scan can be resumed without scanning them again.
+// A checkpoint is only resumed by a scan of the same part of the history, with the same configuration and talisman
+// build, as identified by its key.
+type Checkpoint struct {
+	Key          string        `json:"key"`
+	ScannedBlobs []*CachedBlob `json:"scanned_blobs"`
+
+	path    string
+	scanned map[blobDetails]bool
+}
+
+// CheckpointKey identifies the history scanned along with everything that decides what the scan finds
+func CheckpointKey(tRC *talismanrc.TalismanRC, talismanVersion string, history History) string {
+	hasher := sha256.New()
+	hasher.Write([]byte(CacheKey(tRC, talismanVersion)))
+	if selection, err := json.Marshal(history); err == nil {
+		hasher.Write(selection)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// NewCheckpoint returns an empty checkpoint that is kept in directory
+func NewCheckpoint(directory string, key string) *Checkpoint {
+	return &Checkpoint{Key: key, path: filepath.Join(directory, CheckpointFileName), scanned: map[blobDetails]bool{}}
+}
+
+// LoadCheckpoint reads the checkpoint kept in directory. An empty checkpoint is returned if there is none,
+// if it cannot be read, or if it was written by a scan with a different key.
+func LoadCheckpoint(directory string, key string) *Checkpoint {
+	checkpoint := NewCheckpoint(directory, key)
+	contents, err := os.ReadFile(checkpoint.path)
+	if err != nil {
+		logrus.Warnf("no scan checkpoint to resume from at %s, scanning everything", checkpoint.path)
+		return checkpoint
+	}
+	stored := &Checkpoint{}
+	if err := json.Unmarshal(contents, stored); err != nil {
+		logrus.Warnf("ignoring unreadable scan checkpoint %s: %v", checkpoint.path, err)
+		return checkpoint
+	}
+	if stored.Key != key {
+		logrus.Warnf("scan checkpoint %s was written by a scan of a different history or configuration, scanning everything", checkpoint.path)
+		return checkpoint
+	}
+	for _, blob := range stored.ScannedBlobs {
+		checkpoint.add(blob)
+	}
+	return checkpoint
+}
+
+// Remaining returns the blobs that have not been scanned yet
+func (c *Checkpoint) Remaining(blobs []Blob) []Blob {
+	var remaining []Blob
+	for _, blob := range blobs {
+		if !c.scanned[blobDetails{hash: blob.Hash, filePath: blob.Path}] {
+			remaining = append(remaining, blob)
+		}
+	}
+	return remaining
+}
+
+// Replay adds what was found in the blobs scanned before the checkpoint to results, and returns how many there were
+func (c *Checkpoint) Replay(results *helpers.DetectionResults) int {
+	for _, blob := range c.ScannedBlobs {
+		blob.replay(results)
+	}
+	return len(c.ScannedBlobs)
+}
+
+// Record adds blobs that have been scanned since the last checkpoint, along with what the scan found in them
+func (c *Checkpoint) Record(blobs []Blob, results *helpers.DetectionResults) {
+	resultsByPath := resultsByPath(results)
+	for _, blob := range blobs {
+		scanned := &CachedBlob{Blob: blob}
+		scanned.record(resultsByPath)
+		c.add(scanned)
+	}
+}
+
+// Save writes the checkpoint to its file
+func (c *Checkpoint) Save() error {
+	contents, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("error encoding scan checkpoint: %v", err)
+	}
+	if err := replaceFile(c.path, contents); err != nil {
+		return fmt.Errorf("error writing scan checkpoint: %v", err)
+	}
+	return nil
+}
+
+// Remove deletes the checkpoint once the scan it belongs to is complete
+func (c *Checkpoint) Remove() error {
+	if err := os.Remove(c.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing scan checkpoint: %v", err)
+	}
+	return nil
+}
+
+func (c *Checkpoint) add(blob *CachedBlob) {
+	details := blobDetails{hash: blob.Hash, filePath: blob.Path}
+	if !c.scanned[details] {
+		c.scanned[details] = true
+		c.ScannedBlobs = append(c.ScannedBlobs, blob)
+	}
+}
@@ -0,0 +1,62 @@
+package scanner
+
+import (
+	"os"
+	"path/filepath"
+	"talisman/detector/helpers"
+	"talisman/detector/severity"
+	"talisman/talismanrc"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckpointRemembersScannedBlobsAndTheirFindings(t *testing.T) {
+	directory := t.TempDir()
+	secret := Blob{Hash: "secret", Path: "a.txt", Commits: []string{"c1"}}
+	safe := Blob{Hash: "safe", Path: "b.txt", Commits: []string{"c1"}}
+	results := helpers.NewDetectionResults()
+	results.Fail("a.txt", "filecontent", "secret found", []string{"c1"}, severity.High)
+	checkpoint := NewCheckpoint(directory, "key")
+	checkpoint.Record([]Blob{secret}, results)
+	assert.NoError(t, checkpoint.Save())
+
+	resumed := LoadCheckpoint(directory, "key")
+	resumedResults := helpers.NewDetectionResults()
+
+	assert.Equal(t, []Blob{safe}, resumed.Remaining([]Blob{secret, safe}))
+	assert.Equal(t, 1, resumed.Replay(resumedResults))
+	assert.Equal(t, "secret found", resumedResults.GetFailures("a.txt")[0].Message)
+	assert.Equal(t, severity.High, resumedResults.GetFailures("a.txt")[0].Severity)
+}
+
+func TestLoadCheckpointIgnoresCheckpointOfADifferentScan(t *testing.T) {
+	directory := t.TempDir()
+	blob := Blob{Hash: "h1", Path: "a.txt"}
+	checkpoint := NewCheckpoint(directory, "old-key")
+	checkpoint.Record([]Blob{blob}, helpers.NewDetectionResults())
+	assert.NoError(t, checkpoint.Save())
+
+	assert.Equal(t, []Blob{blob}, LoadCheckpoint(directory, "new-key").Remaining([]Blob{blob}))
+	assert.Equal(t, []Blob{blob}, LoadCheckpoint(t.TempDir(), "old-key").Remaining([]Blob{blob}))
+}
+
+func TestCheckpointRemoveDeletesItsFile(t *testing.T) {
+	directory := t.TempDir()
+	checkpoint := NewCheckpoint(directory, "key")
+	assert.NoError(t, checkpoint.Remove(), "Expected removing a checkpoint that was never saved to succeed")
+	assert.NoError(t, checkpoint.Save())
+
+	assert.NoError(t, checkpoint.Remove())
+
+	_, err := os.Stat(filepath.Join(directory, CheckpointFileName))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCheckpointKeyChangesWithTheHistoryScanned(t *testing.T) {
+	tRC := &talismanrc.TalismanRC{}
+
+	assert.Equal(t, CheckpointKey(tRC, "v1", History{}), CheckpointKey(tRC, "v1", History{}))
+	assert.NotEqual(t, CheckpointKey(tRC, "v1", History{}), CheckpointKey(tRC, "v1", History{Revisions: []string{"main"}}))
+	assert.NotEqual(t, CheckpointKey(tRC, "v1", History{}), CheckpointKey(tRC, "v2", History{}))
+}
@@ -0,0 +1,148 @@
+package scanner
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	stashRef  = "refs/stash"
+	notesRefs = "refs/notes/"
+
+	// ReflogSource labels blobs found in commits only reachable from the reflog
+	ReflogSource = "reflog"
+	// UnreachableSource labels blobs found in objects not reachable from any ref or reflog
+	UnreachableSource = "unreachable"
+)
+
+// ListDeepBlobs lists the blobs that are not part of the history reachable from branches and tags, but that can still
+// be recovered from the repository: blobs in stash entries, in commits only reachable from the reflog, in notes and in
+// unreachable objects. Each blob's path is prefixed with where it was found, such as stash@{1}:config.yml,
+// reflog:config.yml, refs/notes/commits:<annotated object> or unreachable:config.yml.
+// Unreachable blobs that are not part of any commit are labelled with their hash, as in unreachable:<hash>.
+// Notes and unreachable blobs that are not part of any commit have no file path, so they are left out when the scan
+// is limited to some paths.
+func ListDeepBlobs(history History) []Blob {
+	fileHistory := History{Paths: history.Paths, Deep: true}
+	var blobs []Blob
+
+	stashes := stashEntries()
+	var stashCommits []string
+	for _, stash := range stashes {
+		stashCommits = append(stashCommits, stash.commit)
+		commits := revList(append([]string{stash.commit, "--not"}, fileHistory.refArguments()...)...)
+		blobs = append(blobs, labelled(stash.name+":", collectedBlobs(commits, fileHistory))...)
+	}
+
+	reflogCommits := revList(append(append([]string{"--reflog", "--not"}, fileHistory.refArguments()...), stashCommits...)...)
+	blobs = append(blobs, labelled(ReflogSource+":", collectedBlobs(reflogCommits, fileHistory))...)
+
+	if len(history.Paths) == 0 {
+		for _, notesRef := range refsUnder(notesRefs) {
+			for _, blob := range collectedBlobs(revList(notesRef), History{Deep: true}) {
+				// notes are stored at the hash of the object they annotate, split into directories once there are many
+				blob.Path = notesRef + ":" + strings.ReplaceAll(blob.Path, "/", "")
+				blobs = append(blobs, blob)
+			}
+		}
+	}
+
+	unreachableCommits, unreachableBlobs := unreachableObjects()
+	inCommits := map[string]bool{}
+	for _, blob := range collectedBlobs(unreachableCommits, fileHistory) {
+		inCommits[blob.Hash] = true
+		blob.Path = UnreachableSource + ":" + blob.Path
+		blobs = append(blobs, blob)
+	}
+	if len(history.Paths) == 0 {
+		for _, hash := range unreachableBlobs {
+			if !inCommits[hash] {
+				blobs = append(blobs, Blob{Hash: hash, Path: UnreachableSource + ":" + hash})
+			}
+		}
+	}
+	logrus.Infof("deep scan found %d blobs outside the history of branches and tags", len(blobs))
+	return blobs
+}
+
+type stashEntry struct {
+	name, commit string
+}
+
+func stashEntries() []stashEntry {
+	out, err := exec.Command("git", "stash", "list", "--format=%gd %H").Output()
+	if err != nil {
+		logrus.Warnf("unable to list stash entries: %v", err)
+		return nil
+	}
+	var stashes []stashEntry
+	for _, line := range strings.Split(string(out), "\n") {
+		if name, commit, found := strings.Cut(line, " "); found {
+			stashes = append(stashes, stashEntry{name: name, commit: commit})
+		}
+	}
+	return stashes
+}
+
+func refsUnder(prefix string) []string {
+	out, err := exec.Command("git", "for-each-ref", "--format=%(refname)", prefix).Output()
+	if err != nil {
+		logrus.Warnf("unable to list refs under %s: %v", prefix, err)
+		return nil
+	}
+	return nonEmpty(strings.Split(string(out), "\n"))
+}
+
+func revList(arguments ...string) []string {
+	out, err := exec.Command("git", append(append([]string{"rev-list", "--topo-order", "--reverse"}, arguments...), "--")...).Output()
+	if err != nil {
+		logrus.Warnf("unable to list commits for %v: %v", arguments, err)
+		return nil
+	}
+	return nonEmpty(strings.Split(string(out), "\n"))
+}
+
+// unreachableObjects lists the commits and blobs that neither a ref nor a reflog entry leads to
+func unreachableObjects() ([]string, []string) {
+	out, err := exec.Command("git", "fsck", "--unreachable", "--no-progress").Output()
+	if err != nil && len(out) == 0 {
+		logrus.Warnf("unable to list unreachable objects: %v", err)
+		return nil, nil
+	}
+	return parseUnreachableObjects(string(out))
+}
+
+// parseUnreachableObjects reads lines such as "unreachable blob <hash>" printed by git fsck
+func parseUnreachableObjects(fsckOutput string) ([]string, []string) {
+	var commits, blobs []string
+	for _, line := range strings.Split(fsckOutput, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 || fields[0] != "unreachable" {
+			continue
+		}
+		switch fields[1] {
+		case "commit":
+			commits = append(commits, fields[2])
+		case "blob":
+			blobs = append(blobs, fields[2])
+		}
+	}
+	return commits, blobs
+}
+
+func collectedBlobs(commits []string, history History) []Blob {
+	blobsInCommits := newBlobsInCommit()
+	if err := collectBlobs(blobsInCommits, commits, history, func() {}); err != nil {
+		logrus.Warnf("unable to list blobs outside the history of branches and tags: %v", err)
+	}
+	return blobsInCommits.blobs()
+}
+
+func labelled(prefix string, blobs []Blob) []Blob {
+	for i := range blobs {
+		blobs[i].Path = prefix + blobs[i].Path
+	}
+	return blobs
+}
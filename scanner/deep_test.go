@@ -0,0 +1,70 @@
+package scanner
+
+import (
+	"os"
+	"os/exec"
+	"talisman/git_testing"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_parseUnreachableObjects(t *testing.T) {
+	commits, blobs := parseUnreachableObjects(`unreachable blob 351324aa7b3c66043e484c2f2c7b7f1842152f35
+unreachable tree 8715df9907604c8ee8fc5e377821817f84f014fa
+unreachable commit 5ad3b6c1bd01b8e2ea7bbd0fb4f06ef4f7a8d3a2
+dangling blob 0000000000000000000000000000000000000001
+`)
+
+	assert.Equal(t, []string{"5ad3b6c1bd01b8e2ea7bbd0fb4f06ef4f7a8d3a2"}, commits)
+	assert.Equal(t, []string{"351324aa7b3c66043e484c2f2c7b7f1842152f35"}, blobs)
+}
+
+func TestDeepHistoryLeavesStashAndNotesOutOfTheRegularWalk(t *testing.T) {
+	assert.Equal(t, []string{"--exclude=refs/stash", "--exclude=refs/notes/*", "--all"}, History{Deep: true}.logArguments())
+	assert.False(t, History{Deep: true}.IsComplete())
+}
+
+func TestListDeepBlobsLabelsBlobsByWhereTheyWereFound(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		wd, _ := os.Getwd()
+		defer os.Chdir(wd)
+		os.Chdir(git.Root())
+		git.CreateFileWithContents("file.txt", "committed")
+		git.AddAndcommit("*", "Initial commit")
+		run := func(arguments ...string) string {
+			out, err := exec.Command("git", arguments...).CombinedOutput()
+			assert.NoError(t, err, string(out))
+			return string(out)
+		}
+
+		git.CreateFileWithContents("reset.txt", "reset away")
+		git.AddAndcommit("*", "Commit that is reset away")
+		run("reset", "--hard", "HEAD~1")
+		git.OverwriteFileContent("file.txt", "stashed")
+		run("stash")
+		run("notes", "add", "-m", "a note", "HEAD")
+		git.CreateFileWithContents("staged.txt", "staged then unstaged")
+		run("add", "staged.txt")
+		run("reset", "staged.txt")
+		git.RemoveFile("staged.txt")
+
+		paths := map[string]bool{}
+		for _, blob := range ListDeepBlobs(History{Deep: true}) {
+			paths[blob.Path] = true
+		}
+
+		assert.True(t, paths["reflog:reset.txt"], "Expected blobs of reset commits to be found in the reflog, got %v", paths)
+		assert.True(t, paths["stash@{0}:file.txt"], "Expected stashed blobs to be labelled with the stash entry, got %v", paths)
+		head := git.LatestCommit()
+		assert.True(t, paths["refs/notes/commits:"+head], "Expected notes to be labelled with the object they annotate, got %v", paths)
+		unreachableBlobFound := false
+		for path := range paths {
+			if len(path) == len("unreachable:")+40 && path[:len("unreachable:")] == "unreachable:" {
+				unreachableBlobFound = true
+			}
+		}
+		assert.True(t, unreachableBlobFound, "Expected blobs that were staged and then unstaged to be found, got %v", paths)
+		assert.False(t, paths["file.txt"] || paths["reflog:file.txt"], "Expected blobs of branches not to be listed")
+	})
+}
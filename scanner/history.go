@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"os"
+	"talisman/gitrepo"
+)
+
+// History selects the commits a history scan covers and the files scanned in them
+type History struct {
+	// Revisions are the branches, tags, commits and revision ranges (such as v1.0..v1.1) to scan.
+	// Everything reachable from any ref is scanned when none are given.
+	Revisions []string
+	// Since and Until limit the scan to commits made in a period, in any date format understood by git log
+	Since string
+	Until string
+	// Paths limit the scan to files matching any of these patterns, written the same way as .talismanrc file names
+	Paths []string
+	// HeadOnly limits the scan to the files in the latest commit
+	HeadOnly bool
+	// Deep also scans objects that are not reachable from branches and tags: stash entries, reflog entries,
+	// notes and unreachable objects. The history of the stash and of notes is then left to the deep scan.
+	Deep bool
+}
+
+// IsComplete reports whether every file in every commit is scanned
+func (h History) IsComplete() bool {
+	return !h.HeadOnly && !h.Deep && len(h.Revisions) == 0 && h.Since == "" && h.Until == "" && len(h.Paths) == 0
+}
+
+// refArguments selects every ref that is scanned as part of the regular history
+func (h History) refArguments() []string {
+	if h.Deep {
+		return []string{"--exclude=" + stashRef, "--exclude=" + notesRefs + "*", "--all"}
+	}
+	return []string{"--all"}
+}
+
+func (h History) logArguments() []string {
+	if h.HeadOnly {
+		return []string{"--max-count=1"}
+	}
+	var arguments []string
+	if h.Since != "" {
+		arguments = append(arguments, "--since="+h.Since)
+	}
+	if h.Until != "" {
+		arguments = append(arguments, "--until="+h.Until)
+	}
+	if len(h.Revisions) == 0 {
+		return append(arguments, h.refArguments()...)
+	}
+	// revisions are never read as options, even if they start with a dash
+	return append(append(arguments, "--end-of-options"), h.Revisions...)
+}
+
+// revListArguments selects the history like logArguments, for git commands that, unlike git log, do not default to HEAD
+func (h History) revListArguments() []string {
+	if h.HeadOnly {
+		return append(h.logArguments(), "HEAD")
+	}
+	return h.logArguments()
+}
+
+// includes reports whether a file at filePath is scanned
+func (h History) includes(filePath string) bool {
+	if len(h.Paths) == 0 {
+		return true
+	}
+	addition := gitrepo.NewAddition(filePath, nil)
+	for _, pattern := range h.Paths {
+		if pattern != "" && addition.Matches(pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListMessages lists the messages of the commits in the history, along with the annotations of all annotated tags
+// when the whole history is scanned. Messages have no file path, so none are listed when the scan is limited to some
+// paths.
+func ListMessages(history History) ([]gitrepo.Message, error) {
+	if len(history.Paths) > 0 {
+		return nil, nil
+	}
+	wd, _ := os.Getwd()
+	repo := gitrepo.RepoLocatedAt(wd)
+	messages, err := repo.CommitMessages(history.logArguments()...)
+	if err != nil {
+		return nil, err
+	}
+	if !history.HeadOnly && len(history.Revisions) == 0 {
+		annotations, err := repo.TagAnnotations()
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, annotations...)
+	}
+	return messages, nil
+}
@@ -0,0 +1,46 @@
+package scanner
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistoryIsCompleteOnlyWithoutAnyLimits(t *testing.T) {
+	assert.True(t, History{}.IsComplete())
+	assert.False(t, History{HeadOnly: true}.IsComplete())
+	assert.False(t, History{Revisions: []string{"main"}}.IsComplete())
+	assert.False(t, History{Since: "yesterday"}.IsComplete())
+	assert.False(t, History{Until: "yesterday"}.IsComplete())
+	assert.False(t, History{Paths: []string{"src/"}}.IsComplete())
+}
+
+func TestHistoryLogArguments(t *testing.T) {
+	assert.Equal(t, []string{"--all"}, History{}.logArguments())
+	assert.Equal(t, []string{"--max-count=1"}, History{HeadOnly: true, Revisions: []string{"main"}}.logArguments())
+	assert.Equal(t,
+		[]string{"--since=1 day ago", "--until=today", "--end-of-options", "main", "v1.0..v1.1"},
+		History{Revisions: []string{"main", "v1.0..v1.1"}, Since: "1 day ago", Until: "today"}.logArguments())
+	assert.Equal(t, []string{"--end-of-options", "--output=file"}, History{Revisions: []string{"--output=file"}}.logArguments())
+}
+
+func TestHistoryIncludesPathsMatchingAnyPattern(t *testing.T) {
+	history := History{Paths: []string{"src/", "*.pem", "config/app.yml"}}
+
+	assert.True(t, history.includes("src/main.go"))
+	assert.True(t, history.includes("keys/server.pem"))
+	assert.True(t, history.includes("config/app.yml"))
+	assert.False(t, history.includes("config/other.yml"))
+	assert.False(t, history.includes("README.md"))
+	assert.True(t, History{}.includes("README.md"))
+}
+
+func TestFilterBlobEntriesKeepsEntriesForIncludedPaths(t *testing.T) {
+	entries := []string{
+		"100644 blob 351324aa7b3c66043e484c2f2c7b7f1842152f35	src/main.go",
+		"100644 blob 8715df9907604c8ee8fc5e377821817f84f014fa	README.md",
+	}
+
+	assert.Equal(t, entries[:1], filterBlobEntries(entries, History{Paths: []string{"src/"}}))
+	assert.Equal(t, entries, filterBlobEntries(entries, History{}))
+}
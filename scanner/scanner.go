@@ -1,9 +1,11 @@
 package scanner
 
 import (
-	"log"
+	"fmt"
 	"os/exec"
+	"runtime"
 	"strings"
+	"sync"
 	"talisman/gitrepo"
 	"talisman/utility"
 	"os"
@@ -20,78 +22,317 @@ type BlobsInCommits struct {
 	commits map[blobDetails][]string
 }
 
-// GetAdditions will get all the additions for entire git history
-func GetAdditions(ignoreHistory bool, br gitrepo.BatchReader) []gitrepo.Addition {
-	blobsInCommits := getBlobsInCommit(ignoreHistory)
-	var additions []gitrepo.Addition
-	err := br.Start()
+// Blob is a version of a file in the git history, along with the commits that introduced it
+type Blob struct {
+	Hash    string   `json:"hash"`
+	Path    string   `json:"path"`
+	Commits []string `json:"commits"`
+}
+
+// ListBlobs lists the blobs in the selected git history, without reading their contents
+func ListBlobs(history History) ([]Blob, error) {
+	blobsInCommits, err := getBlobsInCommit(history)
 	if err != nil {
-		logrus.Errorf("error creating file reader %v", err)
+		return nil, err
 	}
-	defer func() {
-		err = br.Shutdown()
-		if err != nil {
-			logrus.Errorf("error creating file reader %v", err)
+	return blobsInCommits.blobs(), nil
+}
+
+// ListBlobsUsingCache lists the blobs that the cache has no results for.
+// Only the commits that the cache has not walked yet are walked to find new blobs.
+func ListBlobsUsingCache(cache *Cache) ([]Blob, error) {
+	commits, err := getAllCommits(History{})
+	if err != nil {
+		return nil, err
+	}
+	newCommits := cache.update(nonEmpty(commits))
+	blobsInCommits, err := getBlobsInCommits(newCommits, History{})
+	if err != nil {
+		return nil, err
+	}
+	cache.add(newCommits, blobsInCommits)
+	pending := cache.unscanned()
+	logrus.Infof("walked %d new commits, %d blobs need to be scanned", len(newCommits), len(pending))
+	blobs := make([]Blob, len(pending))
+	for i, blob := range pending {
+		blobs[i] = blob.Blob
+	}
+	return blobs, nil
+}
+
+// StreamAdditions reads the contents of the blobs one at a time and sends them as additions on the returned channel,
+// which is closed once every blob has been read. Reading is held back until the receiver is ready for more additions,
+// so only a few blobs are held in memory at any time.
+// If a blob cannot be read, the channel is closed early and the returned function, called once the channel is
+// closed, returns the error.
+func StreamAdditions(blobs []Blob, br gitrepo.BatchReader) (<-chan gitrepo.Addition, func() error) {
+	return StreamAdditionsUntil(blobs, br, nil)
+}
+
+// StreamAdditionsUntil streams additions like StreamAdditions, but stops reading blobs once stop is closed.
+// The channel is then closed early, after the additions sent so far, which are always for the first blobs in order.
+func StreamAdditionsUntil(blobs []Blob, br gitrepo.BatchReader, stop <-chan struct{}) (<-chan gitrepo.Addition, func() error) {
+	additions := make(chan gitrepo.Addition, additionsBuffered)
+	var streamErr error
+	go func() {
+		defer close(additions)
+		if len(blobs) == 0 {
+			return
+		}
+		if streamErr = br.Start(); streamErr != nil {
+			return
+		}
+		defer func() {
+			if err := br.Shutdown(); err != nil {
+				logrus.Errorf("error shutting down file reader %v", err)
+			}
+		}()
+
+		for _, blob := range blobs {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			contents, err := br.Read(blob.Hash)
+			if err != nil {
+				streamErr = fmt.Errorf("unable to read %s: %w", blob.Path, err)
+				return
+			}
+			select {
+			case additions <- gitrepo.NewScannerAddition(blob.Path, blob.Commits, contents):
+			case <-stop:
+				return
+			}
 		}
 	}()
+	return additions, func() error { return streamErr }
+}
 
-	for blob := range blobsInCommits.commits {
-		contents, _ := br.Read(blob.hash)
-		newAddition := gitrepo.NewScannerAddition(blob.filePath, blobsInCommits.commits[blob], contents)
-		additions = append(additions, newAddition)
-	}
+const additionsBuffered = 64
 
-	return additions
+func getBlobsInCommit(history History) (BlobsInCommits, error) {
+	commits, err := getAllCommits(history)
+	if err != nil {
+		return BlobsInCommits{}, err
+	}
+	return getBlobsInCommits(nonEmpty(commits), history)
 }
 
-func getBlobsInCommit(ignoreHistory bool) BlobsInCommits {
-	progressBar := utility.GetProgressBar(os.Stdout, "Talisman Fetch Blobs")
-	commits := getAllCommits(ignoreHistory)
-	progressBar.Start(len(commits) - 1)
+// getBlobsInCommits collects the blobs each commit introduces, that is the blobs it adds or changes compared to its
+// parents. The commits are diffed by a bounded number of workers, each running a single git diff-tree for a batch of
+// commits, and the blobs are recorded oldest commit first so that each blob lists the commit that introduced it first.
+func getBlobsInCommits(commits []string, history History) (BlobsInCommits, error) {
 	blobsInCommits := newBlobsInCommit()
-	result := make(chan []string, len(commits))
-	for _, commit := range commits {
-		go putBlobsInChannel(commit, result)
-	}
-	for i := 1; i < len(commits); i++ {
-		progressBar.Increment()
-		getBlobsFromChannel(blobsInCommits, result)
+	if history.HeadOnly {
+		for _, commit := range commits {
+			args := []string{"ls-tree", "-r", "-z", commit}
+			blobDetailsBytes, err := exec.Command("git", args...).Output()
+			if err != nil {
+				return BlobsInCommits{}, commandError(args, err)
+			}
+			addBlobs(blobsInCommits, commit, filterBlobEntries(strings.Split(string(blobDetailsBytes), "\x00"), history))
+		}
+		return blobsInCommits, nil
 	}
+
+	progressBar := utility.GetProgressBar(os.Stdout, "Talisman Fetch Blobs")
+	progressBar.Start(len(commits))
+	err := collectBlobs(blobsInCommits, commits, history, progressBar.Increment)
 	progressBar.Finish()
-	return blobsInCommits
+	return blobsInCommits, err
 }
 
-func putBlobsInChannel(commit string, result chan []string) {
-	if commit != "" {
-		blobDetailsBytes, _ := exec.Command("git", "ls-tree", "-r", commit).CombinedOutput()
-		blobDetailsList := strings.Split(string(blobDetailsBytes), "\n")
-		blobDetailsList = append(blobDetailsList, commit)
-		result <- blobDetailsList
+// collectBlobs adds the blobs the commits introduce to blobsInCommits, calling commitDiffed after each commit is diffed.
+// If diffing any batch of commits fails, the error of the first such batch is returned and no blobs are added.
+func collectBlobs(blobsInCommits BlobsInCommits, commits []string, history History, commitDiffed func()) error {
+	batches := batchesOf(commits, commitsPerBatch)
+	diffs := make([][]commitDiff, len(batches))
+	errs := make([]error, len(batches))
+	jobs := make(chan int)
+	var workers sync.WaitGroup
+	for i := 0; i < min(runtime.NumCPU(), len(batches)); i++ {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for batch := range jobs {
+				diffs[batch], errs[batch] = diffCommits(batches[batch], history, commitDiffed)
+			}
+		}()
 	}
+	for batch := range batches {
+		jobs <- batch
+	}
+	close(jobs)
+	workers.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, batchDiffs := range diffs {
+		for _, diff := range batchDiffs {
+			addBlobs(blobsInCommits, diff.commit, diff.blobEntries)
+		}
+	}
+	return nil
 }
 
-func getBlobsFromChannel(blobsInCommits BlobsInCommits, result chan []string) {
-	blobEntries := <-result
-	commit := blobEntries[len(blobEntries)-1]
-	for _, blobEntry := range blobEntries[:len(blobEntries)-1] {
+const commitsPerBatch = 500
+
+// commitDiff lists the blobs a commit introduces, in the same format as git ls-tree
+type commitDiff struct {
+	commit      string
+	blobEntries []string
+}
+
+func batchesOf(commits []string, size int) [][]string {
+	var batches [][]string
+	for len(commits) > size {
+		batches = append(batches, commits[:size])
+		commits = commits[size:]
+	}
+	if len(commits) > 0 {
+		batches = append(batches, commits)
+	}
+	return batches
+}
+
+// diffCommits diffs each commit against its parents. Merges only introduce the blobs that differ from all of their
+// parents, such as conflict resolutions. The output is NUL separated so that paths are read verbatim rather than
+// quoted: each commit is followed by a record for the header of each path it changes and a record for the path.
+func diffCommits(commits []string, history History, commitDiffed func()) ([]commitDiff, error) {
+	args := []string{"diff-tree", "--stdin", "-r", "--root", "-c", "--always", "--no-renames", "-z"}
+	cmd := exec.Command("git", args...)
+	cmd.Stdin = strings.NewReader(strings.Join(commits, "\n") + "\n")
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, commandError(args, err)
+	}
+	var diffs []commitDiff
+	records := strings.Split(string(out), "\x00")
+	for i := 0; i < len(records); i++ {
+		record := records[i]
+		switch {
+		case record == "":
+		case !strings.HasPrefix(record, ":"):
+			commitDiffed()
+			diffs = append(diffs, commitDiff{commit: strings.Fields(record)[0]})
+		case i+1 < len(records):
+			i++
+			if blobEntry, ok := parseRawDiffRecord(record, records[i]); ok && len(diffs) > 0 && history.includes(blobEntry.path) {
+				current := &diffs[len(diffs)-1]
+				current.blobEntries = append(current.blobEntries, blobEntry.String())
+			}
+		}
+	}
+	return diffs, nil
+}
+
+type rawDiffEntry struct {
+	mode, hash, path string
+}
+
+func (e rawDiffEntry) String() string {
+	objectType := "blob"
+	if e.mode == gitlinkMode {
+		objectType = "commit"
+	}
+	return fmt.Sprintf("%s %s %s\t%s", e.mode, objectType, e.hash, e.path)
+}
+
+const gitlinkMode = "160000"
+
+// parseRawDiffRecord reads the resulting mode and object of a path from the header of its record in NUL separated
+// raw diff output. The header of a diff against a single parent looks like
+//
+//	:<old mode> <new mode> <old hash> <new hash> <status>
+//
+// and the header of a combined diff against n parents starts with n colons and lists n+1 modes and hashes.
+func parseRawDiffRecord(header, path string) (rawDiffEntry, bool) {
+	parents := len(header) - len(strings.TrimLeft(header, ":"))
+	fields := strings.Fields(header[parents:])
+	if path == "" || len(fields) != 2*(parents+1)+1 {
+		return rawDiffEntry{}, false
+	}
+	hash := fields[2*parents+1]
+	if strings.Trim(hash, "0") == "" {
+		return rawDiffEntry{}, false
+	}
+	return rawDiffEntry{mode: fields[parents], hash: hash, path: path}, true
+}
+
+func filterBlobEntries(blobEntries []string, history History) []string {
+	if len(history.Paths) == 0 {
+		return blobEntries
+	}
+	var included []string
+	for _, blobEntry := range blobEntries {
+		if _, filePath, found := strings.Cut(blobEntry, "\t"); found && history.includes(filePath) {
+			included = append(included, blobEntry)
+		}
+	}
+	return included
+}
+
+// addBlobs records that commit introduced the blobs, given in git ls-tree format. Entries for anything other than
+// blobs, such as submodules, are skipped.
+func addBlobs(blobsInCommits BlobsInCommits, commit string, blobEntries []string) {
+	for _, blobEntry := range blobEntries {
 		if blobEntry != "" {
-			blobHashAndName := strings.Split(strings.Split(blobEntry, " ")[2], "\t")
+			fields := strings.SplitN(blobEntry, " ", 3)
+			if len(fields) != 3 || fields[1] != "blob" {
+				continue
+			}
+			blobHashAndName := strings.SplitN(fields[2], "\t", 2)
 			blob := blobDetails{hash: blobHashAndName[0], filePath: blobHashAndName[1]}
 			blobsInCommits.commits[blob] = append(blobsInCommits.commits[blob], commit)
 		}
 	}
 }
 
-func getAllCommits(ignoreHistory bool) []string {
-	commitRange := "--all"
-	if ignoreHistory {
-		commitRange = "--max-count=1"
+func getAllCommits(history History) ([]string, error) {
+	arguments := append(append([]string{"log", "--pretty=%H", "--topo-order", "--reverse"}, history.logArguments()...), "--")
+	out, err := gitOutput(arguments...)
+	if err != nil {
+		return nil, err
 	}
-	out, err := exec.Command("git", "log", commitRange, "--pretty=%H").CombinedOutput()
+	return strings.Split(string(out), "\n"), nil
+}
+
+// gitOutput runs git in the current directory and returns its output, or its error typed by commandError
+func gitOutput(args ...string) ([]byte, error) {
+	out, err := exec.Command("git", args...).Output()
 	if err != nil {
-		log.Fatal(err)
+		return nil, commandError(args, err)
+	}
+	return out, nil
+}
+
+// commandError types the error of a git command run in the current directory, such as the history of a shallow clone
+// being cut short
+func commandError(args []string, err error) error {
+	wd, _ := os.Getwd()
+	return gitrepo.RepoLocatedAt(wd).CommandError(args, err)
+}
+
+func nonEmpty(commits []string) []string {
+	var result []string
+	for _, commit := range commits {
+		if commit != "" {
+			result = append(result, commit)
+		}
+	}
+	return result
+}
+
+func (b BlobsInCommits) blobs() []Blob {
+	blobs := make([]Blob, 0, len(b.commits))
+	for blob, commits := range b.commits {
+		blobs = append(blobs, Blob{Hash: blob.hash, Path: blob.filePath, Commits: commits})
 	}
-	return strings.Split(string(out), "\n")
+	return blobs
 }
 
 func newBlobsInCommit() BlobsInCommits {
@@ -1,8 +1,16 @@
 package scanner
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"talisman/git_testing"
+	"talisman/gitrepo"
 	"testing"
+	"time"
 
 	logr "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -10,22 +18,230 @@ import (
 
 func init() {
 	logr.SetOutput(ioutil.Discard)
+	git_testing.Logger = logr.WithField("Environment", "Debug")
 }
 
-func Test_getBlobsFromChannel(t *testing.T) {
-	ch := make(chan []string)
-	go func() {
-		ch <- []string{
-			"100644 blob 351324aa7b3c66043e484c2f2c7b7f1842152f35	.gitignore",
-			"100644 blob 8715df9907604c8ee8fc5e377821817f84f014fa	.pre-commit-hooks.yaml",
-			"commitSha",
-		}
-	}()
+func Test_addBlobs(t *testing.T) {
 	blobsInCommits := BlobsInCommits{commits: map[blobDetails][]string{}}
-	getBlobsFromChannel(blobsInCommits, ch)
+	addBlobs(blobsInCommits, "commitSha", []string{
+		"100644 blob 351324aa7b3c66043e484c2f2c7b7f1842152f35	.gitignore",
+		"100644 blob 8715df9907604c8ee8fc5e377821817f84f014fa	.pre-commit-hooks.yaml",
+		"160000 commit 5ad3b6c1bd01b8e2ea7bbd0fb4f06ef4f7a8d3a2	vendor/library",
+		"",
+	})
 
 	commits := blobsInCommits.commits
 	assert.Len(t, commits, 2)
 	assert.Equal(t, []string{"commitSha"}, commits[blobDetails{"351324aa7b3c66043e484c2f2c7b7f1842152f35", ".gitignore"}])
 	assert.Equal(t, []string{"commitSha"}, commits[blobDetails{"8715df9907604c8ee8fc5e377821817f84f014fa", ".pre-commit-hooks.yaml"}])
 }
+
+func Test_parseRawDiffRecord(t *testing.T) {
+	entry, ok := parseRawDiffRecord(":100644 100755 351324aa7b3c66043e484c2f2c7b7f1842152f35 8715df9907604c8ee8fc5e377821817f84f014fa M", "dir/file name.sh")
+	assert.True(t, ok)
+	assert.Equal(t, "100755 blob 8715df9907604c8ee8fc5e377821817f84f014fa\tdir/file name.sh", entry.String())
+
+	entry, ok = parseRawDiffRecord("::100644 100644 100644 7898192261 7898192261 53c74cd6c8 MM", "a.txt")
+	assert.True(t, ok, "Expected records of combined diffs of merges to be read")
+	assert.Equal(t, rawDiffEntry{mode: "100644", hash: "53c74cd6c8", path: "a.txt"}, entry)
+
+	entry, ok = parseRawDiffRecord(":000000 160000 0000000000000000000000000000000000000000 5ad3b6c1bd01b8e2ea7bbd0fb4f06ef4f7a8d3a2 A", "vendor/library")
+	assert.True(t, ok)
+	assert.Equal(t, "160000 commit 5ad3b6c1bd01b8e2ea7bbd0fb4f06ef4f7a8d3a2\tvendor/library", entry.String())
+
+	_, ok = parseRawDiffRecord(":100644 000000 351324aa7b3c66043e484c2f2c7b7f1842152f35 0000000000000000000000000000000000000000 D", "deleted.txt")
+	assert.False(t, ok, "Expected deleted files to be skipped")
+
+	_, ok = parseRawDiffRecord("not a diff record", "")
+	assert.False(t, ok)
+}
+
+func Test_batchesOf(t *testing.T) {
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, batchesOf([]string{"a", "b", "c"}, 2))
+	assert.Equal(t, [][]string{{"a", "b"}}, batchesOf([]string{"a", "b"}, 2))
+	assert.Empty(t, batchesOf(nil, 2))
+}
+
+func TestGetBlobsInCommitsRecordsTheCommitsThatIntroducedEachBlob(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		wd, _ := os.Getwd()
+		defer os.Chdir(wd)
+		os.Chdir(git.Root())
+		git.CreateFileWithContents("file.txt", "first version")
+		git.AddAndcommit("*", "Add file")
+		introduced := git.LatestCommit()
+		git.CreateFileWithContents("other.txt", "unrelated")
+		git.AddAndcommit("*", "Add other file")
+		git.OverwriteFileContent("file.txt", "second version")
+		git.AddAndcommit("*", "Change file")
+		changed := git.LatestCommit()
+		git.OverwriteFileContent("file.txt", "first version")
+		git.AddAndcommit("*", "Revert file")
+		reverted := git.LatestCommit()
+
+		blobsInCommits, err := getBlobsInCommit(History{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		firstVersionHash, _ := exec.Command("git", "rev-parse", "HEAD:file.txt").Output()
+		firstVersion := blobDetails{hash: strings.TrimSpace(string(firstVersionHash)), filePath: "file.txt"}
+		assert.Equal(t, []string{introduced, reverted}, blobsInCommits.commits[firstVersion])
+		assert.Len(t, blobsInCommits.commits, 3)
+		for blob, commits := range blobsInCommits.commits {
+			if blob.filePath == "file.txt" && blob != firstVersion {
+				assert.Equal(t, []string{changed}, commits)
+			}
+		}
+	})
+}
+
+func TestGetBlobsInCommitsOfHeadOnlyListsEveryFile(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		wd, _ := os.Getwd()
+		defer os.Chdir(wd)
+		os.Chdir(git.Root())
+		git.CreateFileWithContents("file.txt", "contents")
+		git.AddAndcommit("*", "Add file")
+		git.CreateFileWithContents("other.txt", "unrelated")
+		git.AddAndcommit("*", "Add other file")
+
+		blobsInCommits, err := getBlobsInCommit(History{HeadOnly: true})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		assert.Len(t, blobsInCommits.commits, 2)
+		for _, commits := range blobsInCommits.commits {
+			assert.Equal(t, []string{git.LatestCommit()}, commits)
+		}
+	})
+}
+
+func TestGetBlobsInCommitsReadsPathsVerbatim(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		wd, _ := os.Getwd()
+		defer os.Chdir(wd)
+		os.Chdir(git.Root())
+		git.CreateFileWithContents("configs/prod secrets.txt", "contents")
+		git.CreateFileWithContents("configs/clé.txt", "contents")
+		git.AddAndcommit("*", "Add files")
+
+		for _, history := range []History{{}, {HeadOnly: true}} {
+			blobsInCommits, err := getBlobsInCommit(history)
+			if !assert.NoError(t, err) {
+				return
+			}
+
+			var filePaths []string
+			for blob := range blobsInCommits.commits {
+				filePaths = append(filePaths, blob.filePath)
+			}
+			assert.ElementsMatch(t, []string{"configs/prod secrets.txt", "configs/clé.txt"}, filePaths)
+		}
+	})
+}
+
+// countingReader returns the hash of a blob as its contents, and counts how many blobs have been read
+type countingReader struct {
+	read     atomic.Int32
+	shutdown bool
+}
+
+func (r *countingReader) Start() error { return nil }
+
+func (r *countingReader) Read(hash string) ([]byte, error) {
+	r.read.Add(1)
+	return []byte(hash), nil
+}
+
+func (r *countingReader) Shutdown() error {
+	r.shutdown = true
+	return nil
+}
+
+func TestStreamAdditionsReadsBlobsOnlyAsTheyAreReceived(t *testing.T) {
+	blobs := make([]Blob, additionsBuffered*4)
+	for i := range blobs {
+		blobs[i] = Blob{Hash: fmt.Sprintf("hash-%d", i), Path: fmt.Sprintf("file-%d", i), Commits: []string{"commit"}}
+	}
+	reader := &countingReader{}
+
+	additions, streamErr := StreamAdditions(blobs, reader)
+	first := <-additions
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, gitrepo.NewScannerAddition("file-0", []string{"commit"}, []byte("hash-0")), first)
+	assert.LessOrEqual(t, int(reader.read.Load()), additionsBuffered+2, "Expected reading to be held back until additions are received")
+	received := 1
+	for range additions {
+		received++
+	}
+	assert.Equal(t, len(blobs), received)
+	assert.True(t, reader.shutdown)
+	assert.NoError(t, streamErr())
+}
+
+func TestStreamAdditionsUntilStopsSendingOnceStopped(t *testing.T) {
+	blobs := make([]Blob, additionsBuffered*4)
+	for i := range blobs {
+		blobs[i] = Blob{Hash: fmt.Sprintf("hash-%d", i), Path: fmt.Sprintf("file-%d", i)}
+	}
+	reader := &countingReader{}
+	stop := make(chan struct{})
+
+	additions, _ := StreamAdditionsUntil(blobs, reader, stop)
+	first := <-additions
+	close(stop)
+	received := 1
+	for addition := range additions {
+		assert.Equal(t, gitrepo.FilePath(fmt.Sprintf("file-%d", received)), addition.Path, "Expected additions sent before stopping to be in order")
+		received++
+	}
+
+	assert.Equal(t, gitrepo.FilePath("file-0"), first.Path)
+	assert.Less(t, received, len(blobs))
+	assert.True(t, reader.shutdown)
+}
+
+// failingReader fails to read the blob with the given hash
+type failingReader struct {
+	countingReader
+	failOn string
+}
+
+func (r *failingReader) Read(hash string) ([]byte, error) {
+	if hash == r.failOn {
+		return nil, gitrepo.ErrMissingObject
+	}
+	return r.countingReader.Read(hash)
+}
+
+func TestStreamAdditionsStopsAtTheFirstBlobThatCannotBeRead(t *testing.T) {
+	blobs := []Blob{{Hash: "hash-0", Path: "file-0"}, {Hash: "hash-1", Path: "file-1"}, {Hash: "hash-2", Path: "file-2"}}
+	reader := &failingReader{failOn: "hash-1"}
+
+	additions, streamErr := StreamAdditions(blobs, reader)
+	var received []gitrepo.FilePath
+	for addition := range additions {
+		received = append(received, addition.Path)
+	}
+
+	assert.Equal(t, []gitrepo.FilePath{"file-0"}, received)
+	assert.ErrorIs(t, streamErr(), gitrepo.ErrMissingObject)
+	assert.True(t, reader.shutdown)
+}
+
+func TestListBlobsReturnsAnErrorForRevisionsThatDoNotExist(t *testing.T) {
+	git_testing.DoInTempGitRepo(func(git *git_testing.GitTesting) {
+		wd, _ := os.Getwd()
+		defer os.Chdir(wd)
+		os.Chdir(git.Root())
+		git.CreateFileWithContents("file.txt", "contents")
+		git.AddAndcommit("*", "Add file")
+
+		_, err := ListBlobs(History{Revisions: []string{"does-not-exist"}})
+
+		assert.ErrorIs(t, err, gitrepo.ErrMissingObject)
+	})
+}
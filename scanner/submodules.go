@@ -0,0 +1,32 @@
+package scanner
+
+import (
+	"fmt"
+	"os"
+	"path"
+)
+
+// ListSubmoduleBlobs lists the blobs in the history of the submodule checked out at the given path, with their paths
+// given from the top level of the superproject. Revisions name commits of the superproject, so the whole history of
+// the submodule is listed, limited only by the dates, the paths and whether only the latest commit is scanned.
+// Like the rest of the scanner, git is run in the current directory, so it is changed to the submodule until the
+// blobs are listed.
+func ListSubmoduleBlobs(history History, submodule string) ([]Blob, error) {
+	wd, _ := os.Getwd()
+	if err := os.Chdir(submodule); err != nil {
+		return nil, err
+	}
+	defer os.Chdir(wd)
+	blobs, err := ListBlobs(History{Since: history.Since, Until: history.Until, HeadOnly: history.HeadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("unable to list the blobs of submodule %s: %w", submodule, err)
+	}
+	var included []Blob
+	for _, blob := range blobs {
+		blob.Path = path.Join(submodule, blob.Path)
+		if history.includes(blob.Path) {
+			included = append(included, blob)
+		}
+	}
+	return included, nil
+}
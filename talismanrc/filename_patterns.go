@@ -0,0 +1,11 @@
+package talismanrc
+
+// filenamePatternIDs are the IDs of the built-in file name patterns, which can be named in disabled_filename_patterns.
+// They are registered by the filename detector, which holds the patterns.
+var filenamePatternIDs []string
+
+// RegisterFilenamePatterns records the IDs of built-in file name patterns, against which disabled_filename_patterns
+// is validated
+func RegisterFilenamePatterns(ids ...string) {
+	filenamePatternIDs = append(filenamePatternIDs, ids...)
+}
@@ -0,0 +1,264 @@
+package talismanrc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// SourceDefault is the source of settings that are neither configured nor overridden
+	SourceDefault = "default"
+	// SourceCommandLine is the source of settings overridden with --set
+	SourceCommandLine = "--set"
+	// DefaultBase64EntropyThreshold is the entropy above which base64 encoded text is considered a secret
+	DefaultBase64EntropyThreshold = 4.5
+)
+
+// defaultValues describes the value used for settings that are not configured
+var defaultValues = map[string]string{
+	"threshold":                           "low",
+	"checksum_version":                    fmt.Sprint(ChecksumVersionPathAndContent),
+	"experimental.base64EntropyThreshold": fmt.Sprint(DefaultBase64EntropyThreshold),
+}
+
+// Override is a value for a .talismanrc setting that is supplied from outside the file
+type Override struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// ResolvedSetting is the value of a setting after overrides are applied, along with where it came from
+type ResolvedSetting struct {
+	Key                 string
+	EnvironmentVariable string
+	Value               string
+	Source              string
+}
+
+// overridableSetting is a setting whose value can be written on a single line, that is a scalar or a list of scalars.
+// The items of lists of regular expressions may hold commas, so they are not split on them.
+type overridableSetting struct {
+	key   string
+	list  bool
+	regex bool
+}
+
+var overridableSettings = collectOverridableSettings(rcSchema, "")
+
+func collectOverridableSettings(s *schema, parent string) []overridableSetting {
+	var settings []overridableSetting
+	for _, name := range s.propertyNames() {
+		property := s.Properties[name]
+		key := joinLocation(parent, name)
+		switch {
+		case key == "version":
+			continue
+		case property.Type == "object":
+			settings = append(settings, collectOverridableSettings(property, key)...)
+		case property.Type == "array":
+			if property.Items != nil && property.Items.isScalar() {
+				settings = append(settings, overridableSetting{key: key, list: true, regex: property.Items.Format == "regex"})
+			}
+		case property.isScalar():
+			settings = append(settings, overridableSetting{key: key})
+		}
+	}
+	return settings
+}
+
+func (s *schema) isScalar() bool {
+	return len(s.OneOf) == 0 && (s.Type == "string" || s.Type == "number" || s.Type == "integer")
+}
+
+// EnvironmentVariableFor returns the name of the environment variable that overrides a setting,
+// such as TALISMAN_EXPERIMENTAL_BASE64_ENTROPY_THRESHOLD for experimental.base64EntropyThreshold
+func EnvironmentVariableFor(key string) string {
+	name := strings.Builder{}
+	name.WriteString("TALISMAN_")
+	previous := rune(0)
+	for _, c := range key {
+		switch {
+		case c == '.':
+			name.WriteRune('_')
+		case unicode.IsUpper(c) && unicode.IsLower(previous), unicode.IsDigit(previous) && unicode.IsLetter(c):
+			name.WriteRune('_')
+			name.WriteRune(unicode.ToUpper(c))
+		default:
+			name.WriteRune(unicode.ToUpper(c))
+		}
+		previous = c
+	}
+	return name.String()
+}
+
+// EnvironmentOverrides returns overrides for the settings that have their environment variable set
+func EnvironmentOverrides(lookupEnv func(string) (string, bool)) []Override {
+	var overrides []Override
+	for _, setting := range overridableSettings {
+		variable := EnvironmentVariableFor(setting.key)
+		if value, ok := lookupEnv(variable); ok {
+			overrides = append(overrides, Override{Key: setting.key, Value: value, Source: variable})
+		}
+	}
+	return overrides
+}
+
+// CommandLineOverrides returns overrides for assignments of the form key=value
+func CommandLineOverrides(assignments []string) ([]Override, error) {
+	var overrides []Override
+	for _, assignment := range assignments {
+		key, value, found := strings.Cut(assignment, "=")
+		if !found {
+			return nil, fmt.Errorf("%s: expected key=value, but got %q", SourceCommandLine, assignment)
+		}
+		overrides = append(overrides, Override{Key: strings.TrimSpace(key), Value: value, Source: SourceCommandLine})
+	}
+	return overrides, nil
+}
+
+// ApplyOverrides sets the overridden values on top of those loaded from .talismanrc.
+// Each value is validated against the .talismanrc schema, and later overrides take precedence over earlier ones.
+func (tRC *TalismanRC) ApplyOverrides(overrides []Override) error {
+	for _, override := range overrides {
+		setting, known := overridableSettingFor(override.Key)
+		if !known {
+			return fmt.Errorf("%s: unknown setting %q%s", override.Source, override.Key, suggestion(override.Key, overridableKeys()))
+		}
+		contents, err := yaml.Marshal(setting.document(override.Value))
+		if err != nil {
+			return fmt.Errorf("%s: %v", override.Source, err)
+		}
+		if validationErrors := ValidateContents(override.Source, contents); len(validationErrors) > 0 {
+			var messages []string
+			for _, validationError := range validationErrors {
+				messages = append(messages, validationError.Message)
+			}
+			return fmt.Errorf("%s: %s", override.Source, strings.Join(messages, "; "))
+		}
+		if err := yaml.Unmarshal(contents, tRC); err != nil {
+			return fmt.Errorf("%s: %v", override.Source, err)
+		}
+		if tRC.sources == nil {
+			tRC.sources = make(map[string]string)
+		}
+		tRC.sources[setting.key] = override.Source
+	}
+	return nil
+}
+
+// ResolvedSettings returns the value of every overridable setting and whether it came from
+// .talismanrc, an environment variable, the command line or the defaults
+func (tRC *TalismanRC) ResolvedSettings() []ResolvedSetting {
+	configured := map[interface{}]interface{}{}
+	contents, _ := yaml.Marshal(tRC)
+	_ = yaml.Unmarshal(contents, &configured)
+
+	var resolved []ResolvedSetting
+	for _, setting := range overridableSettings {
+		resolvedSetting := ResolvedSetting{
+			Key:                 setting.key,
+			EnvironmentVariable: EnvironmentVariableFor(setting.key),
+			Value:               defaultValues[setting.key],
+			Source:              SourceDefault,
+		}
+		if value, found := lookup(configured, setting.key); found {
+			resolvedSetting.Value = render(value)
+			resolvedSetting.Source = RCFileName
+		}
+		if source, overridden := tRC.sources[setting.key]; overridden {
+			resolvedSetting.Source = source
+		}
+		resolved = append(resolved, resolvedSetting)
+	}
+	return resolved
+}
+
+// document returns a .talismanrc document that sets only this setting. Lists are given as comma separated values,
+// apart from lists of regular expressions, whose value is a single regular expression.
+func (s overridableSetting) document(value string) map[string]interface{} {
+	var typedValue interface{}
+	switch trimmed := strings.TrimSpace(value); {
+	case s.list && s.regex:
+		items := []interface{}{}
+		if trimmed != "" {
+			items = append(items, trimmed)
+		}
+		typedValue = items
+	case s.list:
+		items := []interface{}{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, typedScalar(item))
+			}
+		}
+		typedValue = items
+	case trimmed != "":
+		typedValue = typedScalar(trimmed)
+	}
+	path := strings.Split(s.key, ".")
+	document := map[string]interface{}{path[len(path)-1]: typedValue}
+	for i := len(path) - 2; i >= 0; i-- {
+		document = map[string]interface{}{path[i]: document}
+	}
+	return document
+}
+
+// typedScalar interprets a value the way it would be read from .talismanrc, so that numbers stay numbers
+func typedScalar(value string) interface{} {
+	var typed interface{}
+	if err := yaml.Unmarshal([]byte(value), &typed); err != nil {
+		return value
+	}
+	switch typed.(type) {
+	case int, float64, string:
+		return typed
+	}
+	return value
+}
+
+func overridableSettingFor(key string) (overridableSetting, bool) {
+	for _, setting := range overridableSettings {
+		if setting.key == key {
+			return setting, true
+		}
+	}
+	return overridableSetting{}, false
+}
+
+func overridableKeys() []string {
+	var keys []string
+	for _, setting := range overridableSettings {
+		keys = append(keys, setting.key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func lookup(document map[interface{}]interface{}, key string) (interface{}, bool) {
+	path := strings.Split(key, ".")
+	for _, name := range path[:len(path)-1] {
+		nested, ok := document[name].(map[interface{}]interface{})
+		if !ok {
+			return nil, false
+		}
+		document = nested
+	}
+	value, found := document[path[len(path)-1]]
+	return value, found
+}
+
+func render(value interface{}) string {
+	if items, ok := value.([]interface{}); ok {
+		var rendered []string
+		for _, item := range items {
+			rendered = append(rendered, fmt.Sprint(item))
+		}
+		return strings.Join(rendered, ", ")
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,129 @@
+package talismanrc
+
+import (
+	"testing"
+
+	"talisman/detector/severity"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnvironmentVariableNames(t *testing.T) {
+	assert.Equal(t, "TALISMAN_THRESHOLD", EnvironmentVariableFor("threshold"))
+	assert.Equal(t, "TALISMAN_CHECKSUM_VERSION", EnvironmentVariableFor("checksum_version"))
+	assert.Equal(t, "TALISMAN_EXPERIMENTAL_BASE64_ENTROPY_THRESHOLD", EnvironmentVariableFor("experimental.base64EntropyThreshold"))
+}
+
+func TestOverridableSettings(t *testing.T) {
+	assert.Equal(t, []string{
+		"allowed_patterns",
+		"checksum_version",
+		"disabled_detectors",
+		"disabled_filename_patterns",
+		"experimental.base64EntropyThreshold",
+		"threshold",
+	}, overridableKeys())
+}
+
+func TestApplyingOverrides(t *testing.T) {
+	t.Run("Overrides values loaded from .talismanrc", func(t *testing.T) {
+		tRC, _ := talismanRCFromYaml([]byte(fullyConfiguredTalismanRC))
+		err := tRC.ApplyOverrides([]Override{
+			{Key: "threshold", Value: "high", Source: "TALISMAN_THRESHOLD"},
+			{Key: "experimental.base64EntropyThreshold", Value: "4.8", Source: SourceCommandLine},
+			{Key: "disabled_detectors", Value: "filename, filecontent", Source: SourceCommandLine},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, severity.High, tRC.Threshold)
+		assert.Equal(t, 4.8, tRC.Experimental.Base64EntropyThreshold)
+		assert.Equal(t, []string{"filename", "filecontent"}, tRC.DisabledDetectors)
+		assert.Equal(t, "existing.pem", tRC.FileIgnoreConfig[0].FileName, "Expected settings that are not overridden to be kept")
+	})
+
+	t.Run("Later overrides take precedence", func(t *testing.T) {
+		tRC := &TalismanRC{}
+		err := tRC.ApplyOverrides([]Override{
+			{Key: "threshold", Value: "high", Source: "TALISMAN_THRESHOLD"},
+			{Key: "threshold", Value: "medium", Source: SourceCommandLine},
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, severity.Medium, tRC.Threshold)
+	})
+
+	t.Run("Reports invalid values with their source", func(t *testing.T) {
+		tRC := &TalismanRC{}
+		err := tRC.ApplyOverrides([]Override{{Key: "threshold", Value: "severe", Source: "TALISMAN_THRESHOLD"}})
+
+		assert.EqualError(t, err, `TALISMAN_THRESHOLD: invalid value "severe" for threshold, expected one of: low, medium, high`)
+	})
+
+	t.Run("Takes regular expressions whole, whatever commas they hold", func(t *testing.T) {
+		tRC := &TalismanRC{}
+		err := tRC.ApplyOverrides([]Override{{Key: "allowed_patterns", Value: "key={1,3}", Source: SourceCommandLine}})
+
+		assert.NoError(t, err)
+		assert.Len(t, tRC.AllowedPatterns, 1)
+		assert.Equal(t, "key={1,3}", tRC.AllowedPatterns[0].String())
+	})
+
+	t.Run("Reports values of the wrong type", func(t *testing.T) {
+		tRC := &TalismanRC{}
+		err := tRC.ApplyOverrides([]Override{{Key: "experimental.base64EntropyThreshold", Value: "high", Source: SourceCommandLine}})
+
+		assert.EqualError(t, err, "--set: experimental.base64EntropyThreshold should be a number")
+	})
+
+	t.Run("Reports unknown settings", func(t *testing.T) {
+		tRC := &TalismanRC{}
+		err := tRC.ApplyOverrides([]Override{{Key: "treshold", Value: "high", Source: SourceCommandLine}})
+
+		assert.EqualError(t, err, `--set: unknown setting "treshold" (did you mean "threshold"?)`)
+	})
+}
+
+func TestCollectingOverrides(t *testing.T) {
+	t.Run("Reads overrides from environment variables", func(t *testing.T) {
+		environment := map[string]string{"TALISMAN_THRESHOLD": "high", "TALISMAN_INTERACTIVE": "true"}
+		overrides := EnvironmentOverrides(func(name string) (string, bool) {
+			value, ok := environment[name]
+			return value, ok
+		})
+
+		assert.Equal(t, []Override{{Key: "threshold", Value: "high", Source: "TALISMAN_THRESHOLD"}}, overrides)
+	})
+
+	t.Run("Reads overrides from key=value assignments", func(t *testing.T) {
+		overrides, err := CommandLineOverrides([]string{"threshold=high", "allowed_patterns=a=b"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, []Override{
+			{Key: "threshold", Value: "high", Source: SourceCommandLine},
+			{Key: "allowed_patterns", Value: "a=b", Source: SourceCommandLine},
+		}, overrides)
+	})
+
+	t.Run("Reports assignments without a value", func(t *testing.T) {
+		_, err := CommandLineOverrides([]string{"threshold"})
+
+		assert.EqualError(t, err, `--set: expected key=value, but got "threshold"`)
+	})
+}
+
+func TestResolvingSettings(t *testing.T) {
+	tRC, _ := talismanRCFromYaml([]byte(fullyConfiguredTalismanRC))
+	err := tRC.ApplyOverrides([]Override{{Key: "experimental.base64EntropyThreshold", Value: "4.8", Source: SourceCommandLine}})
+	assert.NoError(t, err)
+
+	resolved := map[string]ResolvedSetting{}
+	for _, setting := range tRC.ResolvedSettings() {
+		resolved[setting.Key] = setting
+	}
+
+	assert.Equal(t, ResolvedSetting{"threshold", "TALISMAN_THRESHOLD", "medium", RCFileName}, resolved["threshold"])
+	assert.Equal(t, ResolvedSetting{"experimental.base64EntropyThreshold", "TALISMAN_EXPERIMENTAL_BASE64_ENTROPY_THRESHOLD", "4.8", SourceCommandLine}, resolved["experimental.base64EntropyThreshold"])
+	assert.Equal(t, ResolvedSetting{"checksum_version", "TALISMAN_CHECKSUM_VERSION", "1", SourceDefault}, resolved["checksum_version"])
+	assert.Equal(t, ResolvedSetting{"allowed_patterns", "TALISMAN_ALLOWED_PATTERNS", "this-is-okay, key={listOfThings.id}", RCFileName}, resolved["allowed_patterns"])
+	assert.Equal(t, ResolvedSetting{"disabled_detectors", "TALISMAN_DISABLED_DETECTORS", "", SourceDefault}, resolved["disabled_detectors"])
+}
@@ -15,6 +15,13 @@ const (
 	DefaultRCVersion = "1.0"
 )
 
+const (
+	// ChecksumVersionPathAndContent is the default checksum scheme, which hashes the paths of files along with their contents
+	ChecksumVersionPathAndContent = 1
+	// ChecksumVersionContent hashes the contents of files alone, so that checksums survive renames and copies
+	ChecksumVersionContent = 2
+)
+
 var (
 	fs = afero.NewOsFs()
 )
@@ -26,6 +33,10 @@ func Load() (*TalismanRC, error) {
 		// File does not exist or is not readable, proceed as if there is no .talismanrc
 		fileContents = []byte{}
 	}
+	if validationErrors := ValidateContents(RCFileName, fileContents); len(validationErrors) > 0 {
+		fmt.Println(FormatValidationErrors(validationErrors))
+		return &TalismanRC{}, fmt.Errorf("%s has %d validation error(s)", RCFileName, len(validationErrors))
+	}
 	return talismanRCFromYaml(fileContents)
 }
 
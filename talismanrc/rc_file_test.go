@@ -33,7 +33,7 @@ func TestLoadingFromFile(t *testing.T) {
 			AllowedPatterns: []*Pattern{
 				{regexp.MustCompile("this-is-okay")},
 				{regexp.MustCompile("key={listOfThings.id}")}},
-			CustomPatterns: []PatternString{"this-isn't-okay"},
+			CustomPatterns: []CustomPattern{{Regex: "this-isn't-okay"}},
 			Threshold:      severity.Medium,
 			CustomSeverities: []CustomSeverityConfig{
 				{Detector: "HexContent", Severity: severity.Low}},
@@ -12,14 +12,22 @@ import (
 )
 
 type TalismanRC struct {
-	FileIgnoreConfig []FileIgnoreConfig     `yaml:"fileignoreconfig,omitempty"`
-	ScopeConfig      []ScopeConfig          `yaml:"scopeconfig,omitempty"`
-	CustomPatterns   []PatternString        `yaml:"custom_patterns,omitempty"`
-	CustomSeverities []CustomSeverityConfig `yaml:"custom_severities,omitempty"`
-	AllowedPatterns  []*Pattern             `yaml:"allowed_patterns,omitempty"`
-	Experimental     ExperimentalConfig     `yaml:"experimental,omitempty"`
-	Threshold        severity.Severity      `yaml:"threshold,omitempty"`
-	Version          string                 `yaml:"version"`
+	FileIgnoreConfig         []FileIgnoreConfig      `yaml:"fileignoreconfig,omitempty"`
+	ScopeConfig              []ScopeConfig           `yaml:"scopeconfig,omitempty"`
+	CustomScopes             []CustomScopeConfig     `yaml:"custom_scopes,omitempty"`
+	CustomPatterns           []CustomPattern         `yaml:"custom_patterns,omitempty"`
+	CustomFilenamePatterns   []CustomFilenamePattern `yaml:"custom_filename_patterns,omitempty"`
+	DisabledFilenamePatterns []string                `yaml:"disabled_filename_patterns,omitempty"`
+	DisabledDetectors        []string                `yaml:"disabled_detectors,omitempty"`
+	CustomSeverities         []CustomSeverityConfig  `yaml:"custom_severities,omitempty"`
+	AllowedPatterns          []*Pattern              `yaml:"allowed_patterns,omitempty"`
+	Experimental             ExperimentalConfig      `yaml:"experimental,omitempty"`
+	Threshold                severity.Severity       `yaml:"threshold,omitempty"`
+	ChecksumVersion          int                     `yaml:"checksum_version,omitempty"`
+	Version                  string                  `yaml:"version"`
+
+	// sources records where overridden settings came from, keyed by setting
+	sources map[string]string
 }
 
 // SuggestRCFor returns a string representation of a .talismanrc for the specified FileIgnoreConfigs
@@ -30,14 +38,25 @@ func SuggestRCFor(configs []FileIgnoreConfig) string {
 	return string(result)
 }
 
+// GetChecksumVersion returns the checksum scheme that new ignore entries should be created with
+func (tRC *TalismanRC) GetChecksumVersion() int {
+	if tRC.ChecksumVersion == 0 {
+		return ChecksumVersionPathAndContent
+	}
+	return tRC.ChecksumVersion
+}
+
+// IsDetectorEnabled answers false if the named detector has been disabled for the whole repository
+func (tRC *TalismanRC) IsDetectorEnabled(detectorName string) bool {
+	return !contains(tRC.DisabledDetectors, detectorName)
+}
+
 // RemoveScopedFiles removes scope files from additions
 func (tRC *TalismanRC) RemoveScopedFiles(additions []gitrepo.Addition) []gitrepo.Addition {
 	var applicableScopeFileNames []string
 	if tRC.ScopeConfig != nil {
 		for _, scope := range tRC.ScopeConfig {
-			if len(knownScopes[scope.ScopeName]) > 0 {
-				applicableScopeFileNames = append(applicableScopeFileNames, knownScopes[scope.ScopeName]...)
-			}
+			applicableScopeFileNames = append(applicableScopeFileNames, tRC.scopeFileNames(scope.ScopeName)...)
 		}
 	}
 	var result []gitrepo.Addition
@@ -56,6 +75,17 @@ func (tRC *TalismanRC) RemoveScopedFiles(additions []gitrepo.Addition) []gitrepo
 	return result
 }
 
+// scopeFileNames returns the names of files in a scope, whether it is a known scope or one defined in the .talismanrc
+func (tRC *TalismanRC) scopeFileNames(scopeName string) []string {
+	fileNames := append([]string{}, knownScopes[scopeName]...)
+	for _, customScope := range tRC.CustomScopes {
+		if customScope.ScopeName == scopeName {
+			fileNames = append(fileNames, customScope.Files...)
+		}
+	}
+	return fileNames
+}
+
 // AddIgnores inserts the specified FileIgnoreConfigs to an existing .talismanrc file, or creates one if it doesn't exist.
 func (tRC *TalismanRC) AddIgnores(entriesToAdd []FileIgnoreConfig) {
 	if len(entriesToAdd) > 0 {
@@ -65,6 +95,35 @@ func (tRC *TalismanRC) AddIgnores(entriesToAdd []FileIgnoreConfig) {
 	}
 }
 
+// RenameIgnores replaces existing FileIgnoreConfigs, keyed by the file name they had, with updated ones
+func (tRC *TalismanRC) RenameIgnores(renamedEntries map[string]FileIgnoreConfig) {
+	if len(renamedEntries) > 0 {
+		logr.Debugf("Renaming entries: %v", renamedEntries)
+		var remaining []FileIgnoreConfig
+		for _, fIC := range tRC.FileIgnoreConfig {
+			if _, renamed := renamedEntries[fIC.FileName]; !renamed {
+				remaining = append(remaining, fIC)
+			}
+		}
+		var incoming []FileIgnoreConfig
+		for _, fIC := range renamedEntries {
+			incoming = append(incoming, fIC)
+		}
+		tRC.FileIgnoreConfig = combineFileIgnores(remaining, incoming)
+		tRC.saveToFile()
+	}
+}
+
+// IgnoreFor returns the FileIgnoreConfig for exactly the given file name, if there is one
+func (tRC *TalismanRC) IgnoreFor(fileName string) (FileIgnoreConfig, bool) {
+	for _, fIC := range tRC.FileIgnoreConfig {
+		if fIC.FileName == fileName {
+			return fIC, true
+		}
+	}
+	return FileIgnoreConfig{}, false
+}
+
 func combineFileIgnores(exsiting, incoming []FileIgnoreConfig) []FileIgnoreConfig {
 	existingMap := make(map[string]FileIgnoreConfig)
 	for _, fIC := range exsiting {
@@ -116,6 +175,11 @@ func (tRC *TalismanRC) Deny(addition gitrepo.Addition, detectorName string) bool
 			return true
 		}
 	}
+	for _, ignore := range tRC.FileIgnoreConfig {
+		if ignore.IsContentChecksum() && ignore.isEffective(detectorName) && addition.RenamedFromMatches(ignore.GetFileName()) {
+			return true
+		}
+	}
 	return false
 }
 
@@ -140,11 +140,73 @@ func TestIgnoreAdditionsByScope(t *testing.T) {
 	}
 }
 
+func TestIgnoreAdditionsByCustomScope(t *testing.T) {
+	talismanRCConfig := &TalismanRC{
+		ScopeConfig: []ScopeConfig{{ScopeName: "rust"}, {ScopeName: "go"}},
+		CustomScopes: []CustomScopeConfig{
+			{ScopeName: "rust", Files: []string{"Cargo.lock"}},
+			{ScopeName: "go", Files: []string{"*.pb.go"}},
+			{ScopeName: "java", Files: []string{"gradle.lockfile"}},
+		},
+	}
+	additions := []gitrepo.Addition{
+		testAddition("Cargo.lock"),
+		testAddition("go.sum"),
+		testAddition("api/service.pb.go"),
+		testAddition("gradle.lockfile"),
+	}
+
+	filteredAdditions := talismanRCConfig.RemoveScopedFiles(additions)
+
+	assert.Equal(t, []gitrepo.Addition{testAddition("gradle.lockfile")}, filteredAdditions, "Expected only files in enabled scopes to be ignored")
+}
+
 func TestIgnoringDetectors(t *testing.T) {
 	assertDeniesDetector("foo", "someDetector", "foo", "someDetector", t)
 	assertAcceptsDetector("foo", "someDetector", "foo", "someOtherDetector", t)
 }
 
+func TestIgnoringDetectorsForRenamedFiles(t *testing.T) {
+	renamedAddition := testAddition("new/cert.pem")
+	renamedAddition.RenamedFrom = "old/cert.pem"
+	contentChecksumRC := &TalismanRC{FileIgnoreConfig: []FileIgnoreConfig{
+		{FileName: "old/cert.pem", ChecksumVersion: ChecksumVersionContent, IgnoreDetectors: []string{"filecontent"}},
+	}}
+	pathAndContentChecksumRC := createTalismanRCWithFileIgnores("old/cert.pem", "filecontent", []string{})
+
+	assert.True(t, contentChecksumRC.Deny(renamedAddition, "filecontent"), "Expected entries with content checksums to follow renames")
+	assert.False(t, contentChecksumRC.Deny(renamedAddition, "filename"))
+	assert.False(t, pathAndContentChecksumRC.Deny(renamedAddition, "filecontent"), "Expected entries with path and content checksums to stay with their path")
+}
+
+func TestRenamingFileIgnores(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	SetFs__(fs)
+	err := afero.WriteFile(fs, RCFileName, []byte(`fileignoreconfig:
+- filename: b.pem
+  checksum: b-checksum
+- filename: old/cert.pem
+  checksum: old-checksum
+  checksum_version: 2
+  ignore_detectors: [filecontent]
+`), 0666)
+	assert.NoError(t, err)
+
+	initialRCConfig, _ := Load()
+	renamedEntry, found := initialRCConfig.IgnoreFor("old/cert.pem")
+	assert.True(t, found)
+	renamedEntry.FileName = "new/cert.pem"
+	initialRCConfig.RenameIgnores(map[string]FileIgnoreConfig{"old/cert.pem": renamedEntry})
+	newRCConfig, _ := Load()
+
+	assert.Equal(t, []FileIgnoreConfig{
+		{FileName: "b.pem", Checksum: "b-checksum"},
+		{FileName: "new/cert.pem", Checksum: "old-checksum", ChecksumVersion: ChecksumVersionContent, IgnoreDetectors: []string{"filecontent"}},
+	}, newRCConfig.FileIgnoreConfig)
+	_, found = newRCConfig.IgnoreFor("old/cert.pem")
+	assert.False(t, found)
+}
+
 func TestAddingFileIgnores(t *testing.T) {
 	fs := afero.NewMemMapFs()
 	SetFs__(fs)
@@ -175,7 +237,7 @@ func TestAddingFileIgnores(t *testing.T) {
 			AllowedPatterns: []*Pattern{
 				{regexp.MustCompile("this-is-okay")},
 				{regexp.MustCompile("key={listOfThings.id}")}},
-			CustomPatterns: []PatternString{"this-isn't-okay"},
+			CustomPatterns: []CustomPattern{{Regex: "this-isn't-okay"}},
 			Threshold:      severity.Medium,
 			CustomSeverities: []CustomSeverityConfig{
 				{Detector: "HexContent", Severity: severity.Low}},
@@ -1,6 +1,7 @@
 package talismanrc
 
 import (
+	"fmt"
 	"regexp"
 	"talisman/detector/severity"
 
@@ -24,10 +25,52 @@ func (p *Pattern) UnmarshalYAML(unmarshal func(interface{}) error) error {
 		logr.Errorf("Pattern.UmarshalYAML error: %v", err)
 		return err
 	}
-	*p = Pattern{regexp.MustCompile(s)}
+	re, err := regexp.Compile(s)
+	if err != nil {
+		logr.Errorf("Pattern.UmarshalYAML error: %v", err)
+		return fmt.Errorf("invalid pattern %q: %v", s, err)
+	}
+	*p = Pattern{re}
 	return nil
 }
 
+// CustomPattern is a user-defined pattern to look for in file contents.
+// In .talismanrc it is either a bare regex string, or a mapping that names the pattern and narrows where and how it applies.
+type CustomPattern struct {
+	ID           string            `yaml:"id,omitempty"`
+	Description  string            `yaml:"description,omitempty"`
+	Regex        PatternString     `yaml:"regex"`
+	Severity     severity.Severity `yaml:"severity,omitempty"`
+	Paths        []string          `yaml:"paths,omitempty"`
+	ExcludePaths []string          `yaml:"exclude_paths,omitempty"`
+	Keywords     []string          `yaml:"keywords,omitempty"`
+	Entropy      float64           `yaml:"entropy,omitempty"`
+}
+
+// plainCustomPattern has the fields of CustomPattern without its custom (un)marshalling
+type plainCustomPattern CustomPattern
+
+func (c *CustomPattern) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var regex string
+	if err := unmarshal(&regex); err == nil {
+		*c = CustomPattern{Regex: PatternString(regex)}
+		return nil
+	}
+	return unmarshal((*plainCustomPattern)(c))
+}
+
+func (c CustomPattern) MarshalYAML() (interface{}, error) {
+	if c.isBareRegex() {
+		return string(c.Regex), nil
+	}
+	return plainCustomPattern(c), nil
+}
+
+func (c CustomPattern) isBareRegex() bool {
+	return c.ID == "" && c.Description == "" && c.Severity == 0 &&
+		len(c.Paths) == 0 && len(c.ExcludePaths) == 0 && len(c.Keywords) == 0 && c.Entropy == 0
+}
+
 type CustomSeverityConfig struct {
 	Detector string            `yaml:"detector"`
 	Severity severity.Severity `yaml:"severity"`
@@ -36,6 +79,7 @@ type CustomSeverityConfig struct {
 type FileIgnoreConfig struct {
 	FileName        string   `yaml:"filename"`
 	Checksum        string   `yaml:"checksum,omitempty"`
+	ChecksumVersion int      `yaml:"checksum_version,omitempty"`
 	IgnoreDetectors []string `yaml:"ignore_detectors,omitempty"`
 	AllowedPatterns []string `yaml:"allowed_patterns,omitempty"`
 
@@ -55,6 +99,20 @@ func (i *FileIgnoreConfig) ChecksumMatches(incomingChecksum string) bool {
 	return i.Checksum == incomingChecksum
 }
 
+// GetChecksumVersion returns the scheme the checksum of this entry was calculated with
+func (i *FileIgnoreConfig) GetChecksumVersion() int {
+	if i.ChecksumVersion == 0 {
+		return ChecksumVersionPathAndContent
+	}
+	return i.ChecksumVersion
+}
+
+// IsContentChecksum answers true if the checksum of this entry depends on file contents alone,
+// in which case the entry keeps applying to the files it matched after they are renamed
+func (i *FileIgnoreConfig) IsContentChecksum() bool {
+	return i.GetChecksumVersion() == ChecksumVersionContent
+}
+
 func (i *FileIgnoreConfig) GetAllowedPatterns() []*regexp.Regexp {
 	if i.compiledPatterns == nil {
 		i.compiledPatterns = make([]*regexp.Regexp, len(i.AllowedPatterns))
@@ -69,10 +127,34 @@ func IgnoreFileWithChecksum(filename, checksum string) FileIgnoreConfig {
 	return FileIgnoreConfig{FileName: filename, Checksum: checksum}
 }
 
+// IgnoreFileWithVersionedChecksum returns an entry for a checksum calculated with the given scheme.
+// The scheme is only recorded when it is not the default, so that existing entries are written as before.
+func IgnoreFileWithVersionedChecksum(filename, checksum string, checksumVersion int) FileIgnoreConfig {
+	fileIgnoreConfig := IgnoreFileWithChecksum(filename, checksum)
+	if checksumVersion != ChecksumVersionPathAndContent {
+		fileIgnoreConfig.ChecksumVersion = checksumVersion
+	}
+	return fileIgnoreConfig
+}
+
 type ScopeConfig struct {
 	ScopeName string `yaml:"scope"`
 }
 
+// CustomScopeConfig defines a scope, or extends a known one, with the names of files that should be ignored anywhere in a repository
+type CustomScopeConfig struct {
+	ScopeName string   `yaml:"scope"`
+	Files     []string `yaml:"files"`
+}
+
+// CustomFilenamePattern is a user-defined pattern that file names are checked against, in addition to the built-in ones
+type CustomFilenamePattern struct {
+	ID          string            `yaml:"id,omitempty"`
+	Description string            `yaml:"description,omitempty"`
+	Regex       PatternString     `yaml:"regex"`
+	Severity    severity.Severity `yaml:"severity,omitempty"`
+}
+
 type ExperimentalConfig struct {
 	Base64EntropyThreshold float64 `yaml:"base64EntropyThreshold,omitempty"`
 }
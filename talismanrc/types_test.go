@@ -4,6 +4,7 @@ import (
 	"io"
 	"regexp"
 	"strings"
+	"talisman/detector/severity"
 	"testing"
 
 	logr "github.com/sirupsen/logrus"
@@ -63,4 +64,65 @@ func TestFileIgnoreConfig(t *testing.T) {
 		assert.Equal(t, 1, len(allowedPatterns))
 		assert.Regexp(t, allowedPatterns[0], "fileName")
 	})
+
+	t.Run("Defaults to path and content checksums", func(t *testing.T) {
+		fileIgnoreConfig := IgnoreFileWithChecksum("some_filename", "some_checksum")
+
+		assert.Equal(t, ChecksumVersionPathAndContent, fileIgnoreConfig.GetChecksumVersion())
+		assert.False(t, fileIgnoreConfig.IsContentChecksum())
+	})
+
+	t.Run("Records the checksum version only when it is not the default", func(t *testing.T) {
+		pathAndContent := IgnoreFileWithVersionedChecksum("some_filename", "some_checksum", ChecksumVersionPathAndContent)
+		contentOnly := IgnoreFileWithVersionedChecksum("some_filename", "some_checksum", ChecksumVersionContent)
+
+		assert.Equal(t, 0, pathAndContent.ChecksumVersion)
+		assert.Equal(t, ChecksumVersionContent, contentOnly.ChecksumVersion)
+		assert.True(t, contentOnly.IsContentChecksum())
+		assert.Equal(t, "fileignoreconfig:\n- filename: some_filename\n  checksum: some_checksum\n  checksum_version: 2\nversion: \"1.0\"\n",
+			SuggestRCFor([]FileIgnoreConfig{contentOnly}))
+	})
+}
+
+func TestUnmarshallingInvalidPattern(t *testing.T) {
+	fromText := Pattern{}
+	err := yaml.Unmarshal([]byte("'*a(crappy|regex'"), &fromText)
+	assert.Error(t, err, "Should not panic or succeed when unmarshalling an invalid regex")
+}
+
+func TestCustomPatternMarshalling(t *testing.T) {
+	t.Run("Can unmarshal both bare and structured custom patterns", func(t *testing.T) {
+		var patterns []CustomPattern
+		err := yaml.Unmarshal([]byte(`
+- bare-regex
+- id: acme-token
+  description: Acme API token
+  regex: acme_[0-9]{10}
+  severity: medium
+  paths: ["*.sh"]
+  exclude_paths: [test/]
+  keywords: [acme]
+  entropy: 3.2
+`), &patterns)
+		assert.Nil(t, err)
+		assert.Equal(t, []CustomPattern{
+			{Regex: "bare-regex"},
+			{
+				ID:           "acme-token",
+				Description:  "Acme API token",
+				Regex:        "acme_[0-9]{10}",
+				Severity:     severity.Medium,
+				Paths:        []string{"*.sh"},
+				ExcludePaths: []string{"test/"},
+				Keywords:     []string{"acme"},
+				Entropy:      3.2,
+			},
+		}, patterns)
+	})
+
+	t.Run("Marshals bare custom patterns as strings", func(t *testing.T) {
+		str, err := yaml.Marshal([]CustomPattern{{Regex: "bare-regex"}, {ID: "named", Regex: "named-regex"}})
+		assert.Nil(t, err)
+		assert.Equal(t, "- bare-regex\n- id: named\n  regex: named-regex\n", string(str))
+	})
 }
@@ -0,0 +1,365 @@
+package talismanrc
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"talisman/detector/severity"
+	"talisman/examples"
+
+	"github.com/spf13/afero"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ValidationError describes a single problem found in a .talismanrc file, along with where it was found
+type ValidationError struct {
+	File    string
+	Line    int
+	Column  int
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Line == 0 {
+		return fmt.Sprintf("%s: %s", e.File, e.Message)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Message)
+}
+
+// schema is the subset of JSON schema used to describe the .talismanrc format
+type schema struct {
+	Type                 string             `json:"type"`
+	Format               string             `json:"format"`
+	Enum                 []interface{}      `json:"enum"`
+	Required             []string           `json:"required"`
+	Properties           map[string]*schema `json:"properties"`
+	AdditionalProperties *bool              `json:"additionalProperties"`
+	Items                *schema            `json:"items"`
+	OneOf                []*schema          `json:"oneOf"`
+}
+
+var rcSchema = mustParseSchema(examples.TalismanRCSchema)
+
+// semanticChecks validate values whose allowed set is only known at runtime, keyed by schema path
+var semanticChecks = map[string]func(v *validator, value string) string{
+	"custom_severities[].detector": func(v *validator, value string) string {
+		if _, ok := severity.SeverityConfiguration[value]; !ok {
+			return fmt.Sprintf("unknown detector %q%s", value, suggestion(value, knownDetectorNames()))
+		}
+		return ""
+	},
+	"disabled_filename_patterns[]": func(v *validator, value string) string {
+		if !contains(filenamePatternIDs, value) {
+			return fmt.Sprintf("unknown filename pattern %q%s", value, suggestion(value, filenamePatternIDs))
+		}
+		return ""
+	},
+	"scopeconfig[].scope": func(v *validator, value string) string {
+		if _, ok := knownScopes[value]; !ok && !v.customScopes[value] {
+			return fmt.Sprintf("unknown scope %q%s", value, suggestion(value, v.scopeNames()))
+		}
+		return ""
+	},
+}
+
+// caseInsensitiveEnums are the schema paths of severities, which are read whatever their case, like severity.FromString
+var caseInsensitiveEnums = map[string]bool{
+	"threshold":                           true,
+	"custom_severities[].severity":        true,
+	"custom_patterns[].severity":          true,
+	"custom_filename_patterns[].severity": true,
+}
+
+var yamlErrorLinePattern = regexp.MustCompile(`line (\d+)`)
+
+func mustParseSchema(data []byte) *schema {
+	s := &schema{}
+	if err := json.Unmarshal(data, s); err != nil {
+		panic(fmt.Sprintf("invalid .talismanrc schema: %v", err))
+	}
+	return s
+}
+
+// Validate checks the .talismanrc file in the current directory against the .talismanrc schema.
+// An error is returned, rather than any problem, if the file cannot be read.
+func Validate() ([]ValidationError, error) {
+	fileContents, err := afero.ReadFile(fs, RCFileName)
+	if err != nil {
+		return nil, err
+	}
+	return ValidateContents(RCFileName, fileContents), nil
+}
+
+// ValidateContents checks the supplied .talismanrc contents against the .talismanrc schema.
+// Every problem found is returned, each with the line and column it was found at.
+func ValidateContents(fileName string, fileContents []byte) []ValidationError {
+	v := &validator{file: fileName}
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(fileContents, &document); err != nil {
+		line := 0
+		if match := yamlErrorLinePattern.FindStringSubmatch(err.Error()); match != nil {
+			line, _ = strconv.Atoi(match[1])
+		}
+		return []ValidationError{{File: fileName, Line: line, Column: 1, Message: err.Error()}}
+	}
+	if len(document.Content) == 0 {
+		return nil
+	}
+	v.collectCustomScopes(document.Content[0])
+	v.check(document.Content[0], rcSchema, "", "")
+	return v.errors
+}
+
+type validator struct {
+	file         string
+	errors       []ValidationError
+	customScopes map[string]bool
+}
+
+// collectCustomScopes records the scopes defined in the document, so that they can be referred to anywhere in it
+func (v *validator) collectCustomScopes(root *yamlv3.Node) {
+	v.customScopes = map[string]bool{}
+	if root.Kind != yamlv3.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "custom_scopes" || root.Content[i+1].Kind != yamlv3.SequenceNode {
+			continue
+		}
+		for _, customScope := range root.Content[i+1].Content {
+			var scope CustomScopeConfig
+			if customScope.Decode(&scope) == nil && scope.ScopeName != "" {
+				v.customScopes[scope.ScopeName] = true
+			}
+		}
+	}
+}
+
+func (v *validator) fail(node *yamlv3.Node, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{
+		File:    v.file,
+		Line:    node.Line,
+		Column:  node.Column,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+// check validates node against s. location is used for messages, schemaPath to look up semantic checks.
+func (v *validator) check(node *yamlv3.Node, s *schema, location, schemaPath string) {
+	if node.Kind == yamlv3.AliasNode {
+		node = node.Alias
+	}
+	if node.Kind == yamlv3.ScalarNode && node.Tag == "!!null" {
+		return
+	}
+	if len(s.OneOf) > 0 {
+		v.checkOneOf(node, s, location, schemaPath)
+		return
+	}
+	switch s.Type {
+	case "object":
+		v.checkObject(node, s, location, schemaPath)
+	case "array":
+		if node.Kind != yamlv3.SequenceNode {
+			v.fail(node, "%s should be a list", describe(location))
+			return
+		}
+		if s.Items == nil {
+			return
+		}
+		for i, item := range node.Content {
+			v.check(item, s.Items, fmt.Sprintf("%s[%d]", location, i), schemaPath+"[]")
+		}
+	case "string":
+		if node.Kind != yamlv3.ScalarNode {
+			v.fail(node, "%s should be a string", describe(location))
+			return
+		}
+		v.checkString(node, s, location, schemaPath)
+	case "number":
+		if node.Kind != yamlv3.ScalarNode || (node.Tag != "!!int" && node.Tag != "!!float") {
+			v.fail(node, "%s should be a number", describe(location))
+		}
+	case "integer":
+		if node.Kind != yamlv3.ScalarNode || node.Tag != "!!int" {
+			v.fail(node, "%s should be a whole number", describe(location))
+			return
+		}
+		v.checkEnum(node, s, location, schemaPath)
+	}
+}
+
+// checkOneOf validates node against the alternative whose type matches the kind of node
+func (v *validator) checkOneOf(node *yamlv3.Node, s *schema, location, schemaPath string) {
+	var types []string
+	for _, alternative := range s.OneOf {
+		if alternative.acceptsKind(node.Kind) {
+			v.check(node, alternative, location, schemaPath)
+			return
+		}
+		types = append(types, alternative.Type)
+	}
+	v.fail(node, "%s should be one of: %s", describe(location), strings.Join(types, ", "))
+}
+
+func (s *schema) acceptsKind(kind yamlv3.Kind) bool {
+	switch s.Type {
+	case "object":
+		return kind == yamlv3.MappingNode
+	case "array":
+		return kind == yamlv3.SequenceNode
+	default:
+		return kind == yamlv3.ScalarNode
+	}
+}
+
+func (v *validator) checkObject(node *yamlv3.Node, s *schema, location, schemaPath string) {
+	if node.Kind != yamlv3.MappingNode {
+		v.fail(node, "%s should be a mapping", describe(location))
+		return
+	}
+	seen := map[string]bool{}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keyNode, valueNode := node.Content[i], node.Content[i+1]
+		key := keyNode.Value
+		seen[key] = true
+		propertySchema, known := s.Properties[key]
+		if !known {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				v.fail(keyNode, "unknown key %q in %s%s", key, describe(location), suggestion(key, s.propertyNames()))
+			}
+			continue
+		}
+		v.check(valueNode, propertySchema, joinLocation(location, key), joinLocation(schemaPath, key))
+	}
+	for _, required := range s.Required {
+		if !seen[required] {
+			v.fail(node, "missing required key %q in %s", required, describe(location))
+		}
+	}
+}
+
+func (v *validator) checkEnum(node *yamlv3.Node, s *schema, location, schemaPath string) {
+	value := node.Value
+	if caseInsensitiveEnums[schemaPath] {
+		value = strings.ToLower(value)
+	}
+	if len(s.Enum) > 0 && !contains(s.enumValues(), value) {
+		v.fail(node, "invalid value %q for %s, expected one of: %s", node.Value, describe(location), strings.Join(s.enumValues(), ", "))
+	}
+}
+
+func (v *validator) checkString(node *yamlv3.Node, s *schema, location, schemaPath string) {
+	v.checkEnum(node, s, location, schemaPath)
+	if s.Format == "regex" {
+		if _, err := regexp.Compile(node.Value); err != nil {
+			v.fail(node, "invalid regular expression %q for %s: %v", node.Value, describe(location), err)
+		}
+	}
+	if semanticCheck, ok := semanticChecks[schemaPath]; ok {
+		if message := semanticCheck(v, node.Value); message != "" {
+			v.fail(node, "%s in %s", message, describe(location))
+		}
+	}
+}
+
+func (s *schema) enumValues() []string {
+	var values []string
+	for _, value := range s.Enum {
+		values = append(values, fmt.Sprint(value))
+	}
+	return values
+}
+
+func (s *schema) propertyNames() []string {
+	var names []string
+	for name := range s.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func joinLocation(parent, key string) string {
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
+
+func describe(location string) string {
+	if location == "" {
+		return "the top level"
+	}
+	return location
+}
+
+func knownDetectorNames() []string {
+	var names []string
+	for name := range severity.SeverityConfiguration {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (v *validator) scopeNames() []string {
+	var names []string
+	for name := range knownScopes {
+		names = append(names, name)
+	}
+	for name := range v.customScopes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// suggestion returns a hint naming the candidate closest to value, if any is close enough to be a likely typo
+func suggestion(value string, candidates []string) string {
+	best, bestDistance := "", len(value)/2+1
+	for _, candidate := range candidates {
+		distance := editDistance(strings.ToLower(value), strings.ToLower(candidate))
+		if distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// FormatValidationErrors renders validation errors for display on the console
+func FormatValidationErrors(validationErrors []ValidationError) string {
+	result := strings.Builder{}
+	result.WriteString(fmt.Sprintf("\n\x1b[1m\x1b[31m%s is invalid:\x1b[0m\x1b[0m\n", RCFileName))
+	for _, validationError := range validationErrors {
+		result.WriteString(fmt.Sprintf("  %s\n", validationError.Error()))
+	}
+	return result.String()
+}
@@ -0,0 +1,232 @@
+package talismanrc
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatingTalismanRC(t *testing.T) {
+	t.Run("Accepts a fully configured .talismanrc", func(t *testing.T) {
+		assert.Empty(t, ValidateContents(RCFileName, []byte(fullyConfiguredTalismanRC)))
+	})
+
+	t.Run("Accepts an empty or commented .talismanrc", func(t *testing.T) {
+		assert.Empty(t, ValidateContents(RCFileName, []byte("")))
+		assert.Empty(t, ValidateContents(RCFileName, []byte("# nothing to see here")))
+	})
+
+	t.Run("Reports unknown keys with their position", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+fileignoreconfigs:
+- filename: a.pem
+`))
+		assert.Len(t, errors, 1)
+		assert.Equal(t, 2, errors[0].Line)
+		assert.Equal(t, 1, errors[0].Column)
+		assert.Equal(t, `.talismanrc:2:1: unknown key "fileignoreconfigs" in the top level (did you mean "fileignoreconfig"?)`, errors[0].Error())
+	})
+
+	t.Run("Reports unknown keys in nested entries", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+fileignoreconfig:
+- filename: a.pem
+  ignore_detector: [filename]
+`))
+		assert.Len(t, errors, 1)
+		assert.Equal(t, 4, errors[0].Line)
+		assert.Equal(t, 3, errors[0].Column)
+		assert.Contains(t, errors[0].Message, `unknown key "ignore_detector" in fileignoreconfig[0]`)
+	})
+
+	t.Run("Reports invalid regular expressions", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+allowed_patterns:
+- ok
+- '*a(crappy|regex'
+custom_patterns:
+- '[unclosed'
+fileignoreconfig:
+- filename: a.txt
+  allowed_patterns: ['(']
+`))
+		assert.Len(t, errors, 3)
+		assert.Equal(t, 4, errors[0].Line)
+		assert.Contains(t, errors[0].Message, "invalid regular expression \"*a(crappy|regex\" for allowed_patterns[1]")
+		assert.Equal(t, 6, errors[1].Line)
+		assert.Contains(t, errors[1].Message, "custom_patterns[0]")
+		assert.Equal(t, 9, errors[2].Line)
+		assert.Contains(t, errors[2].Message, "fileignoreconfig[0].allowed_patterns[0]")
+	})
+
+	t.Run("Validates structured custom patterns", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+custom_patterns:
+- plain
+- id: acme
+  regex: 'acme_('
+  severity: urgent
+  path: ["*.sh"]
+- [not, a, pattern]
+`))
+		assert.Len(t, errors, 4)
+		assert.Contains(t, errors[0].Message, "invalid regular expression")
+		assert.Contains(t, errors[1].Message, `invalid value "urgent" for custom_patterns[1].severity`)
+		assert.Contains(t, errors[2].Message, `unknown key "path" in custom_patterns[1] (did you mean "paths"?)`)
+		assert.Contains(t, errors[3].Message, "custom_patterns[2] should be one of: string, object")
+	})
+
+	t.Run("Reports unknown detectors", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+fileignoreconfig:
+- filename: a.txt
+  ignore_detectors: [filecontents]
+custom_severities:
+- detector: HexContnt
+  severity: low
+`))
+		assert.Len(t, errors, 2)
+		assert.Equal(t, 4, errors[0].Line)
+		assert.Equal(t, 22, errors[0].Column)
+		assert.Contains(t, errors[0].Message, `invalid value "filecontents"`)
+		assert.Equal(t, 6, errors[1].Line)
+		assert.Contains(t, errors[1].Message, `unknown detector "HexContnt" (did you mean "HexContent"?) in custom_severities[0].detector`)
+	})
+
+	t.Run("Accepts every detector that can be ignored for a file", func(t *testing.T) {
+		assert.Empty(t, ValidateContents(RCFileName, []byte(`
+fileignoreconfig:
+- filename: a.txt
+  ignore_detectors: [filecontent, filename, filesize, metadata, kubernetes, terraform, privatekey]
+`)))
+	})
+
+	t.Run("Accepts every detector that can be disabled", func(t *testing.T) {
+		assert.Empty(t, ValidateContents(RCFileName, []byte(
+			"disabled_detectors: [filecontent, filename, metadata, pattern, kubernetes, terraform, privatekey]\n")))
+	})
+
+	t.Run("Reports unknown scopes", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+scopeconfig:
+- scope: node
+- scope: rust
+`))
+		assert.Len(t, errors, 1)
+		assert.Equal(t, 4, errors[0].Line)
+		assert.Contains(t, errors[0].Message, `unknown scope "rust" in scopeconfig[1].scope`)
+	})
+
+	t.Run("Accepts scopes defined in the file itself", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+scopeconfig:
+- scope: rust
+custom_scopes:
+- scope: rust
+  files: [Cargo.lock]
+`))
+		assert.Empty(t, errors)
+	})
+
+	t.Run("Validates filename patterns", func(t *testing.T) {
+		RegisterFilenamePatterns("EnvFile", "PrivateKeyFile")
+		errors := ValidateContents(RCFileName, []byte(`
+custom_filename_patterns:
+- regex: '^.*\.tfvars$'
+  description: Terraform variables
+- regex: '(unclosed'
+disabled_filename_patterns: [EnvFile, EnvFiles, NoSuchPattern, Base64Content, PrivateKey]
+`))
+		assert.Len(t, errors, 5)
+		assert.Contains(t, errors[0].Message, "invalid regular expression")
+		assert.Contains(t, errors[1].Message, `unknown filename pattern "EnvFiles" (did you mean "EnvFile"?)`)
+		assert.Contains(t, errors[2].Message, `unknown filename pattern "NoSuchPattern"`)
+		assert.Contains(t, errors[3].Message, `unknown filename pattern "Base64Content"`)
+		assert.Equal(t, `unknown filename pattern "PrivateKey" (did you mean "PrivateKeyFile"?) in disabled_filename_patterns[4]`, errors[4].Message)
+	})
+
+	t.Run("Reports values of the wrong type", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+threshold: severe
+fileignoreconfig: a.txt
+experimental:
+  base64EntropyThreshold: high
+`))
+		assert.Len(t, errors, 3)
+		assert.Contains(t, errors[0].Message, `invalid value "severe" for threshold`)
+		assert.Contains(t, errors[1].Message, "fileignoreconfig should be a list")
+		assert.Contains(t, errors[2].Message, "experimental.base64EntropyThreshold should be a number")
+	})
+
+	t.Run("Validates checksum versions", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+checksum_version: 2
+fileignoreconfig:
+- filename: a.pem
+  checksum: abc
+  checksum_version: 3
+- filename: b.pem
+  checksum: abc
+  checksum_version: two
+`))
+		assert.Len(t, errors, 2)
+		assert.Contains(t, errors[0].Message, `invalid value "3" for fileignoreconfig[0].checksum_version, expected one of: 1, 2`)
+		assert.Contains(t, errors[1].Message, "fileignoreconfig[1].checksum_version should be a whole number")
+	})
+
+	t.Run("Reports missing required keys", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte(`
+fileignoreconfig:
+- checksum: abc
+`))
+		assert.Len(t, errors, 1)
+		assert.Contains(t, errors[0].Message, `missing required key "filename" in fileignoreconfig[0]`)
+	})
+
+	t.Run("Accepts severities whatever their case", func(t *testing.T) {
+		assert.Empty(t, ValidateContents(RCFileName, []byte(`
+threshold: Medium
+custom_severities:
+- detector: Base64Content
+  severity: High
+custom_patterns:
+- regex: 'token-[0-9]+'
+  severity: LOW
+custom_filename_patterns:
+- regex: '^.*\.tfvars$'
+  severity: hIgH
+`)))
+		errors := ValidateContents(RCFileName, []byte("threshold: Severe\n"))
+		assert.Len(t, errors, 1)
+		assert.Contains(t, errors[0].Message, `invalid value "Severe" for threshold`)
+	})
+
+	t.Run("Reports yaml syntax errors with their line", func(t *testing.T) {
+		errors := ValidateContents(RCFileName, []byte("fileignoreconfig:\n- filename: a\n b: c\n  - d"))
+		assert.Len(t, errors, 1)
+		assert.NotZero(t, errors[0].Line)
+	})
+}
+
+func TestLoadingAnInvalidTalismanRC(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	SetFs__(fs)
+	err := afero.WriteFile(fs, RCFileName, []byte("allowed_patterns: ['*a(crappy|regex']"), 0666)
+	assert.NoError(t, err, "Problem setting up test .talismanrc?")
+
+	_, err = Load()
+	assert.Error(t, err, "Should not load a .talismanrc that fails validation")
+	validationErrors, err := Validate()
+	assert.NoError(t, err)
+	assert.Len(t, validationErrors, 1)
+}
+
+func TestValidatingAMissingTalismanRC(t *testing.T) {
+	SetFs__(afero.NewMemMapFs())
+
+	validationErrors, err := Validate()
+	assert.True(t, os.IsNotExist(err), "Should report that there is no .talismanrc to validate")
+	assert.Empty(t, validationErrors)
+}
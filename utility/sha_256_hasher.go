@@ -3,6 +3,8 @@ package utility
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"sort"
+	"strings"
 	"talisman/gitrepo"
 
 	"github.com/sirupsen/logrus"
@@ -10,6 +12,7 @@ import (
 
 type SHA256Hasher interface {
 	CollectiveSHA256Hash(paths []string) string
+	ContentSHA256Hash(paths []string) string
 	Start() error
 	Shutdown() error
 }
@@ -21,9 +24,44 @@ func (*DefaultSHA256Hasher) CollectiveSHA256Hash(paths []string) string {
 	return collectiveSHA256Hash(paths, SafeReadFile)
 }
 
+//ContentSHA256Hash return sha256 hash of the contents of the passed paths, independent of their names and order
+func (*DefaultSHA256Hasher) ContentSHA256Hash(paths []string) string {
+	return contentSHA256Hash(paths, SafeReadFile)
+}
+
 func (*DefaultSHA256Hasher) Start() error    { return nil }
 func (*DefaultSHA256Hasher) Shutdown() error { return nil }
 
+// additionsSHA256Hasher hashes the contents of additions as they were read, such as content piped to talisman, rather
+// than the files at their paths
+type additionsSHA256Hasher struct {
+	contents map[string][]byte
+}
+
+//NewAdditionsSHA256Hasher returns a hasher of the contents of the given additions
+func NewAdditionsSHA256Hasher(additions []gitrepo.Addition) SHA256Hasher {
+	contents := make(map[string][]byte, len(additions))
+	for _, addition := range additions {
+		contents[string(addition.Path)] = addition.Data
+	}
+	return &additionsSHA256Hasher{contents}
+}
+
+func (a *additionsSHA256Hasher) CollectiveSHA256Hash(paths []string) string {
+	return collectiveSHA256Hash(paths, a.read)
+}
+
+func (a *additionsSHA256Hasher) ContentSHA256Hash(paths []string) string {
+	return contentSHA256Hash(paths, a.read)
+}
+
+func (a *additionsSHA256Hasher) read(path string) ([]byte, error) {
+	return a.contents[path], nil
+}
+
+func (*additionsSHA256Hasher) Start() error    { return nil }
+func (*additionsSHA256Hasher) Shutdown() error { return nil }
+
 type gitBatchSHA256Hasher struct {
 	br gitrepo.BatchReader
 }
@@ -32,6 +70,10 @@ func (g *gitBatchSHA256Hasher) CollectiveSHA256Hash(paths []string) string {
 	return collectiveSHA256Hash(paths, g.br.Read)
 }
 
+func (g *gitBatchSHA256Hasher) ContentSHA256Hash(paths []string) string {
+	return contentSHA256Hash(paths, g.br.Read)
+}
+
 func (g *gitBatchSHA256Hasher) Start() error {
 	return g.br.Start()
 }
@@ -63,6 +105,18 @@ func collectiveSHA256Hash(paths []string, FileReader func(string) ([]byte, error
 	return m
 }
 
+func contentSHA256Hash(paths []string, FileReader func(string) ([]byte, error)) string {
+	var fileHashes []string
+	for _, path := range paths {
+		fileBytes, _ := FileReader(path)
+		fileHashes = append(fileHashes, hashByte(&fileBytes))
+	}
+	fileHashes = UniqueItems(fileHashes)
+	sort.Strings(fileHashes)
+	c := []byte(strings.Join(fileHashes, ""))
+	return hashByte(&c)
+}
+
 var hashers = make(map[string]SHA256Hasher)
 
 //MakeHasher returns a SHA256 file/object hasher based on mode and a repo root
@@ -73,7 +127,7 @@ func MakeHasher(mode string, root string) SHA256Hasher {
 	switch mode {
 	case "pre-push":
 		hashers[mode] = &gitBatchSHA256Hasher{gitrepo.NewBatchGitHeadPathReader(root)}
-	case "pre-commit":
+	case "pre-commit", "commit-msg", "prepare-commit-msg":
 		hashers[mode] = &gitBatchSHA256Hasher{gitrepo.NewBatchGitStagedPathReader(root)}
 	case "scan":
 		hashers[mode] = &gitBatchSHA256Hasher{gitrepo.NewBatchGitObjectHashReader(root)}
@@ -2,6 +2,8 @@ package utility
 
 import (
 	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -18,3 +20,22 @@ func TestShouldReturnEmptyFileHashWhenNoPathsPassed(t *testing.T) {
 	checksum := hasher.CollectiveSHA256Hash([]string{})
 	assert.Equal(t, checksum, "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "Should be equal to empty hash value when no paths passed")
 }
+
+func TestContentHashShouldNotDependOnFilePaths(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "some_file.pem")
+	renamed := filepath.Join(dir, "test", "some_file.pem")
+	other := filepath.Join(dir, "other_file.pem")
+	assert.NoError(t, os.MkdirAll(filepath.Dir(renamed), 0755))
+	assert.NoError(t, os.WriteFile(original, []byte("certificate"), 0644))
+	assert.NoError(t, os.WriteFile(renamed, []byte("certificate"), 0644))
+	assert.NoError(t, os.WriteFile(other, []byte("another certificate"), 0644))
+	hasher := DefaultSHA256Hasher{}
+
+	checksum := hasher.ContentSHA256Hash([]string{original})
+
+	assert.Equal(t, checksum, hasher.ContentSHA256Hash([]string{renamed}), "Should be equal for files with the same contents")
+	assert.Equal(t, checksum, hasher.ContentSHA256Hash([]string{original, renamed}), "Should be equal for copies of the same file")
+	assert.NotEqual(t, checksum, hasher.ContentSHA256Hash([]string{other}), "Should differ for files with different contents")
+	assert.Equal(t, hasher.ContentSHA256Hash([]string{original, other}), hasher.ContentSHA256Hash([]string{other, original}), "Should not depend on the order of files")
+}